@@ -77,13 +77,16 @@ func (a *goBlog) toAPNote(p *post) *ap.Note {
 	}
 	// Content
 	note.MediaType = ap.MimeType(contenttype.HTML)
-	note.Content = ap.NaturalLanguageValues{{Lang: bc.Lang, Value: a.postHtml(&postHtmlOptions{p: p, absolute: true, activityPub: true})}}
+	note.Content = ap.NaturalLanguageValues{{Lang: bc.Lang, Value: a.postHtml(&postHtmlOptions{p: p, absolute: true, activityPub: true}) + a.postSeriesHtml(p)}}
 	// Attachments
 	if images := p.Parameters[a.cfg.Micropub.PhotoParam]; len(images) > 0 {
 		var attachments ap.ItemCollection
 		for _, image := range images {
 			apImage := ap.ObjectNew(ap.ImageType)
 			apImage.URL = ap.IRI(image)
+			if metadata, err := a.db.getMediaMetadata(image); err == nil && metadata != nil && metadata.width > 0 {
+				apImage.Width, apImage.Height, apImage.Blurhash = metadata.width, metadata.height, metadata.blurhash
+			}
 			attachments.Append(apImage)
 		}
 		note.Attachment = attachments
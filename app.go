@@ -58,8 +58,11 @@ type goBlog struct {
 	pDeleteHooks   []postHookFunc
 	pUndeleteHooks []postHookFunc
 	hourlyHooks    []hourlyHookFunc
+	postHooksWg    sync.WaitGroup
 	// HTTP Client
 	httpClient *http.Client
+	// HTTP Client for URLs of third parties, only connects to public addresses
+	publicHttpClient *http.Client
 	// HTTP Routers
 	d http.Handler
 	// IndexNow
@@ -93,14 +96,21 @@ type goBlog struct {
 	// Profile image
 	profileImageHashString string
 	profileImageHashGroup  *sync.Once
+	// Posting queue
+	postingQueueMutex sync.Mutex
 	// Reactions
 	reactionsInit  sync.Once
 	reactionsCache *c.Cache[string, string]
 	reactionsSfg   singleflightx.Group[string, string]
+	// Related posts
+	relatedPostsInit  sync.Once
+	relatedPostsCache *c.Cache[string, []string]
 	// Regex Redirects
 	regexRedirects []*regexRedirect
 	// Sessions
-	loginSessions, captchaSessions, webauthnSessions *dbSessionStore
+	loginSessions, captchaSessions, webauthnSessions, postUnlockSessions *dbSessionStore
+	// Post unlock attempts
+	postUnlockRateLimiter rateLimiter
 	// Shutdown
 	shutdown shutdowner.Shutdowner
 	// Template strings
@@ -110,4 +120,6 @@ type goBlog struct {
 	torHostname string
 	// WebAuthn
 	webAuthn *webauthn.WebAuthn
+	// WebSub
+	webSubRateLimiter rateLimiter
 }
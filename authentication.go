@@ -209,7 +209,7 @@ func (a *goBlog) serveLogout(w http.ResponseWriter, r *http.Request) {
 func (a *goBlog) getDefaultPostStates(r *http.Request) (status []postStatus, visibility []postVisibility) {
 	if a.isLoggedIn(r) {
 		status = []postStatus{statusPublished}
-		visibility = []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate}
+		visibility = []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate, visibilityProtected, visibilityLink}
 	} else {
 		status = []postStatus{statusPublished}
 		visibility = []postVisibility{visibilityPublic}
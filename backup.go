@@ -0,0 +1,337 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.goblog.app/app/pkgs/utils"
+)
+
+const (
+	backupVersion = 1
+
+	backupManifestFile     = "manifest.json"
+	backupDatabaseFile     = "database.db"
+	backupMediaDir         = "media/"
+	backupProfileImageFile = "profileImage"
+	backupAPKeyFile        = "activitypub.key"
+
+	settingsBackupPath = "/backup"
+)
+
+type backupManifest struct {
+	Version int       `json:"version"`
+	Schema  string    `json:"schema"`
+	Created time.Time `json:"created"`
+}
+
+// writeBackup writes a gzipped tar archive with a snapshot of the database, all media files,
+// the profile image and the ActivityPub private key.
+func (a *goBlog) writeBackup(w io.Writer) error {
+	schema, err := a.db.schemaVersion()
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	addFile := func(name string, size int64, modTime time.Time, r io.Reader) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: name, Size: size, Mode: 0o644, ModTime: modTime, Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, r)
+		return err
+	}
+	addBytes := func(name string, data []byte) error {
+		return addFile(name, int64(len(data)), time.Now(), bytes.NewReader(data))
+	}
+	addLocalFile := func(name, file string) error {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return addFile(name, info.Size(), info.ModTime(), f)
+	}
+	// Manifest first, so it can be checked before restoring anything
+	manifest, err := json.Marshal(&backupManifest{Version: backupVersion, Schema: schema, Created: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err = addBytes(backupManifestFile, manifest); err != nil {
+		return err
+	}
+	// Consistent database snapshot
+	tmpDir, err := os.MkdirTemp("", "goblog-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	snapshot := filepath.Join(tmpDir, backupDatabaseFile)
+	if _, err = a.db.Exec("vacuum into ?", snapshot); err != nil {
+		return fmt.Errorf("failed to create database snapshot: %w", err)
+	}
+	if err = addLocalFile(backupDatabaseFile, snapshot); err != nil {
+		return err
+	}
+	// Media files
+	files, err := a.mediaFiles()
+	if err != nil && !errors.Is(err, errNoMediaStorageConfigured) {
+		return err
+	}
+	for _, mf := range files {
+		if err = a.backupMediaFile(mf, addFile, addLocalFile); err != nil {
+			return fmt.Errorf("failed to backup media file %s: %w", mf.Name, err)
+		}
+	}
+	// Profile image
+	if a.hasProfileImage() {
+		if err = addLocalFile(backupProfileImageFile, a.cfg.User.ProfileImageFile); err != nil {
+			return err
+		}
+	}
+	// ActivityPub key
+	if key, err := a.db.retrievePersistentCache("activitypub_key"); err != nil {
+		return err
+	} else if key != nil {
+		if err = addBytes(backupAPKeyFile, key); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func (a *goBlog) backupMediaFile(
+	mf *mediaFile,
+	addFile func(string, int64, time.Time, io.Reader) error,
+	addLocalFile func(string, string) error,
+) error {
+	name := backupMediaDir + mf.Name
+	if l, ok := a.mediaStorage.(*localMediaStorage); ok {
+		return addLocalFile(name, filepath.Join(l.path, mf.Name))
+	}
+	// Remote storage, download the file
+	resp, err := a.httpClient.Get(a.getFullAddress(mf.Location))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return addFile(name, int64(len(data)), mf.Time, bytes.NewReader(data))
+}
+
+// restoreBackup restores a backup archive and initializes the config with the restored database.
+// It must be called before the database is initialized.
+// An existing database is only replaced if force is set.
+// The whole archive is extracted and checked before anything is replaced,
+// so a truncated or corrupt archive doesn't leave a partially restored blog.
+func (a *goBlog) restoreBackup(file string, force bool) error {
+	if a.db != nil {
+		return errors.New("database already initialized")
+	}
+	// Check database
+	dbFile := a.cfg.Db.File
+	if _, err := os.Stat(dbFile); err == nil && !force {
+		return fmt.Errorf("database %s already exists", dbFile)
+	}
+	// Extract archive to a temporary directory
+	tmpDir, err := os.MkdirTemp("", "goblog-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	restore, err := extractBackup(file, tmpDir)
+	if err != nil {
+		return err
+	}
+	// Replace database
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err = os.Remove(dbFile + suffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err = copyFile(filepath.Join(tmpDir, backupDatabaseFile), dbFile, os.ModePerm, 0666); err != nil {
+		return err
+	}
+	// Migrate database to the current schema and initialize config
+	if err = a.initConfig(false); err != nil {
+		return err
+	}
+	// Restore files
+	for _, mediaName := range restore.media {
+		if err = a.restoreMediaFile(filepath.Join(tmpDir, backupMediaDir, mediaName), mediaName); err != nil {
+			return fmt.Errorf("failed to restore media file %s: %w", mediaName, err)
+		}
+	}
+	if restore.profileImage {
+		if err = copyFile(filepath.Join(tmpDir, backupProfileImageFile), a.cfg.User.ProfileImageFile, 0777, 0755); err != nil {
+			return err
+		}
+		a.profileImageHashGroup = nil
+	}
+	if restore.apKey != nil {
+		if err = a.db.cachePersistently("activitypub_key", restore.apKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type extractedBackup struct {
+	media        []string
+	profileImage bool
+	apKey        []byte
+}
+
+// extractBackup reads the whole backup archive into dir and checks the manifest, the database and the file names.
+func extractBackup(file, dir string) (*extractedBackup, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	// Check manifest
+	header, err := tr.Next()
+	if err != nil || header.Name != backupManifestFile {
+		return nil, errors.New("backup archive has no manifest")
+	}
+	var manifest backupManifest
+	if err = json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+	if manifest.Version != backupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", manifest.Version)
+	}
+	migrations, err := dbMigrationNames()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(migrations, manifest.Schema) {
+		return nil, fmt.Errorf("backup has unknown database schema %q, it was probably created by a newer version", manifest.Schema)
+	}
+	// Database is the first file after the manifest
+	header, err = tr.Next()
+	if err != nil || header.Name != backupDatabaseFile {
+		return nil, errors.New("backup archive has no database")
+	}
+	if err = utils.SaveToFile(tr, filepath.Join(dir, backupDatabaseFile)); err != nil {
+		return nil, fmt.Errorf("failed to read backup database: %w", err)
+	}
+	// Other files
+	extracted := &extractedBackup{}
+	for {
+		header, err = tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+		switch name := header.Name; {
+		case strings.HasPrefix(name, backupMediaDir):
+			mediaName := path.Base(name)
+			if !isValidMediaFilename(mediaName) {
+				return nil, fmt.Errorf("invalid media filename: %s", mediaName)
+			}
+			if err = utils.SaveToFile(tr, filepath.Join(dir, backupMediaDir, mediaName)); err != nil {
+				return nil, fmt.Errorf("failed to read media file %s: %w", mediaName, err)
+			}
+			extracted.media = append(extracted.media, mediaName)
+		case name == backupProfileImageFile:
+			if err = utils.SaveToFile(tr, filepath.Join(dir, backupProfileImageFile)); err != nil {
+				return nil, fmt.Errorf("failed to read profile image: %w", err)
+			}
+			extracted.profileImage = true
+		case name == backupAPKeyFile:
+			if extracted.apKey, err = io.ReadAll(tr); err != nil {
+				return nil, fmt.Errorf("failed to read ActivityPub key: %w", err)
+			}
+		}
+	}
+	// Check database integrity
+	if err = checkBackupDatabase(filepath.Join(dir, backupDatabaseFile)); err != nil {
+		return nil, err
+	}
+	return extracted, nil
+}
+
+func checkBackupDatabase(file string) error {
+	db, err := sql.Open("sqlite3", "file:"+file+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err = db.QueryRow("pragma quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("backup database is corrupt: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup database is corrupt: %s", result)
+	}
+	return nil
+}
+
+func (a *goBlog) restoreMediaFile(file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = a.saveMediaFile(name, f)
+	return err
+}
+
+func copyFile(src, dst string, dirMode, fileMode fs.FileMode) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return utils.SaveToFileWithMode(f, dst, dirMode, fileMode)
+}
+
+func (a *goBlog) serveBackup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(contentType, "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", backupFileName(time.Now())))
+	if err := a.writeBackup(w); err != nil {
+		// Headers are probably already sent, so just log the error
+		a.error("Failed to create backup", "err", err)
+	}
+}
+
+func backupFileName(t time.Time) string {
+	return "goblog-backup-" + t.Format("20060102-150405") + ".tar.gz"
+}
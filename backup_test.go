@@ -0,0 +1,169 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_backup(t *testing.T) {
+	newApp := func(t *testing.T) *goBlog {
+		app := &goBlog{
+			cfg:        createDefaultTestConfig(t),
+			httpClient: newHttpClient(),
+		}
+		app.cfg.Server.PublicAddress = "https://example.com"
+		app.mediaStorage = &localMediaStorage{path: t.TempDir()}
+		app.mediaStorageInit.Do(func() {})
+		return app
+	}
+
+	app := newApp(t)
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/test",
+		Content: "Backup me",
+		Section: "posts",
+	}))
+	_, err := app.db.Exec("insert into comments (target, comment, name, website) values ('/test', 'Nice', 'Alice', '')")
+	require.NoError(t, err)
+	_, err = app.saveMediaFile("abc.png", strings.NewReader("image"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(app.cfg.User.ProfileImageFile, []byte("profile"), 0o644))
+	require.NoError(t, app.loadActivityPubPrivateKey())
+	apKey, err := app.db.retrievePersistentCache("activitypub_key")
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "backup.tar.gz")
+	var buf bytes.Buffer
+	require.NoError(t, app.writeBackup(&buf))
+	require.NoError(t, os.WriteFile(file, buf.Bytes(), 0o644))
+
+	t.Run("Archive", func(t *testing.T) {
+		gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		tr := tar.NewReader(gr)
+		var names []string
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			names = append(names, header.Name)
+		}
+		assert.Equal(t, []string{backupManifestFile, backupDatabaseFile, "media/abc.png", backupProfileImageFile, backupAPKeyFile}, names)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.serveBackup(rec, httptest.NewRequest(http.MethodGet, settingsPath+settingsBackupPath, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/gzip", rec.Header().Get(contentType))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "goblog-backup-")
+		_, err := gzip.NewReader(rec.Body)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		restored := newApp(t)
+		require.NoError(t, restored.restoreBackup(file, false))
+
+		p, err := restored.getPost("/test")
+		require.NoError(t, err)
+		assert.Equal(t, "Backup me", p.Content)
+
+		comments, err := restored.db.getComments(&commentsRequestConfig{})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "Alice", comments[0].Name)
+
+		files, err := restored.mediaFiles()
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Equal(t, "abc.png", files[0].Name)
+
+		profileImage, err := os.ReadFile(restored.cfg.User.ProfileImageFile)
+		require.NoError(t, err)
+		assert.Equal(t, "profile", string(profileImage))
+		assert.True(t, restored.hasProfileImage())
+
+		restoredKey, err := restored.db.retrievePersistentCache("activitypub_key")
+		require.NoError(t, err)
+		assert.Equal(t, apKey, restoredKey)
+	})
+
+	t.Run("Existing database", func(t *testing.T) {
+		restored := newApp(t)
+		require.NoError(t, os.WriteFile(restored.cfg.Db.File, nil, 0o644))
+		assert.ErrorContains(t, restored.restoreBackup(file, false), "already exists")
+
+		restored = newApp(t)
+		require.NoError(t, os.WriteFile(restored.cfg.Db.File, nil, 0o644))
+		require.NoError(t, restored.restoreBackup(file, true))
+		_, err := restored.getPost("/test")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Newer schema", func(t *testing.T) {
+		// Replace manifest with a schema that doesn't exist yet
+		var newer bytes.Buffer
+		gw := gzip.NewWriter(&newer)
+		tw := tar.NewWriter(gw)
+		manifest, _ := json.Marshal(&backupManifest{Version: backupVersion, Schema: "99999"})
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: backupManifestFile, Size: int64(len(manifest)), Mode: 0o644}))
+		_, _ = tw.Write(manifest)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		newerFile := filepath.Join(t.TempDir(), "newer.tar.gz")
+		require.NoError(t, os.WriteFile(newerFile, newer.Bytes(), 0o644))
+
+		restored := newApp(t)
+		assert.ErrorContains(t, restored.restoreBackup(newerFile, false), "unknown database schema")
+		assert.NoFileExists(t, restored.cfg.Db.File)
+	})
+
+	t.Run("Truncated archive", func(t *testing.T) {
+		truncatedFile := filepath.Join(t.TempDir(), "truncated.tar.gz")
+		require.NoError(t, os.WriteFile(truncatedFile, buf.Bytes()[:buf.Len()-100], 0o644))
+
+		restored := newApp(t)
+		require.NoError(t, os.WriteFile(restored.cfg.Db.File, []byte("existing"), 0o644))
+		assert.Error(t, restored.restoreBackup(truncatedFile, true))
+		db, err := os.ReadFile(restored.cfg.Db.File)
+		require.NoError(t, err)
+		assert.Equal(t, "existing", string(db))
+		assert.NoFileExists(t, restored.cfg.User.ProfileImageFile)
+	})
+
+	t.Run("Corrupt database", func(t *testing.T) {
+		var corrupt bytes.Buffer
+		gw := gzip.NewWriter(&corrupt)
+		tw := tar.NewWriter(gw)
+		migrations, err := dbMigrationNames()
+		require.NoError(t, err)
+		manifest, _ := json.Marshal(&backupManifest{Version: backupVersion, Schema: migrations[len(migrations)-1]})
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: backupManifestFile, Size: int64(len(manifest)), Mode: 0o644}))
+		_, _ = tw.Write(manifest)
+		db := []byte("not a database")
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: backupDatabaseFile, Size: int64(len(db)), Mode: 0o644}))
+		_, _ = tw.Write(db)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		corruptFile := filepath.Join(t.TempDir(), "corrupt.tar.gz")
+		require.NoError(t, os.WriteFile(corruptFile, corrupt.Bytes(), 0o644))
+
+		restored := newApp(t)
+		assert.ErrorContains(t, restored.restoreBackup(corruptFile, false), "corrupt")
+		assert.NoFileExists(t, restored.cfg.Db.File)
+	})
+}
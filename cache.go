@@ -39,6 +39,7 @@ func (a *goBlog) initCache() {
 func (a *goBlog) purgeCache() {
 	a.initCache()
 	a.cache.purge()
+	a.clearRelatedPostsCache()
 }
 
 func cacheLoggedIn(next http.Handler) http.Handler {
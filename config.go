@@ -31,6 +31,7 @@ type config struct {
 	Notifications *configNotifications   `mapstructure:"notifications"`
 	PrivateMode   *configPrivateMode     `mapstructure:"privateMode"`
 	IndexNow      *configIndexNow        `mapstructure:"indexNow"`
+	WebSub        *configWebSub          `mapstructure:"webSub"`
 	EasterEgg     *configEasterEgg       `mapstructure:"easterEgg"`
 	MapTiles      *configMapTiles        `mapstructure:"mapTiles"`
 	TTS           *configTTS             `mapstructure:"tts"`
@@ -92,6 +93,9 @@ type configBlog struct {
 	Taxonomies     []*configTaxonomy         `mapstructure:"taxonomies"`
 	Menus          map[string]*configMenu    `mapstructure:"menus"`
 	Photos         *configPhotos             `mapstructure:"photos"`
+	Series         *configSeries             `mapstructure:"series"`
+	RelatedPosts   *configRelatedPosts       `mapstructure:"relatedPosts"`
+	PostingQueue   *configPostingQueue       `mapstructure:"postingQueue"`
 	Search         *configSearch             `mapstructure:"search"`
 	BlogStats      *configBlogStats          `mapstructure:"blogStats"`
 	Blogroll       *configBlogroll           `mapstructure:"blogroll"`
@@ -121,6 +125,7 @@ type configBlog struct {
 	// Editor state WebSockets
 	esws sync.Map
 	esm  sync.Mutex
+	esd  map[string]*editorDocument
 }
 
 type configSection struct {
@@ -152,6 +157,29 @@ type configPhotos struct {
 	Path        string `mapstructure:"path"`
 	Title       string `mapstructure:"title"`
 	Description string `mapstructure:"description"`
+	ShowExif    bool   `mapstructure:"showExif"`
+}
+
+type configSeries struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Path        string `mapstructure:"path"`
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+}
+
+type configRelatedPosts struct {
+	Enabled bool `mapstructure:"enabled"`
+	Count   int  `mapstructure:"count"`
+}
+
+type configPostingQueue struct {
+	Enabled bool                      `mapstructure:"enabled"`
+	Slots   []*configPostingQueueSlot `mapstructure:"slots"`
+}
+
+type configPostingQueueSlot struct {
+	Days []string `mapstructure:"days"` // Weekdays like mon, tue, empty for every day
+	Time string   `mapstructure:"time"` // Local time like 09:00
 }
 
 type configSearch struct {
@@ -274,8 +302,31 @@ type configMicropubMedia struct {
 	FTPAddress  string `mapstructure:"ftpAddress"`
 	FTPUser     string `mapstructure:"ftpUser"`
 	FTPPassword string `mapstructure:"ftpPassword"`
+	// S3-compatible storage
+	S3Endpoint  string `mapstructure:"s3Endpoint"`
+	S3Region    string `mapstructure:"s3Region"`
+	S3Bucket    string `mapstructure:"s3Bucket"`
+	S3Prefix    string `mapstructure:"s3Prefix"`
+	S3AccessKey string `mapstructure:"s3AccessKey"`
+	S3SecretKey string `mapstructure:"s3SecretKey"`
+	S3PathStyle bool   `mapstructure:"s3PathStyle"`
+	// SFTP
+	SFTPAddress    string `mapstructure:"sftpAddress"`
+	SFTPUser       string `mapstructure:"sftpUser"`
+	SFTPPrivateKey string `mapstructure:"sftpPrivateKey"`
+	SFTPPassphrase string `mapstructure:"sftpPassphrase"`
+	SFTPHostKey    string `mapstructure:"sftpHostKey"`
+	SFTPKnownHosts string `mapstructure:"sftpKnownHosts"`
+	SFTPPath       string `mapstructure:"sftpPath"`
+	// WebDAV
+	WebDAVURL      string `mapstructure:"webdavUrl"`
+	WebDAVUser     string `mapstructure:"webdavUser"`
+	WebDAVPassword string `mapstructure:"webdavPassword"`
 	// Local
-	LocalCompressionEnabled bool `mapstructure:"localCompressionEnabled"`
+	LocalCompressionEnabled bool  `mapstructure:"localCompressionEnabled"`
+	ResponsiveImageWidths   []int `mapstructure:"responsiveImageWidths"`
+	// Photo metadata
+	KeepExif bool `mapstructure:"keepExif"`
 }
 
 type configRegexRedirect struct {
@@ -332,6 +383,12 @@ type configIndexNow struct {
 	Enabled bool `mapstructure:"enabled"`
 }
 
+type configWebSub struct {
+	Enabled    bool     `mapstructure:"enabled"`
+	Hubs       []string `mapstructure:"hubs"`
+	BuiltInHub bool     `mapstructure:"builtInHub"`
+}
+
 type configEasterEgg struct {
 	Enabled bool `mapstructure:"enabled"`
 }
@@ -566,6 +623,12 @@ func (a *goBlog) initConfig(logging bool) error {
 		if bc.Pagination == 0 {
 			bc.Pagination = 10
 		}
+		// Check posting queue slots
+		if bc.PostingQueue != nil {
+			if err = bc.PostingQueue.check(); err != nil {
+				return err
+			}
+		}
 		// Check sections and add section if none exists
 		if len(bc.Sections) == 0 {
 			bc.Sections = createDefaultSections()
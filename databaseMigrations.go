@@ -8,13 +8,33 @@ import (
 	"strings"
 
 	"github.com/lopezator/migrator"
+	"github.com/samber/lo"
 )
 
 //go:embed dbmigrations/*
 var dbMigrations embed.FS
 
 func (a *goBlog) migrateDb(db *sql.DB, logging bool) error {
-	var sqlMigrations []any
+	migrations, err := dbMigrationList()
+	if err != nil {
+		return err
+	}
+	m, err := migrator.New(
+		migrator.WithLogger(migrator.LoggerFunc(func(s string, i ...any) {
+			if logging {
+				a.info(fmt.Sprintf(s, i...))
+			}
+		})),
+		migrator.Migrations(lo.ToAnySlice(migrations)...),
+	)
+	if err != nil {
+		return err
+	}
+	return m.Migrate(db)
+}
+
+func dbMigrationList() ([]*migrator.Migration, error) {
+	var sqlMigrations []*migrator.Migration
 	err := fs.WalkDir(dbMigrations, "dbmigrations", func(path string, d fs.DirEntry, err error) error {
 		if err != nil || d.Type().IsDir() {
 			return err
@@ -35,19 +55,25 @@ func (a *goBlog) migrateDb(db *sql.DB, logging bool) error {
 		sqlMigrations = append(sqlMigrations, mig)
 		return nil
 	})
+	return sqlMigrations, err
+}
+
+// dbMigrationNames returns the names of all known migrations in the order they are applied.
+func dbMigrationNames() ([]string, error) {
+	migrations, err := dbMigrationList()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	m, err := migrator.New(
-		migrator.WithLogger(migrator.LoggerFunc(func(s string, i ...any) {
-			if logging {
-				a.info(fmt.Sprintf(s, i...))
-			}
-		})),
-		migrator.Migrations(sqlMigrations...),
-	)
+	return lo.Map(migrations, func(m *migrator.Migration, _ int) string { return m.Name }), nil
+}
+
+// schemaVersion returns the name of the last migration applied to the database.
+func (db *database) schemaVersion() (string, error) {
+	row, err := db.QueryRow("select version from migrations order by id desc limit 1")
 	if err != nil {
-		return err
+		return "", err
 	}
-	return m.Migrate(db)
+	var version string
+	err = row.Scan(&version)
+	return version, err
 }
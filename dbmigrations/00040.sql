@@ -0,0 +1,16 @@
+create table post_revisions (
+    id integer primary key autoincrement,
+    path text not null,
+    time text not null default '',
+    content text not null default '',
+    parameters text not null default '',
+    published text not null default '',
+    updated text not null default '',
+    blog text not null default '',
+    section text not null default '',
+    status text not null default '',
+    visibility text not null default '',
+    priority integer not null default 0,
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
+create index index_post_revisions_path on post_revisions (path, id);
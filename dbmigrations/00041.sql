@@ -0,0 +1,6 @@
+create table post_redirects (
+    from_path text not null primary key,
+    path text not null,
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
+create index index_post_redirects_path on post_redirects (path);
@@ -0,0 +1,8 @@
+create table post_tokens (
+    token text not null primary key,
+    path text not null,
+    created text not null default '',
+    expires text not null default '',
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
+create index index_post_tokens_path on post_tokens (path);
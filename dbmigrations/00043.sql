@@ -0,0 +1,7 @@
+create table media_variants (
+    original text not null,
+    location text not null,
+    width integer not null,
+    format text not null,
+    primary key (original, location)
+);
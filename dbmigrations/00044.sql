@@ -0,0 +1,8 @@
+create table media_metadata (
+    location text not null primary key,
+    camera text not null default '',
+    lens text not null default '',
+    exposure text not null default '',
+    taken text not null default '',
+    geo text not null default ''
+);
@@ -0,0 +1,3 @@
+alter table media_metadata add width integer not null default 0;
+alter table media_metadata add height integer not null default 0;
+alter table media_metadata add blurhash text not null default '';
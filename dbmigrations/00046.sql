@@ -0,0 +1,13 @@
+create table media_library (
+    name text not null primary key,
+    location text not null default '',
+    alt text not null default '',
+    caption text not null default '',
+    width integer not null default 0,
+    height integer not null default 0,
+    mime text not null default '',
+    hash text not null default '',
+    uploaded text not null default '',
+    original text not null default ''
+);
+create index index_media_library_location on media_library (location);
@@ -0,0 +1 @@
+create index index_media_library_hash on media_library (hash);
@@ -0,0 +1 @@
+alter table media_metadata add placeholder text not null default '';
@@ -3,12 +3,15 @@ package main
 import (
 	"bytes"
 	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path"
 	"strings"
 
 	"github.com/carlmjohnson/requests"
@@ -23,31 +26,67 @@ import (
 const editorPath = "/editor"
 
 func (a *goBlog) serveEditor(w http.ResponseWriter, r *http.Request) {
+	edrd := &editorRenderData{
+		presetParams: parsePresetPostParamsFromQuery(r),
+	}
+	// Only show uploaded files of the media storage, the link is rendered on the page
+	if uploaded := r.URL.Query().Get("uploaded"); uploaded != "" && a.isMediaFileLocation(uploaded) {
+		edrd.uploaded = uploaded
+		edrd.uploadedMetadata, _ = a.db.getMediaMetadata(uploaded)
+		edrd.uploadedFile, _ = a.db.getMediaLibraryFile(path.Base(uploaded))
+	}
 	a.render(w, r, a.renderEditor, &renderData{
-		Data: &editorRenderData{
-			presetParams: parsePresetPostParamsFromQuery(r),
-		},
+		Data: edrd,
 	})
 }
 
+// isMediaFileLocation checks if the URL is the location of a file in the media storage
+func (a *goBlog) isMediaFileLocation(location string) bool {
+	var prefixes []string
+	if storageLocation := a.mediaFileLocation(""); storageLocation != "" {
+		prefixes = append(prefixes, a.getFullAddress(storageLocation))
+	}
+	if ms := a.cfg.Micropub.MediaStorage; ms != nil && ms.MediaURL != "" {
+		prefixes = append(prefixes, strings.TrimSuffix(ms.MediaURL, "/")+"/")
+	}
+	for _, prefix := range prefixes {
+		if name, ok := strings.CutPrefix(location, prefix); ok && isValidMediaFilename(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *goBlog) serveEditorPost(w http.ResponseWriter, r *http.Request) {
 	switch action := r.FormValue("editoraction"); action {
-	case "loadupdate":
+	case "loadupdate", "discarddraft":
 		post, err := a.getPost(r.FormValue("path"))
 		if err != nil {
 			a.serveError(w, r, err.Error(), http.StatusBadRequest)
 			return
 		}
-		a.render(w, r, a.renderEditor, &renderData{
-			Data: &editorRenderData{
-				presetParams:      parsePresetPostParamsFromQuery(r),
-				updatePostUrl:     a.fullPostURL(post),
-				updatePostContent: post.contentWithParams(),
-			},
-		})
+		if action == "discarddraft" {
+			// Reset open editors to the current post and remove the draft
+			if err = a.replaceEditorDocument(r.Context(), post.Blog, post.Path, editorPostHash(post), post.contentWithParams()); err == nil {
+				err = a.deleteEditorPostDraft(r.Context(), post.Path)
+			}
+			if err != nil {
+				a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		a.serveEditorUpdate(w, r, post, nil)
 	case "createpost", "updatepost":
 		reqBody := map[string]any{}
 		if action == "updatepost" {
+			// Check if the post was updated since the editor was opened
+			if conflict, err := a.editorUpdateConflict(r); err != nil {
+				a.serveError(w, r, err.Error(), http.StatusBadRequest)
+				return
+			} else if conflict != nil {
+				a.serveEditorUpdate(w, r, conflict.post, conflict)
+				return
+			}
 			reqBody["action"] = micropub.ActionUpdate
 			reqBody["url"] = r.FormValue("url")
 			reqBody["replace"] = map[string][]string{
@@ -56,7 +95,7 @@ func (a *goBlog) serveEditorPost(w http.ResponseWriter, r *http.Request) {
 			}
 		} else {
 			// Handle files first
-			images, gpx, statusCode, err := a.editorHandleFileAttachments(r)
+			images, imageAlts, gpx, statusCode, err := a.editorHandleFileAttachments(r)
 			if err != nil {
 				a.serveError(w, r, err.Error(), statusCode)
 				return
@@ -65,29 +104,35 @@ func (a *goBlog) serveEditorPost(w http.ResponseWriter, r *http.Request) {
 			blog, _ := a.getBlog(r)
 			reqBody["type"] = []string{"h-entry"}
 			reqBody["properties"] = map[string][]string{
-				"content":                 {r.FormValue("content")},
-				"blog":                    {blog},
-				a.cfg.Micropub.PhotoParam: images,
-				gpxParameter:              {gpx},
+				"content":                            {r.FormValue("content")},
+				"blog":                               {blog},
+				a.cfg.Micropub.PhotoParam:            images,
+				a.cfg.Micropub.PhotoDescriptionParam: imageAlts,
+				gpxParameter:                         {gpx},
 			}
 		}
 		req, _ := requests.URL("").BodyJSON(reqBody).Request(r.Context())
-		a.editorMicropubPost(w, req, false, "")
+		if a.editorMicropubPost(w, req, "") && action == "updatepost" {
+			// The autosaved draft is saved now
+			if parsedURL, err := url.Parse(r.FormValue("url")); err == nil {
+				_ = a.deleteEditorPostDraft(r.Context(), parsedURL.Path)
+			}
+		}
 	case "upload":
-		a.editorMicropubPost(w, r, true, "")
+		a.editorUpload(w, r)
 	case "delete", "undelete":
 		req, _ := requests.URL("").
 			Method(http.MethodPost).
 			BodyForm(url.Values{"action": {action}, "url": {r.FormValue("url")}}).
 			Request(r.Context())
-		a.editorMicropubPost(w, req, false, r.FormValue("url"))
+		a.editorMicropubPost(w, req, r.FormValue("url"))
 	case "visibility":
 		reqBody := map[string]any{}
 		reqBody["action"] = micropub.ActionUpdate
 		reqBody["url"] = r.FormValue("url")
 		reqBody["replace"] = map[string][]string{"visibility": {r.FormValue("visibility")}}
 		req, _ := requests.URL("").BodyJSON(reqBody).Request(r.Context())
-		a.editorMicropubPost(w, req, false, r.FormValue("url"))
+		a.editorMicropubPost(w, req, r.FormValue("url"))
 	case "tts":
 		parsedURL, err := url.Parse(r.FormValue("url"))
 		if err != nil {
@@ -147,10 +192,11 @@ func (a *goBlog) serveEditorPost(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (a *goBlog) editorHandleFileAttachments(r *http.Request) (images []string, gpx string, statusCode int, rerr error) {
+// editorHandleFileAttachments uploads the attached files and returns the images with the alt texts of the media library and the merged GPX
+func (a *goBlog) editorHandleFileAttachments(r *http.Request) (images, imageAlts []string, gpx string, statusCode int, rerr error) {
 	err := r.ParseMultipartForm(10 * bodylimit.MB)
 	if err != nil {
-		return nil, "", http.StatusBadRequest, err
+		return nil, nil, "", http.StatusBadRequest, err
 	}
 	files := []*multipart.FileHeader{}
 	for _, name := range []string{"files1", "files2", "files3"} {
@@ -161,14 +207,14 @@ func (a *goBlog) editorHandleFileAttachments(r *http.Request) (images []string,
 		for _, fileHeader := range files {
 			file, err := fileHeader.Open()
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			defer file.Close()
 			// Create a new request for each file (if it's a GPX use, collect it first, later merge using the helper)
 			if strings.HasSuffix(fileHeader.Filename, ".gpx") {
 				fileContent, err := io.ReadAll(file)
 				if err != nil {
-					return nil, "", http.StatusBadRequest, err
+					return nil, nil, "", http.StatusBadRequest, err
 				}
 				gpxFiles = append(gpxFiles, fileContent)
 				_ = file.Close()
@@ -180,15 +226,15 @@ func (a *goBlog) editorHandleFileAttachments(r *http.Request) (images []string,
 			mpw := multipart.NewWriter(requestBody)
 			part, err := mpw.CreateFormFile("file", fileHeader.Filename)
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			_, err = io.Copy(part, file)
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			err = mpw.Close()
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			req, err := requests.URL("").
 				Method(http.MethodPost).
@@ -196,59 +242,171 @@ func (a *goBlog) editorHandleFileAttachments(r *http.Request) (images []string,
 				Header(contentType, mpw.FormDataContentType()).
 				Request(r.Context())
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			recorder := httptest.NewRecorder()
 			addAllScopes(a.getMicropubImplementation().getMediaHandler()).ServeHTTP(recorder, req)
 			result := recorder.Result()
 			_ = result.Body.Close()
 			if result.StatusCode != http.StatusCreated {
-				return nil, "", result.StatusCode, fmt.Errorf("failed to upload file")
+				return nil, nil, "", result.StatusCode, fmt.Errorf("failed to upload file")
 			}
 			location := result.Header.Get("Location")
 			if location == "" {
-				return nil, "", http.StatusBadRequest, fmt.Errorf("failed to get location header")
+				return nil, nil, "", http.StatusBadRequest, fmt.Errorf("failed to get location header")
 			}
 			images = append(images, location)
+			// Use the alt text of the file in the media library, e.g. if the file was uploaded before
+			alt := ""
+			if f, _ := a.db.getMediaLibraryFile(path.Base(location)); f != nil {
+				alt = f.alt
+			}
+			imageAlts = append(imageAlts, alt)
 		}
 		// Merge the GPX files
 		if len(gpxFiles) > 0 {
 			mergedGpx, err := gpxhelper.MergeGpx(gpxFiles...)
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			buf := bufferpool.Get()
 			defer bufferpool.Put(buf)
 			err = a.min.Get().Minify(contenttype.XML, buf, bytes.NewReader(mergedGpx))
 			if err != nil {
-				return nil, "", http.StatusBadRequest, err
+				return nil, nil, "", http.StatusBadRequest, err
 			}
 			gpx = buf.String()
 		}
 	}
-	return images, gpx, 0, nil
+	return images, imageAlts, gpx, 0, nil
 }
 
-func (a *goBlog) editorMicropubPost(w http.ResponseWriter, r *http.Request, media bool, redirectSuccess string) {
+// editorMicropubPost passes the request to the Micropub handler and reports whether it was successful.
+func (a *goBlog) editorMicropubPost(w http.ResponseWriter, r *http.Request, redirectSuccess string) bool {
 	recorder := httptest.NewRecorder()
-	if media {
-		addAllScopes(a.getMicropubImplementation().getMediaHandler()).ServeHTTP(recorder, r)
-	} else {
-		addAllScopes(a.getMicropubImplementation().getHandler()).ServeHTTP(recorder, r)
-	}
+	addAllScopes(a.getMicropubImplementation().getHandler()).ServeHTTP(recorder, r)
 	result := recorder.Result()
 	if location := result.Header.Get("Location"); location != "" {
 		http.Redirect(w, r, location, http.StatusFound)
-		return
+		return true
 	}
 	if result.StatusCode >= 200 && result.StatusCode < 400 {
 		redirectPath := cmp.Or(redirectSuccess, editorPath)
 		http.Redirect(w, r, redirectPath, http.StatusFound)
-		return
+		return true
 	}
 	w.WriteHeader(result.StatusCode)
 	_, _ = io.Copy(w, result.Body)
 	_ = result.Body.Close()
+	return false
+}
+
+// editorUpload passes the file to the Micropub media handler and shows the location in the editor.
+func (a *goBlog) editorUpload(w http.ResponseWriter, r *http.Request) {
+	recorder := httptest.NewRecorder()
+	addAllScopes(a.getMicropubImplementation().getMediaHandler()).ServeHTTP(recorder, r)
+	result := recorder.Result()
+	location := result.Header.Get("Location")
+	if location == "" {
+		w.WriteHeader(result.StatusCode)
+		_, _ = io.Copy(w, result.Body)
+		_ = result.Body.Close()
+		return
+	}
+	// Save the alt text and caption in the media library, keep the stored ones if empty
+	if alt, caption := r.FormValue("alt"), r.FormValue("caption"); alt != "" || caption != "" {
+		if f, err := a.db.getMediaLibraryFile(path.Base(location)); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		} else if f != nil {
+			if err = a.db.updateMediaLibraryText(f.name, cmp.Or(alt, f.alt), cmp.Or(caption, f.caption)); err != nil {
+				a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath(editorPath)+"?"+url.Values{"uploaded": {location}}.Encode()+"#upload", http.StatusFound)
+}
+
+// editorUpdateConflict is returned when a post was updated after the editor was opened.
+type editorUpdateConflict struct {
+	post    *post
+	content string // The submitted content
+	diff    []*revisionDiffLine
+}
+
+// editorPostHash identifies the version of the post the editor was opened with.
+// Unlike the updated date it changes with every saved change, also within the same second or without setting the updated date.
+func editorPostHash(p *post) string {
+	hash := sha256.Sum256([]byte(p.contentWithParams()))
+	return hex.EncodeToString(hash[:])
+}
+
+// editorUpdateConflict compares the hash of the post the editor was opened with to the one of the current post.
+// Requests without the hash or with the overwrite flag skip the check.
+func (a *goBlog) editorUpdateConflict(r *http.Request) (*editorUpdateConflict, error) {
+	if _, ok := r.Form["hash"]; !ok || r.FormValue("overwrite") != "" {
+		return nil, nil
+	}
+	parsedURL, err := url.Parse(r.FormValue("url"))
+	if err != nil {
+		return nil, err
+	}
+	current, err := a.getPost(parsedURL.Path)
+	if err != nil {
+		return nil, err
+	}
+	if editorPostHash(current) == r.FormValue("hash") {
+		return nil, nil
+	}
+	content := r.FormValue("content")
+	return &editorUpdateConflict{
+		post:    current,
+		content: content,
+		diff:    lineDiff(current.contentWithParams(), content),
+	}, nil
+}
+
+// serveEditorUpdate renders the editor to update the post.
+// It restores an autosaved draft or shows the conflict with the current version of the post.
+func (a *goBlog) serveEditorUpdate(w http.ResponseWriter, r *http.Request, p *post, conflict *editorUpdateConflict) {
+	redirects, err := a.db.getPostRedirects(p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := &editorRenderData{
+		presetParams:           parsePresetPostParamsFromQuery(r),
+		updatePostUrl:          a.fullPostURL(p),
+		updatePostPath:         p.Path,
+		updatePostHash:         editorPostHash(p),
+		updatePostContent:      p.contentWithParams(),
+		updatePostRedirects:    redirects,
+		updatePostExpiry:       p.expiry(),
+		updatePostExpiryAction: p.expiryAction(),
+		updatePostConflict:     conflict,
+	}
+	if conflict != nil {
+		// Continue editing the submitted content based on the current post
+		if err = a.replaceEditorDocument(r.Context(), p.Blog, p.Path, data.updatePostHash, conflict.content); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data.updatePostContent = conflict.content
+		a.renderWithStatusCode(w, r, http.StatusConflict, a.renderEditor, &renderData{Data: data})
+		return
+	}
+	draft, err := a.getEditorPostDraft(r.Context(), p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if draft != nil && draft.Content != data.updatePostContent {
+		// Keep the hash of the post the draft is based on to detect conflicts when saving
+		data.updatePostContent, data.updatePostHash, data.updatePostDraft = draft.Content, draft.Hash, true
+	}
+	a.render(w, r, a.renderEditor, &renderData{Data: data})
 }
 
 func (*goBlog) editorPostTemplate(blog string, bc *configBlog, presetParams map[string][]string) string {
@@ -302,6 +460,11 @@ func (a *goBlog) editorPostDesc(bc *configBlog) string {
 		a.cfg.Micropub.ReplyTitleParam,
 		a.cfg.Micropub.ReplyContextParam,
 		gpxParameter,
+		seriesParameter,
+		seriesPartParameter,
+		postPasswordParameter,
+		postExpiresParam,
+		postExpiryActionParam,
 	} {
 		if param == "" {
 			continue
@@ -324,7 +487,7 @@ func (a *goBlog) editorPostDesc(bc *configBlog) string {
 		statusBuilder.WriteByte('`')
 	}
 	for i, visibility := range []postVisibility{
-		visibilityPublic, visibilityUnlisted, visibilityPrivate,
+		visibilityPublic, visibilityUnlisted, visibilityPrivate, visibilityProtected, visibilityLink,
 	} {
 		if i > 0 {
 			visibilityBuilder.WriteString(", ")
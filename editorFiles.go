@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
 	"sort"
 
 	"github.com/go-chi/chi/v5"
@@ -14,6 +17,8 @@ const (
 	editorFileUsesPath            = editorFilesPath + "/uses"
 	editorFileUsesPathPlaceholder = "/{filename}"
 	editorFileDeletePath          = editorFilesPath + "/delete"
+	editorFileEditPath            = editorFilesPath + "/edit"
+	editorFilesUnusedPath         = editorFilesPath + "/unused"
 )
 
 func (a *goBlog) serveEditorFiles(w http.ResponseWriter, r *http.Request) {
@@ -34,10 +39,17 @@ func (a *goBlog) serveEditorFiles(w http.ResponseWriter, r *http.Request) {
 	sort.Slice(files, func(i, j int) bool {
 		return files[i].Time.After(files[j].Time)
 	})
+	// Get library entries for the alt texts
+	library, err := a.db.getMediaLibraryFiles()
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	// Serve HTML
 	a.render(w, r, a.renderEditorFiles, &renderData{
 		Data: &editorFilesRenderData{
-			files: files,
+			files:   files,
+			library: library,
 		},
 	})
 }
@@ -89,3 +101,76 @@ func (a *goBlog) serveEditorFilesDelete(w http.ResponseWriter, r *http.Request)
 	_, bc := a.getBlog(r)
 	http.Redirect(w, r, bc.getRelativePath(editorPath+editorFilesPath), http.StatusFound)
 }
+
+func (a *goBlog) serveEditorFilesEdit(w http.ResponseWriter, r *http.Request) {
+	filename := r.FormValue("filename")
+	if filename == "" {
+		a.serveError(w, r, "No file selected", http.StatusBadRequest)
+		return
+	}
+	f, err := a.db.getMediaLibraryFile(filename)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if f == nil {
+		// Files uploaded before the media library existed
+		f = &mediaLibraryFile{name: filename, location: a.mediaFileLocation(filename), mimeType: mime.TypeByExtension(path.Ext(filename))}
+	}
+	a.render(w, r, a.renderEditorFileEdit, &renderData{
+		Data: f,
+	})
+}
+
+func (a *goBlog) serveEditorFilesEditSave(w http.ResponseWriter, r *http.Request) {
+	filename := r.FormValue("filename")
+	if filename == "" {
+		a.serveError(w, r, "No file selected", http.StatusBadRequest)
+		return
+	}
+	f, err := a.db.getMediaLibraryFile(filename)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if f == nil {
+		// Add files uploaded before the media library existed
+		f = &mediaLibraryFile{name: filename, location: a.mediaFileLocation(filename), mimeType: mime.TypeByExtension(path.Ext(filename))}
+		if err = a.db.saveMediaLibraryFile(f); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err = a.db.updateMediaLibraryText(filename, r.FormValue("alt"), r.FormValue("caption")); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath(editorPath+editorFileEditPath)+"?"+url.Values{"filename": {filename}}.Encode(), http.StatusFound)
+}
+
+func (a *goBlog) serveEditorFilesUnused(w http.ResponseWriter, r *http.Request) {
+	files, err := a.unusedMediaFiles()
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderEditorFilesUnused, &renderData{
+		Data: &editorFilesRenderData{
+			files: files,
+		},
+	})
+}
+
+func (a *goBlog) serveEditorFilesUnusedDelete(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := a.deleteUnusedMediaFiles(r.Form["filename"]); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath(editorPath+editorFilesUnusedPath), http.StatusFound)
+}
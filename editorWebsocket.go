@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -12,6 +14,7 @@ import (
 	"go.goblog.app/app/pkgs/bodylimit"
 	"go.goblog.app/app/pkgs/contenttype"
 	"go.goblog.app/app/pkgs/htmlbuilder"
+	"go.goblog.app/app/pkgs/textot"
 )
 
 func (a *goBlog) serveEditorWebsocket(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +26,8 @@ func (a *goBlog) serveEditorWebsocket(w http.ResponseWriter, r *http.Request) {
 	}
 	// Get blog
 	blog, bc := a.getBlog(r)
+	// Editors of existing posts sync an autosaved draft per post
+	draftPath, draftHash := r.URL.Query().Get("path"), r.URL.Query().Get("hash")
 	// Open websocket connection
 	c, err := ws.Accept(w, r, &ws.AcceptOptions{CompressionMode: ws.CompressionContextTakeover})
 	if err != nil {
@@ -30,33 +35,31 @@ func (a *goBlog) serveEditorWebsocket(w http.ResponseWriter, r *http.Request) {
 	}
 	c.SetReadLimit(10 * bodylimit.MB)
 	defer c.Close(ws.StatusNormalClosure, "")
-	// Store connection to be able to send updates
-	var connectionId string
-	if enableSync {
-		connectionId = uuid.NewString()
-		bc.esws.Store(connectionId, c)
-		defer bc.esws.Delete(connectionId)
+	ec := &editorConnection{
+		id:            uuid.NewString(),
+		c:             c,
+		blog:          blog,
+		draftPath:     draftPath,
+		draftHash:     draftHash,
+		collaborative: r.URL.Query().Get("ot") == "1",
+		preview:       enablePreview,
 	}
 	// Set cancel context
 	ctx, cancel := context.WithTimeout(r.Context(), time.Hour*6)
 	defer cancel()
 	// Send initial content
 	if enableSync {
-		initialState, err := a.getEditorStateFromDatabase(ctx, blog)
+		// Store connection to be able to send updates
+		initialState, err := a.openEditorConnection(ctx, bc, ec)
 		if err != nil {
 			return
 		}
-		if initialState != nil {
-			// Send initial state
-			if err := a.sendEditorState(ctx, c, initialState); err != nil {
+		defer a.closeEditorConnection(bc, ec)
+		// Send preview
+		if enablePreview && initialState != "" {
+			if err := a.sendEditorPreview(ctx, c, blog, []byte(initialState)); err != nil {
 				return
 			}
-			// Send preview
-			if enablePreview {
-				if err := a.sendEditorPreview(ctx, c, blog, initialState); err != nil {
-					return
-				}
-			}
 		}
 	} else if !enableSync && enablePreview {
 		// Trigger editor to send content to generate the preview
@@ -86,13 +89,18 @@ func (a *goBlog) serveEditorWebsocket(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			break
 		}
-		// Save editor state
-		// and send editor state to other connections
+		// Merge the change into the editor state
+		// and send it to the other connections
 		if enableSync {
-			bc.esm.Lock()
-			a.updateEditorStateInDatabase(ctx, blog, messageBytes)
-			bc.esm.Unlock()
-			a.sendNewEditorStateToAllConnections(ctx, bc, connectionId, messageBytes)
+			state, receivers, err := a.applyEditorMessage(ctx, bc, ec, messageBytes)
+			if err != nil {
+				break
+			}
+			messageBytes = []byte(state)
+			// Update the previews of the other connections
+			for _, receiver := range receivers {
+				_ = a.sendEditorPreview(ctx, receiver.c, blog, messageBytes)
+			}
 		}
 		// Create preview
 		if enablePreview {
@@ -105,21 +113,222 @@ func (a *goBlog) serveEditorWebsocket(w http.ResponseWriter, r *http.Request) {
 
 // SYNC
 
-func (a *goBlog) sendNewEditorStateToAllConnections(ctx context.Context, bc *configBlog, origin string, state []byte) {
+type editorConnection struct {
+	id            string
+	c             *ws.Conn
+	blog          string
+	draftPath     string // Empty for the editor to create new posts
+	draftHash     string // Hash of the post the draft is based on
+	collaborative bool   // Exchanges operations instead of the full state
+	preview       bool
+}
+
+// editorDocument is the shared state of the editors connected to the same draft.
+// Concurrent changes are merged using operational transformation.
+type editorDocument struct {
+	text    string
+	rev     int
+	history []*textot.Operation // The operations that led to the last revisions
+}
+
+const editorDocumentHistory = 500
+
+type editorOperationMessage struct {
+	Rev  int               `json:"rev"`
+	Op   *textot.Operation `json:"op,omitempty"`
+	Text *string           `json:"text,omitempty"`
+}
+
+// editorDocument returns the document of the draft and loads it if necessary, must be called while holding esm lock.
+func (a *goBlog) editorDocument(ctx context.Context, bc *configBlog, blog, draftPath string) (*editorDocument, error) {
+	if doc, ok := bc.esd[draftPath]; ok {
+		return doc, nil
+	}
+	doc := &editorDocument{}
+	if draftPath == "" {
+		state, err := a.getEditorStateFromDatabase(ctx, blog)
+		if err != nil {
+			return nil, err
+		}
+		doc.text = string(state)
+	} else if draft, err := a.getEditorPostDraft(ctx, draftPath); err != nil {
+		return nil, err
+	} else if draft != nil {
+		doc.text = draft.Content
+	} else if p, err := a.getPost(draftPath); err == nil {
+		// Start with the current post
+		doc.text = p.contentWithParams()
+	}
+	if bc.esd == nil {
+		bc.esd = map[string]*editorDocument{}
+	}
+	bc.esd[draftPath] = doc
+	return doc, nil
+}
+
+// transform transforms the operation based on the revision against all later operations.
+func (doc *editorDocument) transform(rev int, op *textot.Operation) (*textot.Operation, error) {
+	if rev < doc.rev-len(doc.history) || rev > doc.rev {
+		return nil, errors.New("unknown revision")
+	}
+	var err error
+	for _, concurrent := range doc.history[len(doc.history)-(doc.rev-rev):] {
+		if op, _, err = textot.Transform(op, concurrent); err != nil {
+			return nil, err
+		}
+	}
+	return op, nil
+}
+
+// openEditorConnection stores the connection and sends the initial state.
+func (a *goBlog) openEditorConnection(ctx context.Context, bc *configBlog, ec *editorConnection) (string, error) {
+	bc.esm.Lock()
+	defer bc.esm.Unlock()
+	doc, err := a.editorDocument(ctx, bc, ec.blog, ec.draftPath)
+	if err != nil {
+		return "", err
+	}
+	bc.esws.Store(ec.id, ec)
+	if ec.collaborative {
+		return doc.text, a.sendEditorMessage(ctx, ec.c, "doc:", &editorOperationMessage{Rev: doc.rev, Text: &doc.text})
+	} else if doc.text != "" {
+		return doc.text, a.sendEditorState(ctx, ec.c, []byte(doc.text))
+	}
+	return doc.text, nil
+}
+
+// closeEditorConnection removes the connection and unloads the document if it was the last editor of it.
+func (*goBlog) closeEditorConnection(bc *configBlog, ec *editorConnection) {
+	bc.esm.Lock()
+	defer bc.esm.Unlock()
+	bc.esws.Delete(ec.id)
+	unloadUnusedEditorDocument(bc, ec.draftPath)
+}
+
+// unloadUnusedEditorDocument removes the document from memory if no editor is connected to it, must be called while holding esm lock.
+func unloadUnusedEditorDocument(bc *configBlog, draftPath string) {
+	used := false
+	bc.esws.Range(func(_, value any) bool {
+		if ec, ok := value.(*editorConnection); ok && ec.draftPath == draftPath {
+			used = true
+			return false
+		}
+		return true
+	})
+	if !used {
+		delete(bc.esd, draftPath)
+	}
+}
+
+// applyEditorMessage merges the change of the editor into the document, saves it and sends it to the other editors.
+// Collaborative editors send operations ("op:" + JSON) or "reset", other editors send the full state.
+// It returns the new state and the other connections that have the preview enabled.
+func (a *goBlog) applyEditorMessage(ctx context.Context, bc *configBlog, ec *editorConnection, message []byte) (string, []*editorConnection, error) {
+	bc.esm.Lock()
+	defer bc.esm.Unlock()
+	doc, err := a.editorDocument(ctx, bc, ec.blog, ec.draftPath)
+	if err != nil {
+		return "", nil, err
+	}
+	var op *textot.Operation
+	if !ec.collaborative {
+		op = textot.Diff(doc.text, string(message))
+	} else if string(message) == "reset" {
+		op = textot.Diff(doc.text, "")
+	} else if opMessage, ok := bytes.CutPrefix(message, []byte("op:")); ok {
+		m := &editorOperationMessage{}
+		if err = json.Unmarshal(opMessage, m); err == nil && m.Op != nil {
+			op, err = doc.transform(m.Rev, m.Op)
+		} else if err == nil {
+			err = errors.New("operation missing")
+		}
+	} else {
+		err = errors.New("unknown message")
+	}
+	if err != nil {
+		// Send the current state, so the editor can continue
+		return doc.text, nil, a.sendEditorMessage(ctx, ec.c, "doc:", &editorOperationMessage{Rev: doc.rev, Text: &doc.text})
+	}
+	receivers, err := a.applyEditorOperation(ctx, bc, doc, ec, op)
+	if err != nil {
+		return doc.text, nil, a.sendEditorMessage(ctx, ec.c, "doc:", &editorOperationMessage{Rev: doc.rev, Text: &doc.text})
+	}
+	if ec.collaborative {
+		// Acknowledge the operation
+		if err = a.sendEditorMessage(ctx, ec.c, "ack:", &editorOperationMessage{Rev: doc.rev}); err != nil {
+			return "", nil, err
+		}
+	}
+	return doc.text, receivers, nil
+}
+
+// applyEditorOperation applies the operation to the document, saves it and sends it to the other editors,
+// must be called while holding esm lock.
+func (a *goBlog) applyEditorOperation(ctx context.Context, bc *configBlog, doc *editorDocument, origin *editorConnection, op *textot.Operation) ([]*editorConnection, error) {
+	text, err := op.Apply(doc.text)
+	if err != nil {
+		return nil, err
+	}
+	doc.text = text
+	doc.rev++
+	doc.history = append(doc.history, op)
+	if len(doc.history) > editorDocumentHistory {
+		doc.history = doc.history[len(doc.history)-editorDocumentHistory:]
+	}
+	// Save the state
+	if origin.draftPath != "" {
+		a.updateEditorPostDraft(ctx, origin.draftPath, origin.draftHash, []byte(text))
+	} else {
+		a.updateEditorStateInDatabase(ctx, origin.blog, []byte(text))
+	}
+	// Send to the other connections
+	var receivers []*editorConnection
 	bc.esws.Range(func(key, value any) bool {
-		if key == origin {
+		receiver, ok := value.(*editorConnection)
+		if !ok || receiver == origin || receiver.draftPath != origin.draftPath {
 			return true
 		}
-		c, ok := value.(*ws.Conn)
-		if !ok {
-			return true
+		if receiver.collaborative {
+			err = a.sendEditorMessage(ctx, receiver.c, "op:", &editorOperationMessage{Rev: doc.rev, Op: op})
+		} else {
+			err = a.sendEditorState(ctx, receiver.c, []byte(text))
 		}
-		if err := a.sendEditorState(ctx, c, state); err != nil {
+		if err != nil {
 			bc.esws.Delete(key)
-			return true
+		} else if receiver.preview {
+			receivers = append(receivers, receiver)
 		}
 		return true
 	})
+	return receivers, nil
+}
+
+// replaceEditorDocument replaces the draft of the editors of a post, e.g. to continue editing after a conflict.
+func (a *goBlog) replaceEditorDocument(ctx context.Context, blog, draftPath, hash, text string) error {
+	bc := a.cfg.Blogs[blog]
+	bc.esm.Lock()
+	defer bc.esm.Unlock()
+	doc, err := a.editorDocument(ctx, bc, blog, draftPath)
+	if err != nil {
+		return err
+	}
+	_, err = a.applyEditorOperation(ctx, bc, doc, &editorConnection{blog: blog, draftPath: draftPath, draftHash: hash}, textot.Diff(doc.text, text))
+	unloadUnusedEditorDocument(bc, draftPath)
+	return err
+}
+
+func (*goBlog) sendEditorMessage(ctx context.Context, c *ws.Conn, prefix string, m *editorOperationMessage) error {
+	w, err := c.Writer(ctx, ws.MessageText)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, prefix); err != nil {
+		return errors.Join(err, w.Close())
+	}
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		return errors.Join(err, w.Close())
+	}
+	return w.Close()
 }
 
 func (*goBlog) sendEditorState(ctx context.Context, c *ws.Conn, state []byte) error {
@@ -146,6 +355,44 @@ func (a *goBlog) getEditorStateFromDatabase(ctx context.Context, blog string) ([
 	return a.db.retrievePersistentCacheContext(ctx, editorStateCacheKey+blog)
 }
 
+const editorPostDraftCacheKey = "editordraft_"
+
+// editorPostDraft is the autosaved content of the editor for an existing post,
+// Hash identifies the version of the post the draft is based on.
+type editorPostDraft struct {
+	Hash    string `json:"hash"`
+	Content string `json:"content"`
+}
+
+// updateEditorPostDraft saves the draft of a post, an empty state removes it.
+func (a *goBlog) updateEditorPostDraft(ctx context.Context, path, hash string, state []byte) {
+	if len(state) == 0 {
+		_ = a.deleteEditorPostDraft(ctx, path)
+		return
+	}
+	data, err := json.Marshal(&editorPostDraft{Hash: hash, Content: string(state)})
+	if err != nil {
+		return
+	}
+	_ = a.db.cachePersistentlyContext(ctx, editorPostDraftCacheKey+path, data)
+}
+
+func (a *goBlog) getEditorPostDraft(ctx context.Context, path string) (*editorPostDraft, error) {
+	data, err := a.db.retrievePersistentCacheContext(ctx, editorPostDraftCacheKey+path)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	draft := &editorPostDraft{}
+	if err = json.Unmarshal(data, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func (a *goBlog) deleteEditorPostDraft(ctx context.Context, path string) error {
+	return a.db.deletePersistentCacheContext(ctx, editorPostDraftCacheKey+path)
+}
+
 // PREVIEW
 
 func (a *goBlog) sendEditorPreview(ctx context.Context, c *ws.Conn, blog string, md []byte) error {
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/websocket"
@@ -115,3 +116,123 @@ func Test_editorSync(t *testing.T) {
 	msgStr = string(msg)
 	assert.Equal(t, "sync:Test", msgStr)
 }
+
+func Test_editorPostDraftSync(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig(false)
+
+	h := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		app.serveEditorWebsocket(rw, r.WithContext(context.WithValue(r.Context(), blogKey, "default")))
+	})
+
+	dial := func(query string) *websocket.Conn {
+		c, resp, err := wstest.NewDialer(h).Dial("ws://example.com/editor/ws?"+query, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return c
+	}
+
+	create1, create2 := dial("sync=1"), dial("sync=1")
+	c1 := dial("sync=1&path=%2Fposts%2Fa&hash=abc")
+	c2 := dial("sync=1&path=%2Fposts%2Fa&hash=abc")
+
+	// Drafts are only synced between editors of the same post
+	require.NoError(t, c1.WriteMessage(websocket.TextMessage, []byte("Draft")))
+	_, msg, err := c2.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "sync:Draft", string(msg))
+
+	require.NoError(t, create1.WriteMessage(websocket.TextMessage, []byte("New")))
+	_, msg, err = create2.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "sync:New", string(msg))
+	require.NoError(t, c2.WriteMessage(websocket.TextMessage, []byte("Draft")))
+	_, msg, err = c1.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "sync:Draft", string(msg))
+
+	draft, err := app.getEditorPostDraft(context.Background(), "/posts/a")
+	require.NoError(t, err)
+	require.NotNil(t, draft)
+	assert.Equal(t, "Draft", draft.Content)
+	assert.Equal(t, "abc", draft.Hash)
+
+	// New editors receive the draft
+	c3 := dial("sync=1&path=%2Fposts%2Fa")
+	_, msg, err = c3.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "sync:Draft", string(msg))
+
+	// Empty state removes the draft
+	require.NoError(t, c3.WriteMessage(websocket.TextMessage, []byte("")))
+	_, _, err = c1.ReadMessage()
+	require.NoError(t, err)
+	draft, err = app.getEditorPostDraft(context.Background(), "/posts/a")
+	require.NoError(t, err)
+	assert.Nil(t, draft)
+}
+
+func Test_editorCollaboration(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig(false)
+
+	h := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		app.serveEditorWebsocket(rw, r.WithContext(context.WithValue(r.Context(), blogKey, "default")))
+	})
+
+	dial := func(query string) *websocket.Conn {
+		c, resp, err := wstest.NewDialer(h).Dial("ws://example.com/editor/ws?"+query, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return c
+	}
+	read := func(c *websocket.Conn) string {
+		_, msg, err := c.ReadMessage()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(msg))
+	}
+	// Reads messages until one with the prefix
+	readUntil := func(c *websocket.Conn, prefix string) (msgs []string) {
+		for {
+			msg := read(c)
+			msgs = append(msgs, msg)
+			if strings.HasPrefix(msg, prefix) {
+				return msgs
+			}
+		}
+	}
+
+	c1, c2 := dial("sync=1&ot=1"), dial("sync=1&ot=1")
+	assert.Equal(t, `doc:{"rev":0,"text":""}`, read(c1))
+	assert.Equal(t, `doc:{"rev":0,"text":""}`, read(c2))
+	legacy := dial("sync=1")
+
+	require.NoError(t, c1.WriteMessage(websocket.TextMessage, []byte(`op:{"rev":0,"op":["Hello"]}`)))
+	assert.Equal(t, []string{`ack:{"rev":1}`}, readUntil(c1, "ack:"))
+	assert.Equal(t, `op:{"rev":1,"op":["Hello"]}`, read(c2))
+	assert.Equal(t, "sync:Hello", read(legacy))
+
+	// Concurrent operations based on the same revision are merged
+	require.NoError(t, c1.WriteMessage(websocket.TextMessage, []byte(`op:{"rev":1,"op":[5," World"]}`)))
+	require.NoError(t, c2.WriteMessage(websocket.TextMessage, []byte(`op:{"rev":1,"op":["Oh, ",5]}`)))
+	readUntil(c1, "ack:")
+	readUntil(c2, "ack:")
+	assert.Equal(t, `doc:{"rev":3,"text":"Oh, Hello World"}`, read(dial("sync=1&ot=1")))
+	state, err := app.getEditorStateFromDatabase(context.Background(), "default")
+	require.NoError(t, err)
+	assert.Equal(t, "Oh, Hello World", string(state))
+
+	// Invalid operations get the current state
+	require.NoError(t, c1.WriteMessage(websocket.TextMessage, []byte(`op:{"rev":3,"op":[100]}`)))
+	msgs := readUntil(c1, "doc:")
+	assert.Equal(t, `doc:{"rev":3,"text":"Oh, Hello World"}`, msgs[len(msgs)-1])
+
+	// Reset after creating the post
+	require.NoError(t, c2.WriteMessage(websocket.TextMessage, []byte("reset")))
+	readUntil(c2, "ack:")
+	assert.Equal(t, `doc:{"rev":4,"text":""}`, read(dial("sync=1&ot=1")))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -9,6 +10,9 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEditorHandleFileAttachments(t *testing.T) {
@@ -45,7 +49,7 @@ func TestEditorHandleFileAttachments(t *testing.T) {
 				"track.gpx": "<gpx>valid gpx content</gpx>",
 			},
 		})
-		images, gpx, statusCode, err := a.editorHandleFileAttachments(req)
+		images, _, gpx, statusCode, err := a.editorHandleFileAttachments(req)
 		if err != nil || statusCode != 0 {
 			t.Fatalf("Expected no error, got %v with status code %d", err, statusCode)
 		}
@@ -63,7 +67,7 @@ func TestEditorHandleFileAttachments(t *testing.T) {
 				"track.gpx": "invalid gpx content",
 			},
 		})
-		_, _, statusCode, err := a.editorHandleFileAttachments(req)
+		_, _, _, statusCode, err := a.editorHandleFileAttachments(req)
 		if err == nil || statusCode != http.StatusBadRequest {
 			t.Fatalf("Expected error with status code %d, got %v with status code %d", http.StatusBadRequest, err, statusCode)
 		}
@@ -80,7 +84,7 @@ func TestEditorHandleFileAttachments(t *testing.T) {
 				"image3.jpg": "image content",
 			},
 		})
-		images, gpx, statusCode, err := a.editorHandleFileAttachments(req)
+		images, _, gpx, statusCode, err := a.editorHandleFileAttachments(req)
 		if err != nil || statusCode != 0 {
 			t.Fatalf("Expected no error, got %v with status code %d", err, statusCode)
 		}
@@ -94,7 +98,7 @@ func TestEditorHandleFileAttachments(t *testing.T) {
 
 	t.Run("No files", func(t *testing.T) {
 		req := createMultipartRequest(map[string]map[string]string{})
-		images, gpx, statusCode, err := a.editorHandleFileAttachments(req)
+		images, _, gpx, statusCode, err := a.editorHandleFileAttachments(req)
 		if err != nil || statusCode != 0 {
 			t.Fatalf("Expected no error, got %v with status code %d", err, statusCode)
 		}
@@ -188,3 +192,77 @@ func TestServeEditorPost(t *testing.T) {
 		}
 	})
 }
+
+func Test_editorUpdateConflict(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:    "/posts/a",
+		Section: "posts",
+		Content: "Original content",
+		Updated: "2024-01-01T00:00:00Z",
+	}))
+	p, err := app.getPost("/posts/a")
+	require.NoError(t, err)
+	openedHash := editorPostHash(p)
+
+	editor := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/editor", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		app.postHooksWg.Wait()
+		return rec
+	}
+
+	// Autosaved draft is restored
+	app.updateEditorPostDraft(context.Background(), "/posts/a", openedHash, []byte("Draft content"))
+	rec := editor(url.Values{"editoraction": {"loadupdate"}, "path": {"/posts/a"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "update-draft")
+	assert.Contains(t, rec.Body.String(), "Draft content")
+
+	// Discarding the draft loads the post
+	rec = editor(url.Values{"editoraction": {"discarddraft"}, "path": {"/posts/a"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "update-draft")
+	assert.NotContains(t, rec.Body.String(), "Draft content")
+	draft, err := app.getEditorPostDraft(context.Background(), "/posts/a")
+	require.NoError(t, err)
+	assert.Nil(t, draft)
+
+	// Another device updates the post without changing the updated date
+	p.Content = "Content from another device"
+	require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, true))
+	p, err = app.getPost("/posts/a")
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-01T00:00:00Z", toUTCSafe(p.Updated))
+
+	// Updating with the old hash shows the conflict
+	app.updateEditorPostDraft(context.Background(), "/posts/a", openedHash, []byte("My content"))
+	rec = editor(url.Values{"editoraction": {"updatepost"}, "url": {"https://example.com/posts/a"}, "hash": {openedHash}, "content": {"My content"}})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "update-conflict")
+	assert.Contains(t, rec.Body.String(), "<del>- Content from another device</del>")
+	assert.Contains(t, rec.Body.String(), "<ins>+ My content</ins>")
+	p, err = app.getPost("/posts/a")
+	require.NoError(t, err)
+	assert.Equal(t, "Content from another device", p.Content)
+
+	// Updating again after merging applies the update and removes the draft
+	rec = editor(url.Values{"editoraction": {"updatepost"}, "url": {"https://example.com/posts/a"}, "hash": {editorPostHash(p)}, "content": {"Merged content"}})
+	assert.Equal(t, http.StatusFound, rec.Code)
+	p, err = app.getPost("/posts/a")
+	require.NoError(t, err)
+	assert.Equal(t, "Merged content", p.Content)
+	draft, err = app.getEditorPostDraft(context.Background(), "/posts/a")
+	require.NoError(t, err)
+	assert.Nil(t, draft)
+}
@@ -24,19 +24,19 @@ func (a *goBlog) postPostHooks(p *post) {
 	// Hooks after post published
 	if hc := a.cfg.Hooks; hc != nil {
 		for _, cmdTmplString := range hc.PostPost {
-			go func(p *post, cmdTmplString string) {
+			a.postHooksWg.Go(func() {
 				a.executeHookTemplateCommand("post-post", cmdTmplString, map[string]any{
 					"URL":  a.fullPostURL(p),
 					"Post": p,
 				})
-			}(p, cmdTmplString)
+			})
 		}
 	}
 	for _, f := range a.pPostHooks {
-		go f(p)
+		a.postHooksWg.Go(func() { f(p) })
 	}
 	for _, plugin := range a.getPlugins(pluginPostCreatedHookType) {
-		go plugin.(plugintypes.PostCreatedHook).PostCreated(p)
+		a.postHooksWg.Go(func() { plugin.(plugintypes.PostCreatedHook).PostCreated(p) })
 	}
 }
 
@@ -44,54 +44,54 @@ func (a *goBlog) postUpdateHooks(p *post) {
 	// Hooks after post updated
 	if hc := a.cfg.Hooks; hc != nil {
 		for _, cmdTmplString := range hc.PostUpdate {
-			go func(p *post, cmdTmplString string) {
+			a.postHooksWg.Go(func() {
 				a.executeHookTemplateCommand("post-update", cmdTmplString, map[string]any{
 					"URL":  a.fullPostURL(p),
 					"Post": p,
 				})
-			}(p, cmdTmplString)
+			})
 		}
 	}
 	for _, f := range a.pUpdateHooks {
-		go f(p)
+		a.postHooksWg.Go(func() { f(p) })
 	}
 	for _, plugin := range a.getPlugins(pluginPostUpdatedHookType) {
-		go plugin.(plugintypes.PostUpdatedHook).PostUpdated(p)
+		a.postHooksWg.Go(func() { plugin.(plugintypes.PostUpdatedHook).PostUpdated(p) })
 	}
 }
 
 func (a *goBlog) postDeleteHooks(p *post) {
 	if hc := a.cfg.Hooks; hc != nil {
 		for _, cmdTmplString := range hc.PostDelete {
-			go func(p *post, cmdTmplString string) {
+			a.postHooksWg.Go(func() {
 				a.executeHookTemplateCommand("post-delete", cmdTmplString, map[string]any{
 					"URL":  a.fullPostURL(p),
 					"Post": p,
 				})
-			}(p, cmdTmplString)
+			})
 		}
 	}
 	for _, f := range a.pDeleteHooks {
-		go f(p)
+		a.postHooksWg.Go(func() { f(p) })
 	}
 	for _, plugin := range a.getPlugins(pluginPostDeletedHookType) {
-		go plugin.(plugintypes.PostDeletedHook).PostDeleted(p)
+		a.postHooksWg.Go(func() { plugin.(plugintypes.PostDeletedHook).PostDeleted(p) })
 	}
 }
 
 func (a *goBlog) postUndeleteHooks(p *post) {
 	if hc := a.cfg.Hooks; hc != nil {
 		for _, cmdTmplString := range hc.PostUndelete {
-			go func(p *post, cmdTmplString string) {
+			a.postHooksWg.Go(func() {
 				a.executeHookTemplateCommand("post-undelete", cmdTmplString, map[string]any{
 					"URL":  a.fullPostURL(p),
 					"Post": p,
 				})
-			}(p, cmdTmplString)
+			})
 		}
 	}
 	for _, f := range a.pUndeleteHooks {
-		go f(p)
+		a.postHooksWg.Go(func() { f(p) })
 	}
 }
 
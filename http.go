@@ -31,6 +31,7 @@ const (
 	blogKey       contextKey = "blog"
 	pathKey       contextKey = "httpPath"
 	altAddressKey contextKey = "altAddress"
+	remoteAddrKey contextKey = "remoteAddr"
 )
 
 func (a *goBlog) startServer() (err error) {
@@ -212,6 +213,11 @@ func (a *goBlog) buildRouter() http.Handler {
 	// Webmentions
 	r.Route(webmentionPath, a.webmentionsRouter)
 
+	// WebSub hub
+	if a.webSubEnabled() && a.cfg.WebSub.BuiltInHub {
+		r.With(bodylimit.BodyLimit(10*bodylimit.KB)).Post(webSubHubPath, a.serveWebSubHub)
+	}
+
 	// Notifications
 	r.Route(notificationsPath, a.notificationsRouter)
 
@@ -286,6 +292,9 @@ func (a *goBlog) servePostsAliasesRedirects() http.HandlerFunc {
 		-- post aliases
 		select 'alias', path, '', 302 from post_parameters where parameter = 'aliases' and value = @path
 		union all
+		-- old paths of renamed posts
+		select 'redirect', path, '', 301 from post_redirects where from_path = @path
+		union all
 		-- deleted posts
 		select 'deleted', '', '', 410 from deleted where path = @path
 		-- just select the first result
@@ -318,6 +327,8 @@ func (a *goBlog) servePostsAliasesRedirects() http.HandlerFunc {
 					switch postVisibility(value2) {
 					case visibilityPublic, visibilityUnlisted:
 						alicePrivate.Append(a.checkActivityStreamsRequest, a.cacheMiddleware).ThenFunc(a.servePost).ServeHTTP(w, r)
+					case visibilityProtected, visibilityLink:
+						alicePrivate.ThenFunc(a.serveRestrictedPost).ServeHTTP(w, r)
 					default: // private, etc.
 						alice.New(a.authMiddleware).ThenFunc(a.servePost).ServeHTTP(w, r)
 					}
@@ -339,6 +350,10 @@ func (a *goBlog) servePostsAliasesRedirects() http.HandlerFunc {
 					http.Redirect(w, r, value1, status)
 				}).ServeHTTP(w, r)
 				return
+			case "redirect":
+				// Post was renamed, permanently redirect
+				a.servePostRedirect(value1).ServeHTTP(w, r)
+				return
 			case "deleted":
 				// Is deleted, serve 410
 				alicePrivate.Append(a.cacheMiddleware).ThenFunc(a.serve410).ServeHTTP(w, r)
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"net"
 	"net/http"
+	"net/netip"
+	"slices"
+	"syscall"
 	"time"
 
 	"github.com/klauspost/compress/gzhttp"
@@ -16,25 +20,83 @@ func newHttpClient() *http.Client {
 }
 
 func newHttpTransport() http.RoundTripper {
-	return newAddUserAgentTransport(
-		gzhttp.Transport(
-			&http.Transport{
-				// Default
-				Proxy:                 http.ProxyFromEnvironment,
-				ForceAttemptHTTP2:     true,
-				MaxIdleConns:          100,
-				IdleConnTimeout:       90 * time.Second,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ExpectContinueTimeout: 1 * time.Second,
-				DialContext: (&net.Dialer{
-					Timeout:   30 * time.Second,
-					KeepAlive: 30 * time.Second,
-				}).DialContext,
-				// Custom
-				DisableKeepAlives: true,
-			},
-		),
-	)
+	return newAddUserAgentTransport(gzhttp.Transport(newBaseHttpTransport()))
+}
+
+func newBaseHttpTransport() *http.Transport {
+	return &http.Transport{
+		// Default
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		// Custom
+		DisableKeepAlives: true,
+	}
+}
+
+// newPublicHttpClient returns a client for requests to URLs provided by third parties,
+// it refuses to connect to loopback, private and link-local addresses.
+// The address is checked when dialing, so DNS rebinding and redirects can't be used to bypass it.
+func newPublicHttpClient() *http.Client {
+	transport := newBaseHttpTransport()
+	// Connect directly, a proxy would hide the address of the destination
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkPublicDialAddress,
+	}).DialContext
+	return &http.Client{
+		Timeout:   time.Minute,
+		Transport: newAddUserAgentTransport(gzhttp.Transport(transport)),
+	}
+}
+
+func checkPublicDialAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicIP(ip) {
+		return fmt.Errorf("connection to non-public address %s refused", ip)
+	}
+	return nil
+}
+
+// nonPublicPrefixes are special-purpose ranges that aren't globally reachable and not covered by the netip checks
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // This network
+	netip.MustParsePrefix("100.64.0.0/10"),   // Shared address space (carrier-grade NAT)
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, can reach internal IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // Local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // Discard-only
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+}
+
+// isPublicIP checks that the IP isn't loopback, private, link-local (including cloud metadata services), unspecified, multicast
+// or in another range that isn't globally reachable
+func isPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() &&
+		!slices.ContainsFunc(nonPublicPrefixes, func(prefix netip.Prefix) bool { return prefix.Contains(ip) })
 }
 
 type addUserAgentTransport struct {
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"sync"
 	"testing"
 
@@ -93,3 +94,41 @@ func Test_addUserAgent(t *testing.T) {
 
 	assert.Equal(t, appUserAgent, ua)
 }
+
+func Test_publicHttpClient(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"93.184.215.14":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.0.0.1":        false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"0.0.0.0":         false,
+		"::ffff:10.0.0.1": false,
+		"100.64.0.1":      false,
+		"100.127.255.254": false,
+		"100.128.0.1":     true,
+		"192.0.0.8":       false,
+		"198.18.0.1":      false,
+		"198.19.255.255":  false,
+		"198.20.0.1":      true,
+		"240.0.0.1":       false,
+		"255.255.255.255": false,
+		"64:ff9b::a00:1":  false,
+		"64:ff9b::7f00:1": false,
+		"64:ff9b:1::1":    false,
+		"2001:db8::1":     false,
+	} {
+		assert.Equal(t, public, isPublicIP(netip.MustParseAddr(ip)), ip)
+	}
+
+	// The address is checked when connecting
+	srv := httptest.NewServer(testHttpHandler())
+	defer srv.Close()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+	_, err := newPublicHttpClient().Do(req)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "non-public address 127.0.0.1")
+}
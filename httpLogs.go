@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"time"
 
@@ -25,8 +27,21 @@ func (a *goBlog) initHTTPLog() (err error) {
 func (a *goBlog) logMiddleware(next http.Handler) http.Handler {
 	h := handlers.CombinedLoggingHandler(a.logf, next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Remove remote address for privacy
+		// Remove remote address for privacy, but keep it in the context for rate limiting
+		r = r.WithContext(context.WithValue(r.Context(), remoteAddrKey, r.RemoteAddr))
 		r.RemoteAddr = ""
 		h.ServeHTTP(w, r)
 	})
 }
+
+// remoteIP returns the IP of the client, also if the remote address got removed for the logs
+func remoteIP(r *http.Request) string {
+	addr := r.RemoteAddr
+	if ctxAddr, ok := r.Context().Value(remoteAddrKey).(string); ok {
+		addr = ctxAddr
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
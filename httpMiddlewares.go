@@ -10,6 +10,7 @@ import (
 	"github.com/tiptophelmet/cspolicy/directives"
 	"github.com/tiptophelmet/cspolicy/directives/constraint"
 	"github.com/tiptophelmet/cspolicy/src"
+	"go.goblog.app/app/pkgs/s3"
 )
 
 func noIndexHeader(next http.Handler) http.Handler {
@@ -51,6 +52,12 @@ func (a *goBlog) securityHeaders(next http.Handler) http.Handler {
 		if u, err := url.Parse(mp.MediaURL); err == nil {
 			allowedDomains = append(allowedDomains, u.Hostname())
 		}
+	} else if mp != nil && mp.S3Endpoint != "" {
+		// Media files are served directly from the bucket
+		bucket := &s3.Client{Endpoint: mp.S3Endpoint, Bucket: mp.S3Bucket, PathStyle: mp.S3PathStyle}
+		if u, err := url.Parse(bucket.URL("")); err == nil {
+			allowedDomains = append(allowedDomains, u.Hostname())
+		}
 	}
 	allowedDomains = lo.Uniq(lo.Filter(allowedDomains, func(v string, _ int) bool { return v != "" }))
 	defaultSrcList := []src.SourceVal{src.Self(), src.Scheme("blob:")}
@@ -134,6 +134,9 @@ func (a *goBlog) otherRoutesRouter(r chi.Router) {
 		r.With(bodylimit.BodyLimit(10*bodylimit.KB)).Post("/reactions", a.postReaction)
 	}
 
+	// Unlock password-protected posts
+	r.With(bodylimit.BodyLimit(10*bodylimit.KB)).Post(postUnlockPath, a.servePostUnlock)
+
 	// Reload router
 	r.With(a.authMiddleware).Get("/reload", a.serveReloadRouter)
 }
@@ -160,6 +163,9 @@ func (a *goBlog) blogRouter(blog string, conf *configBlog) func(r chi.Router) {
 		// Photos
 		r.Group(a.blogPhotosRouter(conf))
 
+		// Series
+		r.Group(a.blogSeriesRouter(conf))
+
 		// Search
 		r.Group(a.blogSearchRouter(conf))
 
@@ -308,6 +314,21 @@ func (a *goBlog) blogPhotosRouter(conf *configBlog) func(r chi.Router) {
 	}
 }
 
+// Blog - Series
+func (a *goBlog) blogSeriesRouter(conf *configBlog) func(r chi.Router) {
+	return func(r chi.Router) {
+		if conf.seriesEnabled() {
+			seriesPath := conf.getSeriesPath("")
+			r.Use(
+				a.privateModeHandler,
+				a.cacheMiddleware,
+			)
+			r.Get(seriesPath, a.serveSeriesIndex)
+			r.Get(seriesPath+"/{series}", a.serveSeries)
+		}
+	}
+}
+
 // Blog - Search
 func (a *goBlog) blogSearchRouter(conf *configBlog) func(r chi.Router) {
 	return func(r chi.Router) {
@@ -326,6 +347,8 @@ func (a *goBlog) blogSearchRouter(conf *configBlog) func(r chi.Router) {
 					r.Get(searchResultPath, a.serveSearchResult)
 					r.Get(searchResultPath+feedPath, a.serveSearchResult)
 					r.Get(searchResultPath+paginationPath, a.serveSearchResult)
+					r.Get(searchApiPath, a.serveSearchApi)
+					r.Get(searchSuggestionsPath, a.serveSearchSuggestions)
 				})
 				r.With(
 					// No private mode, to allow using OpenSearch in browser
@@ -367,6 +390,20 @@ func (a *goBlog) blogEditorRouter(_ *configBlog) func(r chi.Router) {
 		r.Get(editorFileUsesPath+editorFileUsesPathPlaceholder, a.serveEditorFilesUsesResults)
 		r.Get(editorFileUsesPath+editorFileUsesPathPlaceholder+paginationPath, a.serveEditorFilesUsesResults)
 		r.Post(editorFileDeletePath, a.serveEditorFilesDelete)
+		r.Get(editorFileEditPath, a.serveEditorFilesEdit)
+		r.Post(editorFileEditPath, a.serveEditorFilesEditSave)
+		r.Get(editorFilesUnusedPath, a.serveEditorFilesUnused)
+		r.Post(editorFilesUnusedPath, a.serveEditorFilesUnusedDelete)
+		r.Get(editorRevisionsPath, a.serveEditorRevisions)
+		r.Post(editorRevisionsRestorePath, a.serveEditorRevisionRestore)
+		r.Get(editorShareLinksPath, a.serveEditorShareLinks)
+		r.Post(editorShareLinksCreatePath, a.serveEditorShareLinksCreate)
+		r.Post(editorShareLinksRevokePath, a.serveEditorShareLinksRevoke)
+		r.Get(editorPostingQueuePath, a.serveEditorPostingQueue)
+		r.Post(editorPostingQueuePath, a.serveEditorPostingQueuePost)
+		r.Get(editorPostsPath, a.servePostsAdmin)
+		r.Get(editorPostsPath+paginationPath, a.servePostsAdmin)
+		r.Post(editorPostsBulkPath, a.servePostsAdminBulk)
 		r.Get("/drafts", a.serveDrafts)
 		r.Get("/drafts"+feedPath, a.serveDrafts)
 		r.Get("/drafts"+paginationPath, a.serveDrafts)
@@ -376,6 +413,9 @@ func (a *goBlog) blogEditorRouter(_ *configBlog) func(r chi.Router) {
 		r.Get("/unlisted", a.serveUnlisted)
 		r.Get("/unlisted"+feedPath, a.serveUnlisted)
 		r.Get("/unlisted"+paginationPath, a.serveUnlisted)
+		r.Get("/shared", a.serveShared)
+		r.Get("/shared"+feedPath, a.serveShared)
+		r.Get("/shared"+paginationPath, a.serveShared)
 		r.Get("/scheduled", a.serveScheduled)
 		r.Get("/scheduled"+feedPath, a.serveScheduled)
 		r.Get("/scheduled"+paginationPath, a.serveScheduled)
@@ -504,5 +544,12 @@ func (a *goBlog) blogSettingsRouter(_ *configBlog) func(r chi.Router) {
 		r.Post(settingsDeleteTOTPPath, a.settingsDeleteTOTP)
 		r.Post(settingsCreateAppPasswordPath, a.settingsCreateAppPassword)
 		r.Post(settingsDeleteAppPasswordPath, a.settingsDeleteAppPassword)
+		r.Get(settingsBackupPath, a.serveBackup)
+		r.Route(settingsTaxonomyPath+"/{taxonomy}", func(r chi.Router) {
+			r.Get("/", a.serveTaxonomyAdmin)
+			r.Post(settingsTaxonomyRenamePath, a.settingsRenameTaxonomyValue())
+			r.Post(settingsTaxonomyMergePath, a.settingsMergeTaxonomyValue())
+			r.Post(settingsTaxonomyDeletePath, a.settingsDeleteTaxonomyValue())
+		})
 	}
 }
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/araddon/dateparse"
+	"github.com/samber/lo"
+)
+
+type importOptions struct {
+	dryRun    bool // Only report what would be imported
+	skipHooks bool // Don't trigger hooks (webmentions, ActivityPub, ...)
+}
+
+type importAction string
+
+const (
+	importActionCreate    importAction = "create"
+	importActionUpdate    importAction = "update"
+	importActionUnchanged importAction = "unchanged"
+	importActionConflict  importAction = "conflict"
+	importActionSkip      importAction = "skip"
+	importActionError     importAction = "error"
+)
+
+type importResult struct {
+	File   string
+	Path   string
+	Action importAction
+	Reason string
+}
+
+// blogImportOptions are the options to import posts from other blog software.
+type blogImportOptions struct {
+	importOptions
+	blog               string // Blog to import to, defaults to the default blog
+	section            string // Section for posts, defaults to the default section of the blog
+	tagsTaxonomy       string // Taxonomy for tags, values are dropped if not configured
+	categoriesTaxonomy string // Taxonomy for categories, values are dropped if not configured
+}
+
+// importTaxonomy returns the taxonomy name if it's configured for the blog, otherwise an empty string.
+func (a *goBlog) importTaxonomy(blog, taxonomy string) string {
+	if bc, ok := a.cfg.Blogs[blog]; ok && taxonomy != "" {
+		if lo.ContainsBy(bc.Taxonomies, func(t *configTaxonomy) bool { return t.Name == taxonomy }) {
+			return taxonomy
+		}
+	}
+	return ""
+}
+
+// importAliasPath returns the path of an old URL to use it as alias, empty if not usable.
+func importAliasPath(oldURL, newPath string) string {
+	u, err := url.Parse(oldURL)
+	if err != nil || u.RawQuery != "" {
+		// Can't redirect URLs with query parameters
+		return ""
+	}
+	p := u.Path
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	if p == "" || p == "/" || p == newPath {
+		return ""
+	}
+	return p
+}
+
+// importMarkdownFiles reads all Markdown files (as written by exportMarkdownFiles) from the directory
+// and creates or updates the posts.
+func (a *goBlog) importMarkdownFiles(dir string, opts *importOptions) ([]*importResult, error) {
+	dir = cmp.Or(dir, "export")
+	if opts == nil {
+		opts = &importOptions{}
+	}
+	var results []*importResult
+	seen := map[string]string{}
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(file) != ".md" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		result := &importResult{File: rel}
+		results = append(results, result)
+		p, err := a.readImportFile(file, rel)
+		if err != nil {
+			result.Action, result.Reason = importActionError, err.Error()
+			return nil
+		}
+		result.Path = p.Path
+		// Check for duplicates in the import directory
+		if other, ok := seen[p.Path]; ok {
+			result.Action, result.Reason = importActionConflict, "same path as "+other
+			return nil
+		}
+		seen[p.Path] = rel
+		result.Action, result.Reason = a.importPost(p, opts)
+		return nil
+	})
+	return results, err
+}
+
+func (a *goBlog) readImportFile(file, rel string) (*post, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := &post{Content: string(content)}
+	if err = a.processContentAndParameters(p); err != nil {
+		return nil, err
+	}
+	if p.Path == "" {
+		// Fallback to the file name, export uses the post path
+		p.Path = "/" + strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+	}
+	return p, nil
+}
+
+func (a *goBlog) importPost(p *post, opts *importOptions) (importAction, string) {
+	if p.Path == "" {
+		// Generate path to check for existing posts
+		if err := a.checkPost(p, true, true); err != nil {
+			return importActionError, err.Error()
+		}
+	}
+	existing, err := a.getPost(p.Path)
+	if err != nil && !errors.Is(err, errPostNotFound) {
+		return importActionError, err.Error()
+	}
+	if existing == nil {
+		if !opts.dryRun {
+			if err := a.saveImportedPost(p, nil, opts); err != nil {
+				return importActionError, err.Error()
+			}
+		}
+		return importActionCreate, ""
+	}
+	if a.importUnchanged(existing, p) {
+		return importActionUnchanged, ""
+	}
+	// Don't overwrite changes made after the file was written
+	if importNewer(existing, p) {
+		return importActionConflict, fmt.Sprintf("post was updated at %s, after the imported version", cmp.Or(existing.Updated, existing.Published))
+	}
+	if !opts.dryRun {
+		if err := a.saveImportedPost(p, existing, opts); err != nil {
+			return importActionError, err.Error()
+		}
+	}
+	return importActionUpdate, ""
+}
+
+// importUnchanged checks if the imported post is the same as the existing post,
+// the password is only stored as hash and compared separately.
+func (a *goBlog) importUnchanged(existing, p *post) bool {
+	password, hasPassword := p.Parameters[postPasswordParameter]
+	if hasPassword {
+		if !checkPasswordHash(p.firstParameter(postPasswordParameter), a.db.getPostPasswordHash(existing.Path)) {
+			return false
+		}
+		delete(p.Parameters, postPasswordParameter)
+		defer func() { p.Parameters[postPasswordParameter] = password }()
+	}
+	return existing.contentWithParams() == p.contentWithParams()
+}
+
+// importNewer checks if the existing post was updated after the imported post.
+func importNewer(existing, imported *post) bool {
+	existingTime, err := dateparse.ParseLocal(cmp.Or(existing.Updated, existing.Published))
+	if err != nil {
+		return false
+	}
+	importedTime, err := dateparse.ParseLocal(cmp.Or(imported.Updated, imported.Published))
+	if err != nil {
+		return true
+	}
+	return existingTime.After(importedTime)
+}
+
+func (a *goBlog) saveImportedPost(p, existing *post, opts *importOptions) error {
+	if !opts.skipHooks {
+		if existing == nil {
+			return a.createPost(p)
+		}
+		return a.replacePost(p, existing.Path, existing.Status, existing.Visibility, true)
+	}
+	// Save without triggering hooks
+	o := &postCreationOptions{new: existing == nil, noUpdated: true}
+	if existing != nil {
+		o.oldPath, o.oldStatus, o.oldVisibility = existing.Path, existing.Status, existing.Visibility
+	}
+	return a.savePostWithoutHooks(p, o)
+}
+
+// initImportHooks initializes the components that add hooks for created or updated posts.
+func (a *goBlog) initImportHooks() error {
+	for _, f := range []func() error{
+		a.initTemplateAssets, a.initTemplateStrings, a.initActivityPub,
+	} {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	for _, f := range []func(){
+		a.initWebmention, a.initTelegram, a.initAtproto, a.initTTS, a.initIndexNow, a.initWebSub,
+	} {
+		f()
+	}
+	return nil
+}
+
+// importMedia uploads media files referenced by imported posts and remembers the new locations.
+type importMedia struct {
+	a        *goBlog
+	dryRun   bool
+	open     func(ref string) (io.ReadCloser, error)
+	uploaded map[string]string
+}
+
+func (a *goBlog) newImportMedia(dryRun bool, open func(ref string) (io.ReadCloser, error)) *importMedia {
+	return &importMedia{a: a, dryRun: dryRun, open: open, uploaded: map[string]string{}}
+}
+
+// location returns the new location of the media file, it gets uploaded if not already done.
+func (m *importMedia) location(ref string) (string, error) {
+	if loc, ok := m.uploaded[ref]; ok {
+		return loc, nil
+	}
+	if m.dryRun {
+		return ref, nil
+	}
+	rc, err := m.open(ref)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	// Reuse an identical file that was already uploaded
+	hash := sha256.Sum256(data)
+	if existing, err := m.a.existingMediaLocation(fmt.Sprintf("%x", hash)); err != nil {
+		return "", err
+	} else if existing != "" {
+		m.uploaded[ref] = existing
+		return existing, nil
+	}
+	// Use the same file naming as for Micropub uploads
+	ext := path.Ext(ref)
+	if u, err := url.Parse(ref); err == nil {
+		ext = path.Ext(u.Path)
+	}
+	fileName := fmt.Sprintf("%x%s", hash, strings.ToLower(ext))
+	loc, err := m.a.saveMediaFile(fileName, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	m.uploaded[ref] = loc
+	return loc, nil
+}
+
+// rewrite uploads all referenced media files and replaces the references in the content.
+// refs maps the references in the content to the sources to upload.
+func (m *importMedia) rewrite(content string, refs map[string]string) (string, error) {
+	// Replace longer references first, so they aren't replaced partially
+	sortedRefs := slices.SortedFunc(maps.Keys(refs), func(a, b string) int { return len(b) - len(a) })
+	var errs []error
+	for _, ref := range sortedRefs {
+		loc, err := m.location(refs[ref])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to import media %s: %w", ref, err))
+			continue
+		}
+		content = strings.ReplaceAll(content, ref, loc)
+	}
+	return content, errors.Join(errs...)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	hugoFigureRegex    = regexp.MustCompile(`{{[<%]\s*figure\s+(.*?)\s*/?[>%]}}`)
+	hugoAttributeRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	hugoMediaRefRegex  = regexp.MustCompile(`(?:\]\(|src=")([^)"\s]+)`)
+)
+
+// importHugo imports posts from a Hugo content directory.
+// Media files are searched relative to the post (page bundles) or in the static directory for absolute references.
+func (a *goBlog) importHugo(dir, staticDir string, opts *blogImportOptions) ([]*importResult, error) {
+	blog := cmp.Or(opts.blog, a.cfg.DefaultBlog)
+	bc, ok := a.cfg.Blogs[blog]
+	if !ok {
+		return nil, errors.New("blog doesn't exist")
+	}
+	staticDir = cmp.Or(staticDir, filepath.Join(dir, "..", "static"))
+	media := a.newImportMedia(opts.dryRun, func(file string) (io.ReadCloser, error) {
+		return os.Open(file)
+	})
+	var results []*importResult
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(file)
+		if d.IsDir() || (ext != ".md" && ext != ".markdown") || strings.HasPrefix(d.Name(), "_") {
+			// Skip list pages like _index.md
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		result := &importResult{File: rel}
+		results = append(results, result)
+		p, err := a.hugoPost(file, filepath.ToSlash(rel), bc, blog, opts)
+		if err != nil {
+			result.Action, result.Reason = importActionError, err.Error()
+			return nil
+		}
+		// Upload media files and rewrite links
+		refs := map[string]string{}
+		for _, m := range hugoMediaRefRegex.FindAllStringSubmatch(p.Content, -1) {
+			ref := m[1]
+			if strings.Contains(ref, ":") || strings.HasPrefix(ref, "#") {
+				// External links, anchors, ...
+				continue
+			}
+			source := filepath.Join(filepath.Dir(file), filepath.FromSlash(ref))
+			if strings.HasPrefix(ref, "/") {
+				source = filepath.Join(staticDir, filepath.FromSlash(ref))
+			}
+			if info, err := os.Stat(source); err == nil && !info.IsDir() && filepath.Ext(source) != ext {
+				refs[ref] = source
+			}
+		}
+		var mediaErr error
+		p.Content, mediaErr = media.rewrite(p.Content, refs)
+		result.Action, result.Reason = a.importPost(p, &opts.importOptions)
+		result.Path = p.Path
+		if mediaErr != nil {
+			result.Reason = strings.TrimPrefix(result.Reason+", "+mediaErr.Error(), ", ")
+		}
+		return nil
+	})
+	return results, err
+}
+
+// hugoPost maps a Hugo content file to a post.
+func (a *goBlog) hugoPost(file, rel string, bc *configBlog, blog string, opts *blogImportOptions) (*post, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fm, content, err := hugoFrontMatter(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))))
+	if err != nil {
+		return nil, err
+	}
+	p := &post{
+		Blog:       blog,
+		Content:    hugoShortcodes(content),
+		Status:     statusPublished,
+		Visibility: visibilityPublic,
+		Parameters: map[string][]string{},
+	}
+	// Hugo uses the directory name for page bundles
+	dir, name := path.Split(strings.TrimSuffix(rel, path.Ext(rel)))
+	dir = strings.TrimSuffix(dir, "/")
+	if name == "index" {
+		dir, name = path.Split(dir)
+		dir = strings.TrimSuffix(dir, "/")
+	}
+	slug := cmp.Or(cast.ToString(fm["slug"]), name)
+	if dir == "" {
+		// Top level content is a page without section
+		p.Path = bc.getRelativePath("/" + slug)
+	} else {
+		p.Slug = slug
+		p.Section = cmp.Or(opts.section, bc.DefaultSection)
+		if section := strings.Split(dir, "/")[0]; bc.Sections[section] != nil {
+			p.Section = section
+		}
+	}
+	// Map front matter
+	for key, value := range fm {
+		switch key {
+		case "title":
+			p.Parameters["title"] = []string{cast.ToString(value)}
+		case "date", "publishDate":
+			if p.Published == "" || key == "publishDate" {
+				if p.Published, err = hugoDate(value); err != nil {
+					return nil, err
+				}
+			}
+		case "lastmod":
+			if p.Updated, err = hugoDate(value); err != nil {
+				return nil, err
+			}
+		case "draft":
+			if cast.ToBool(value) {
+				p.Status = statusDraft
+			}
+		case "summary", "description":
+			p.Parameters["summary"] = []string{cast.ToString(value)}
+		case "aliases":
+			for _, alias := range cast.ToStringSlice(value) {
+				if alias = importAliasPath(alias, p.Path); alias != "" {
+					p.Parameters["aliases"] = append(p.Parameters["aliases"], alias)
+				}
+			}
+		case "tags", "categories":
+			taxonomy := a.importTaxonomy(blog, lo.Ternary(key == "tags", opts.tagsTaxonomy, opts.categoriesTaxonomy))
+			if taxonomy != "" {
+				p.Parameters[taxonomy] = append(p.Parameters[taxonomy], cast.ToStringSlice(value)...)
+			}
+		default:
+			// Custom taxonomies with the same name
+			if taxonomy := a.importTaxonomy(blog, key); taxonomy != "" {
+				p.Parameters[taxonomy] = append(p.Parameters[taxonomy], cast.ToStringSlice(value)...)
+			}
+		}
+	}
+	if p.Status == statusPublished && p.Published != "" {
+		if published, err := dateparse.ParseLocal(p.Published); err == nil && published.After(time.Now()) {
+			p.Status = statusScheduled
+		}
+	}
+	// Redirect from the old URL
+	oldPath := "/" + path.Join(dir, slug)
+	if u := cast.ToString(fm["url"]); u != "" {
+		oldPath = u
+	}
+	if alias := importAliasPath(oldPath, p.Path); alias != "" {
+		p.Parameters["aliases"] = append(p.Parameters["aliases"], alias)
+	}
+	return p, nil
+}
+
+// hugoFrontMatter splits YAML, TOML or JSON front matter from the content.
+func hugoFrontMatter(s string) (map[string]any, string, error) {
+	fm := map[string]any{}
+	switch {
+	case strings.HasPrefix(s, "---\n"), strings.HasPrefix(s, "+++\n"):
+		separator := s[:3]
+		raw, content, found := strings.Cut(s[3:], "\n"+separator)
+		if !found {
+			return nil, "", errors.New("front matter not closed")
+		}
+		var err error
+		if separator == "+++" {
+			err = toml.Unmarshal([]byte(raw), &fm)
+		} else {
+			err = yaml.Unmarshal([]byte(raw), &fm)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse front matter: %w", err)
+		}
+		return fm, strings.TrimSpace(content), nil
+	case strings.HasPrefix(s, "{"):
+		dec := json.NewDecoder(strings.NewReader(s))
+		if err := dec.Decode(&fm); err != nil {
+			return nil, "", fmt.Errorf("failed to parse front matter: %w", err)
+		}
+		return fm, strings.TrimSpace(s[dec.InputOffset():]), nil
+	default:
+		return fm, strings.TrimSpace(s), nil
+	}
+}
+
+// hugoShortcodes converts figure shortcodes to Markdown images, other shortcodes are kept.
+func hugoShortcodes(content string) string {
+	return hugoFigureRegex.ReplaceAllStringFunc(content, func(s string) string {
+		attrs := map[string]string{}
+		for _, m := range hugoAttributeRegex.FindAllStringSubmatch(hugoFigureRegex.FindStringSubmatch(s)[1], -1) {
+			attrs[m[1]] = m[2]
+		}
+		if attrs["src"] == "" {
+			return s
+		}
+		alt := cmp.Or(attrs["alt"], attrs["caption"], attrs["title"])
+		if title := cmp.Or(attrs["title"], attrs["caption"]); title != "" {
+			return fmt.Sprintf("![%s](%s %q)", alt, attrs["src"], title)
+		}
+		return fmt.Sprintf("![%s](%s)", alt, attrs["src"])
+	})
+}
+
+// hugoDate converts front matter dates (strings or parsed dates) to the local time format.
+func hugoDate(value any) (string, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Local().Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return toLocal(v.String())
+	default:
+		return toLocal(cast.ToString(v))
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_importHugo(t *testing.T) {
+	app := newImportTestApp(t)
+
+	site := t.TempDir()
+	content := filepath.Join(site, "content")
+	writeFile := func(name, data string) {
+		file := filepath.Join(site, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(data), 0o644))
+	}
+	writeFile("content/_index.md", "---\ntitle: Home\n---\n")
+	writeFile("content/about.md", "---\ntitle: About\ndate: 2021-04-01T10:00:00Z\n---\nThis is me.\n")
+	writeFile("content/posts/_index.md", "---\ntitle: Posts\n---\n")
+	writeFile("content/posts/first.md", "---\ntitle: First\ndate: 2021-05-01T10:00:00Z\nlastmod: 2021-05-02T10:00:00Z\ntags: [Go, Hugo]\ncategories: [News]\naliases: [/old/first/]\ndescription: The first post\n---\nHello ![Logo](/images/logo.png)\n")
+	writeFile("content/posts/bundle/index.md", "+++\ntitle = \"Bundle\"\ndate = 2021-06-01T10:00:00Z\ndraft = true\n+++\n{{< figure src=\"photo.jpg\" caption=\"A photo\" >}}\n")
+	writeFile("content/posts/bundle/photo.jpg", "photo")
+	writeFile("content/notes/note.md", "{\"date\": \"2021-07-01T10:00:00Z\"}\nA note\n")
+	writeFile("static/images/logo.png", "logo")
+
+	opts := &blogImportOptions{
+		importOptions:      importOptions{skipHooks: true},
+		tagsTaxonomy:       "tags",
+		categoriesTaxonomy: "categories",
+	}
+
+	resultFor := func(results []*importResult, file string) *importResult {
+		r, _ := lo.Find(results, func(r *importResult) bool { return r.File == filepath.FromSlash(file) })
+		return r
+	}
+
+	results, err := app.importHugo(content, "", opts)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for _, r := range results {
+		assert.Equal(t, importActionCreate, r.Action, r.File+": "+r.Reason)
+	}
+
+	// Page
+	p, err := app.getPost(resultFor(results, "about.md").Path)
+	require.NoError(t, err)
+	assert.Equal(t, "/about", p.Path)
+	assert.Empty(t, p.Section)
+	assert.Equal(t, "This is me.", p.Content)
+
+	// YAML front matter and static media
+	p, err = app.getPost(resultFor(results, "posts/first.md").Path)
+	require.NoError(t, err)
+	assert.Equal(t, "/posts/2021/05/first", p.Path)
+	assert.Equal(t, "First", p.Title())
+	assert.Equal(t, "The first post", p.firstParameter("summary"))
+	assert.Equal(t, []string{"Go", "Hugo"}, p.Parameters["tags"])
+	assert.Equal(t, []string{"News"}, p.Parameters["categories"])
+	assert.ElementsMatch(t, []string{"/old/first", "/posts/first"}, p.Parameters["aliases"])
+	assert.Equal(t, toLocalSafe("2021-05-02T10:00:00Z"), p.Updated)
+	assert.NotContains(t, p.Content, "/images/logo.png")
+	assert.Contains(t, p.Content, "https://example.com/m/")
+
+	// TOML front matter, page bundle and figure shortcode
+	p, err = app.getPost(resultFor(results, "posts/bundle/index.md").Path)
+	require.NoError(t, err)
+	assert.Equal(t, "/posts/2021/06/bundle", p.Path)
+	assert.Equal(t, statusDraft, p.Status)
+	assert.NotContains(t, p.Content, "figure")
+	assert.Contains(t, p.Content, `"A photo"`)
+	assert.Contains(t, p.Content, "https://example.com/m/")
+
+	// JSON front matter in a section
+	p, err = app.getPost(resultFor(results, "notes/note.md").Path)
+	require.NoError(t, err)
+	assert.Equal(t, "notes", p.Section)
+	assert.Equal(t, "A note", p.Content)
+
+	files, err := app.mediaFiles()
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	t.Run("Import again", func(t *testing.T) {
+		results, err := app.importHugo(content, "", opts)
+		require.NoError(t, err)
+		for _, r := range results {
+			assert.Equal(t, importActionUnchanged, r.Action, r.File+": "+r.Reason)
+		}
+	})
+}
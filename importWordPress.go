@@ -0,0 +1,302 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type wxrRSS struct {
+	Channel struct {
+		Link        string     `xml:"link"`
+		BaseSiteURL string     `xml:"base_site_url"`
+		BaseBlogURL string     `xml:"base_blog_url"`
+		Items       []*wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	Encoded []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:"encoded"` // content:encoded and excerpt:encoded
+	ID            int    `xml:"post_id"`
+	DateGMT       string `xml:"post_date_gmt"`
+	Date          string `xml:"post_date"`
+	ModifiedGMT   string `xml:"post_modified_gmt"`
+	Name          string `xml:"post_name"`
+	Status        string `xml:"status"`
+	Type          string `xml:"post_type"`
+	Password      string `xml:"post_password"`
+	AttachmentURL string `xml:"attachment_url"`
+	Categories    []struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"category"`
+	Meta []struct {
+		Key   string `xml:"meta_key"`
+		Value string `xml:"meta_value"`
+	} `xml:"postmeta"`
+	Comments []*wxrComment `xml:"comment"`
+}
+
+type wxrComment struct {
+	ID       int    `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	URL      string `xml:"comment_author_url"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+}
+
+var (
+	wpBlockCommentRegex = regexp.MustCompile(`<!-- /?wp:[^>]*-->\n?`)
+	wpURLRegex          = regexp.MustCompile(`https?://[^\s"'<>()\[\]]+`)
+)
+
+// importWordPress imports posts, pages, attachments and comments from a WordPress WXR export file.
+func (a *goBlog) importWordPress(file string, opts *blogImportOptions) ([]*importResult, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rss wxrRSS
+	if err = xml.NewDecoder(f).Decode(&rss); err != nil {
+		return nil, fmt.Errorf("failed to parse WXR file: %w", err)
+	}
+	blog := cmp.Or(opts.blog, a.cfg.DefaultBlog)
+	bc, ok := a.cfg.Blogs[blog]
+	if !ok {
+		return nil, errors.New("blog doesn't exist")
+	}
+	section := cmp.Or(opts.section, bc.DefaultSection)
+	tagsTaxonomy := a.importTaxonomy(blog, opts.tagsTaxonomy)
+	categoriesTaxonomy := a.importTaxonomy(blog, opts.categoriesTaxonomy)
+	// Collect attachments
+	attachments := map[int]string{}
+	for _, item := range rss.Channel.Items {
+		if item.Type == "attachment" && item.AttachmentURL != "" {
+			attachments[item.ID] = item.AttachmentURL
+		}
+	}
+	attachmentURLs := lo.Values(attachments)
+	uploadPrefixes := lo.FilterMap(
+		[]string{rss.Channel.BaseSiteURL, rss.Channel.BaseBlogURL, rss.Channel.Link},
+		func(u string, _ int) (string, bool) {
+			return strings.TrimSuffix(u, "/") + "/wp-content/uploads/", u != ""
+		},
+	)
+	media := a.newImportMedia(opts.dryRun, func(ref string) (io.ReadCloser, error) {
+		resp, err := a.httpClient.Get(ref)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return resp.Body, nil
+	})
+	var results []*importResult
+	for _, item := range rss.Channel.Items {
+		if item.Type != "post" && item.Type != "page" {
+			continue
+		}
+		result := &importResult{File: cmp.Or(item.Link, fmt.Sprintf("%s %d", item.Type, item.ID))}
+		results = append(results, result)
+		p, err := a.wordPressPost(item, blog, section, tagsTaxonomy, categoriesTaxonomy)
+		if err != nil {
+			result.Action, result.Reason = importActionError, err.Error()
+			continue
+		}
+		if p == nil {
+			result.Action, result.Reason = importActionSkip, "status "+item.Status
+			continue
+		}
+		// Upload media files and rewrite links
+		refs := map[string]string{}
+		for _, u := range wpURLRegex.FindAllString(p.Content, -1) {
+			if lo.Contains(attachmentURLs, u) || lo.SomeBy(uploadPrefixes, func(prefix string) bool { return strings.HasPrefix(u, prefix) }) {
+				refs[u] = u
+			}
+		}
+		var mediaErr error
+		p.Content, mediaErr = media.rewrite(p.Content, refs)
+		if thumbnail, ok := attachments[item.featuredImage()]; ok {
+			if loc, err := media.location(thumbnail); err == nil {
+				p.Parameters[a.cfg.Micropub.PhotoParam] = []string{loc}
+			} else {
+				mediaErr = errors.Join(mediaErr, err)
+			}
+		}
+		result.Action, result.Reason = a.importPost(p, &opts.importOptions)
+		result.Path = p.Path
+		if result.Action == importActionError || result.Action == importActionConflict {
+			continue
+		}
+		// Import comments
+		count, err := a.importWordPressComments(item, p.Path, opts.dryRun)
+		notes := lo.Compact([]string{result.Reason})
+		if count > 0 {
+			notes = append(notes, fmt.Sprintf("%d comments", count))
+		}
+		for _, e := range []error{err, mediaErr} {
+			if e != nil {
+				notes = append(notes, e.Error())
+			}
+		}
+		result.Reason = strings.Join(notes, ", ")
+	}
+	return results, nil
+}
+
+func (item *wxrItem) encoded(namespacePart string) string {
+	for _, e := range item.Encoded {
+		if strings.Contains(e.XMLName.Space, namespacePart) {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func (item *wxrItem) featuredImage() int {
+	for _, m := range item.Meta {
+		if m.Key == "_thumbnail_id" {
+			var id int
+			if _, err := fmt.Sscan(m.Value, &id); err == nil {
+				return id
+			}
+		}
+	}
+	return 0
+}
+
+// wordPressPost maps a WordPress post or page to a post, returns nil if the item should be skipped.
+func (a *goBlog) wordPressPost(item *wxrItem, blog, section, tagsTaxonomy, categoriesTaxonomy string) (*post, error) {
+	p := &post{
+		Blog:       blog,
+		Content:    strings.TrimSpace(wpBlockCommentRegex.ReplaceAllString(item.encoded("/content/"), "")),
+		Visibility: visibilityPublic,
+		Parameters: map[string][]string{},
+	}
+	switch item.Status {
+	case "publish":
+		p.Status = statusPublished
+	case "future":
+		p.Status = statusScheduled
+	case "draft", "pending":
+		p.Status = statusDraft
+	case "private":
+		p.Status, p.Visibility = statusPublished, visibilityPrivate
+	default:
+		// Trash, auto drafts, ...
+		return nil, nil
+	}
+	if item.Password != "" && p.Visibility == visibilityPublic {
+		// Only the hash of the password is stored when saving the post
+		p.Visibility = visibilityProtected
+		p.Parameters[postPasswordParameter] = []string{item.Password}
+	}
+	var err error
+	if p.Published, err = wordPressDate(item.DateGMT, item.Date); err != nil {
+		return nil, err
+	}
+	if p.Updated, err = wordPressDate(item.ModifiedGMT, ""); err != nil {
+		return nil, err
+	}
+	if p.Updated == p.Published {
+		p.Updated = ""
+	}
+	if title := strings.TrimSpace(item.Title); title != "" {
+		p.Parameters["title"] = []string{title}
+	}
+	if excerpt := strings.TrimSpace(item.encoded("/excerpt/")); excerpt != "" {
+		p.Parameters["summary"] = []string{excerpt}
+	}
+	for _, c := range item.Categories {
+		switch {
+		case c.Domain == "post_tag" && tagsTaxonomy != "":
+			p.Parameters[tagsTaxonomy] = append(p.Parameters[tagsTaxonomy], c.Value)
+		case c.Domain == "category" && categoriesTaxonomy != "" && c.Nicename != "uncategorized":
+			p.Parameters[categoriesTaxonomy] = append(p.Parameters[categoriesTaxonomy], c.Value)
+		}
+	}
+	slug := cmp.Or(item.Name, fmt.Sprintf("%d", item.ID))
+	if item.Type == "page" {
+		// Pages don't have a section
+		p.Path = a.getRelativePath(blog, "/"+slug)
+	} else {
+		p.Section, p.Slug = section, slug
+	}
+	if alias := importAliasPath(item.Link, p.Path); alias != "" {
+		p.Parameters["aliases"] = []string{alias}
+	}
+	return p, nil
+}
+
+// wordPressDate converts a WordPress date (preferably GMT) to the local time format.
+func wordPressDate(gmt, local string) (string, error) {
+	const wpDateFormat = "2006-01-02 15:04:05"
+	if gmt != "" && !strings.HasPrefix(gmt, "0000") {
+		t, err := time.ParseInLocation(wpDateFormat, gmt, time.UTC)
+		if err != nil {
+			return "", err
+		}
+		return t.Local().Format(time.RFC3339), nil
+	}
+	if local != "" && !strings.HasPrefix(local, "0000") {
+		t, err := time.ParseInLocation(wpDateFormat, local, time.Local)
+		if err != nil {
+			return "", err
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	return "", nil
+}
+
+// importWordPressComments saves the approved comments of the item, already imported comments are skipped.
+func (a *goBlog) importWordPressComments(item *wxrItem, target string, dryRun bool) (count int, err error) {
+	for _, c := range item.Comments {
+		if c.Approved != "1" || (c.Type != "" && c.Type != "comment") {
+			// Skip spam, pingbacks and trackbacks
+			continue
+		}
+		content := cleanHTMLText(c.Content)
+		if content == "" {
+			continue
+		}
+		original := fmt.Sprintf("%s#comment-%d", item.Link, c.ID)
+		exists, _, err := a.db.commentIdByOriginal(original)
+		if err != nil {
+			return count, err
+		} else if exists {
+			continue
+		}
+		count++
+		if dryRun {
+			continue
+		}
+		if _, err := a.db.Exec(
+			"insert into comments (target, comment, name, website, original) values (@target, @comment, @name, @website, @original)",
+			sql.Named("target", target), sql.Named("comment", content), sql.Named("name", cmp.Or(cleanHTMLText(c.Author), "Anonymous")),
+			sql.Named("website", cleanHTMLText(c.URL)), sql.Named("original", original),
+		); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
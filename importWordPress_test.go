@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImportTestApp(t *testing.T) *goBlog {
+	t.Helper()
+	app := &goBlog{
+		cfg:        createDefaultTestConfig(t),
+		httpClient: newHttpClient(),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	app.cfg.Blogs = map[string]*configBlog{
+		"en": {
+			Path: "/",
+			Lang: "en",
+			Sections: map[string]*configSection{
+				"posts": {},
+				"notes": {},
+			},
+			DefaultSection: "posts",
+			Taxonomies: []*configTaxonomy{
+				{Name: "tags"},
+				{Name: "categories"},
+			},
+		},
+	}
+	app.cfg.DefaultBlog = "en"
+	require.NoError(t, app.initConfig(false))
+	app.mediaStorage = &localMediaStorage{path: t.TempDir()}
+	app.mediaStorageInit.Do(func() {})
+	return app
+}
+
+func Test_importWordPress(t *testing.T) {
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte("file " + r.URL.Path))
+	}))
+	defer ts.Close()
+
+	app := newImportTestApp(t)
+
+	// Point the export to the test server
+	data, err := os.ReadFile(filepath.Join("testdata", "wordpress.xml"))
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "wordpress.xml")
+	require.NoError(t, os.WriteFile(file, []byte(strings.ReplaceAll(string(data), "https://wp.example.com", ts.URL)), 0o644))
+
+	opts := &blogImportOptions{
+		importOptions:      importOptions{skipHooks: true},
+		tagsTaxonomy:       "tags",
+		categoriesTaxonomy: "categories",
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		dryOpts := *opts
+		dryOpts.dryRun = true
+		results, err := app.importWordPress(file, &dryOpts)
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+		assert.Equal(t, importActionCreate, results[0].Action)
+		assert.Equal(t, "/posts/2021/05/hello-world", results[0].Path)
+		assert.Contains(t, results[0].Reason, "1 comments")
+
+		count, err := app.db.countPosts(&postsRequestConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+		assert.Empty(t, requested)
+	})
+
+	results, err := app.importWordPress(file, opts)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	for _, r := range results[:3] {
+		assert.Equal(t, importActionCreate, r.Action, r.Reason)
+	}
+	assert.Equal(t, importActionSkip, results[3].Action)
+	assert.Equal(t, importActionCreate, results[4].Action, results[4].Reason)
+
+	// Post
+	p, err := app.getPost("/posts/2021/05/hello-world")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello World", p.Title())
+	assert.Equal(t, "The first post", p.firstParameter("summary"))
+	assert.Equal(t, []string{"First", "Hello"}, p.Parameters["tags"])
+	assert.Equal(t, []string{"News"}, p.Parameters["categories"])
+	assert.Equal(t, []string{"/2021/05/hello-world"}, p.Parameters["aliases"])
+	assert.Equal(t, statusPublished, p.Status)
+	assert.Equal(t, toLocalSafe("2021-05-01T10:00:00Z"), p.Published)
+	assert.Equal(t, toLocalSafe("2021-05-02T10:00:00Z"), p.Updated)
+	assert.NotContains(t, p.Content, "wp:paragraph")
+	assert.NotContains(t, p.Content, ts.URL)
+	assert.Contains(t, p.Content, "https://example.com/m/")
+
+	// Media
+	assert.ElementsMatch(t, []string{"/wp-content/uploads/2021/05/photo.png", "/wp-content/uploads/2021/05/cover.jpg"}, requested)
+	files, err := app.mediaFiles()
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	if assert.Len(t, p.Parameters["images"], 1) {
+		assert.True(t, strings.HasSuffix(p.Parameters["images"][0], ".jpg"))
+	}
+
+	// Comments
+	comments, err := app.db.getComments(&commentsRequestConfig{})
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "/posts/2021/05/hello-world", comments[0].Target)
+	assert.Equal(t, "Alice", comments[0].Name)
+	assert.Equal(t, "Nice post!", comments[0].Comment)
+
+	// Page
+	p, err = app.getPost("/about")
+	require.NoError(t, err)
+	assert.Empty(t, p.Section)
+	assert.Equal(t, "This is me.", p.Content)
+	assert.Empty(t, p.Parameters["aliases"])
+
+	// Draft without slug
+	draft, ok := lo.Find(results, func(r *importResult) bool { return strings.HasSuffix(r.File, "?p=6") })
+	require.True(t, ok)
+	p, err = app.getPost(draft.Path)
+	require.NoError(t, err)
+	assert.Equal(t, statusDraft, p.Status)
+	assert.True(t, strings.HasSuffix(p.Path, "/6"))
+
+	// Password-protected post, only the password hash is stored
+	p, err = app.getPost("/posts/2021/07/secret")
+	require.NoError(t, err)
+	assert.Equal(t, visibilityProtected, p.Visibility)
+	assert.Empty(t, p.firstParameter("password"))
+	assert.True(t, checkPasswordHash("friends", app.db.getPostPasswordHash(p.Path)))
+
+	// Old URL redirects to the new path
+	app.d = app.buildRouter()
+	rec := httptest.NewRecorder()
+	app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/2021/05/hello-world/", nil))
+	for rec.Code == http.StatusMovedPermanently {
+		// Trailing slash redirect
+		loc := rec.Header().Get("Location")
+		rec = httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, loc, nil))
+	}
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/posts/2021/05/hello-world", rec.Header().Get("Location"))
+
+	t.Run("Import again", func(t *testing.T) {
+		results, err := app.importWordPress(file, opts)
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+		assert.Equal(t, importActionUnchanged, results[0].Action, results[0].Reason)
+		assert.Equal(t, importActionUnchanged, results[1].Action, results[1].Reason)
+		assert.Equal(t, importActionUnchanged, results[4].Action, results[4].Reason)
+
+		comments, err := app.db.getComments(&commentsRequestConfig{})
+		require.NoError(t, err)
+		assert.Len(t, comments, 1)
+	})
+}
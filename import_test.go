@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_import(t *testing.T) {
+	postHook := 0
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Blogs = map[string]*configBlog{
+		"en": {
+			Sections: map[string]*configSection{
+				"test": {},
+			},
+		},
+	}
+	app.cfg.DefaultBlog = "en"
+	app.pPostHooks = append(app.pPostHooks, func(p *post) {
+		postHook++
+	})
+	require.NoError(t, app.initConfig(false))
+
+	published := toLocalSafe(time.Now().Add(-24 * time.Hour).String())
+	require.NoError(t, app.db.savePost(&post{
+		Path:       "/test/abc",
+		Content:    "ABC",
+		Published:  published,
+		Blog:       "en",
+		Section:    "test",
+		Status:     statusPublished,
+		Visibility: visibilityPublic,
+		Parameters: map[string][]string{
+			"title": {"Title"},
+		},
+	}, &postCreationOptions{new: true}))
+
+	dir := filepath.Join(t.TempDir(), "export")
+	require.NoError(t, app.exportMarkdownFiles(dir))
+
+	// Edit exported file and add new files
+	exportFile := filepath.Join(dir, "test", "abc.md")
+	content, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(exportFile, []byte(strings.Replace(string(content), "title: Title", "title: Edited", 1)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "new.md"), []byte("---\nsection: test\npublished: "+published+"\ntitle: New\n---\nNew post"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "duplicate.md"), []byte("---\npath: /test/new\nsection: test\n---\nDuplicate"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "invalid.md"), []byte("---\nsection: missing\n---\nInvalid"), 0o644))
+
+	resultFor := func(results []*importResult, file string) *importResult {
+		r, _ := lo.Find(results, func(r *importResult) bool { return r.File == file })
+		return r
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		results, err := app.importMarkdownFiles(dir, &importOptions{dryRun: true})
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		assert.Equal(t, importActionUpdate, resultFor(results, filepath.Join("test", "abc.md")).Action)
+		assert.Equal(t, importActionConflict, resultFor(results, filepath.Join("test", "new.md")).Action)
+		assert.Equal(t, importActionCreate, resultFor(results, filepath.Join("test", "duplicate.md")).Action)
+		assert.Equal(t, "/test/new", resultFor(results, filepath.Join("test", "duplicate.md")).Path)
+		// Invalid sections are only detected when saving
+		assert.Equal(t, importActionCreate, resultFor(results, filepath.Join("test", "invalid.md")).Action)
+
+		// Nothing changed
+		p, err := app.getPost("/test/abc")
+		require.NoError(t, err)
+		assert.Equal(t, "Title", p.Title())
+		_, err = app.getPost("/test/new")
+		assert.ErrorIs(t, err, errPostNotFound)
+	})
+
+	t.Run("Import without hooks", func(t *testing.T) {
+		results, err := app.importMarkdownFiles(dir, &importOptions{skipHooks: true})
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		assert.Equal(t, importActionUpdate, resultFor(results, filepath.Join("test", "abc.md")).Action)
+		assert.Equal(t, importActionError, resultFor(results, filepath.Join("test", "invalid.md")).Action)
+
+		p, err := app.getPost("/test/abc")
+		require.NoError(t, err)
+		assert.Equal(t, "Edited", p.Title())
+		assert.Equal(t, published, p.Published)
+
+		p, err = app.getPost("/test/new")
+		require.NoError(t, err)
+		assert.Equal(t, "Duplicate", p.Content)
+
+		app.postHooksWg.Wait()
+		assert.Equal(t, 0, postHook)
+
+		// Importing again doesn't change anything
+		results, err = app.importMarkdownFiles(dir, &importOptions{skipHooks: true})
+		require.NoError(t, err)
+		assert.Equal(t, importActionUnchanged, resultFor(results, filepath.Join("test", "abc.md")).Action)
+	})
+
+	t.Run("Conflict with newer post", func(t *testing.T) {
+		p, err := app.getPost("/test/abc")
+		require.NoError(t, err)
+		p.Content = "Changed after export"
+		require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+
+		results, err := app.importMarkdownFiles(dir, &importOptions{skipHooks: true})
+		require.NoError(t, err)
+		assert.Equal(t, importActionConflict, resultFor(results, filepath.Join("test", "abc.md")).Action)
+
+		p, err = app.getPost("/test/abc")
+		require.NoError(t, err)
+		assert.Equal(t, "Changed after export", p.Content)
+	})
+
+	t.Run("Import with hooks", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "test", "hooks.md"), []byte("---\nsection: test\n---\nWith hooks"), 0o644))
+
+		results, err := app.importMarkdownFiles(dir, &importOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, importActionCreate, resultFor(results, filepath.Join("test", "hooks.md")).Action)
+
+		app.postHooksWg.Wait()
+		assert.Equal(t, 1, postHook)
+	})
+}
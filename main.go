@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"time"
 
 	"github.com/pquerna/otp/totp"
 	"github.com/spf13/cobra"
@@ -136,6 +137,250 @@ Example:
 		},
 	})
 
+	// Static export command
+	staticExportCmd := &cobra.Command{
+		Use:   "static-export [directory]",
+		Short: "Export a static copy of the blog",
+		Long: `Render every public page to disk to publish a read-only mirror.
+
+This command crawls the blog starting from the sitemap and writes all public
+pages (posts, section and taxonomy indexes with pagination, ...), feeds,
+sitemaps, assets and media files to the directory. HTML pages are saved as
+index.html files, redirects as pages with a meta refresh. Dynamic endpoints
+like search, comments, the editor or Micropub are skipped.
+
+Use --base-url to replace the public address in absolute links, for example
+with the address of the mirror.
+
+If no directory is specified, files are exported to the "static-export" directory.
+
+Example:
+  ./GoBlog static-export ./public
+  ./GoBlog static-export ./public --base-url https://mirror.example.com`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			if err := app.initPlugins(); err != nil {
+				app.logErrAndQuit("Failed to init plugins", "err", err)
+				return
+			}
+			if err := app.initStaticExport(); err != nil {
+				app.logErrAndQuit("Failed to init static export", "err", err)
+				return
+			}
+			var dir string
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			opts := &staticExportOptions{}
+			opts.baseURL, _ = cmd.Flags().GetString("base-url")
+			results, err := app.staticExport(dir, opts)
+			if err != nil {
+				app.logErrAndQuit("Failed to export static site", "err", err)
+				return
+			}
+			written := 0
+			for _, r := range results {
+				if r.File == "" {
+					fmt.Printf("%d\t%s\n", r.Status, r.Path)
+				} else {
+					written++
+				}
+			}
+			fmt.Printf("Exported %d files\n", written)
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	staticExportCmd.Flags().String("base-url", "", "Replace the public address in absolute links")
+	rootCmd.AddCommand(staticExportCmd)
+
+	// Markdown import command
+	importCmd := &cobra.Command{
+		Use:   "import [directory]",
+		Short: "Import markdown files",
+		Long: `Import Markdown files with front matter as posts.
+
+This command reads all Markdown files in the directory (for example files written
+by the export command) and creates or updates the posts. The front matter is
+parsed the same way as for Micropub posts. If there's no path in the front
+matter, the file path relative to the directory is used.
+
+Posts in the database that were updated after the imported version are reported
+as conflicts and not overwritten. Use --dry-run to only report what would happen.
+
+By default, hooks like sending webmentions or ActivityPub activities are triggered
+for imported posts. Use --skip-hooks to import without these side effects.
+
+If no directory is specified, files are imported from the "export" directory.
+
+Example:
+  ./GoBlog import ./backup --dry-run
+  ./GoBlog import ./backup --skip-hooks`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			var dir string
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			opts := &importOptions{}
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.skipHooks, _ = cmd.Flags().GetBool("skip-hooks")
+			app.runImport(opts, func() ([]*importResult, error) {
+				return app.importMarkdownFiles(dir, opts)
+			})
+		},
+	}
+	addImportFlags(importCmd)
+	rootCmd.AddCommand(importCmd)
+
+	// WordPress import command
+	importWordPressCmd := &cobra.Command{
+		Use:   "import-wordpress <file>",
+		Short: "Import a WordPress export",
+		Long: `Import posts, pages and comments from a WordPress export file (WXR).
+
+Posts are imported to the section given with --section (or the default section
+of the blog), pages are imported without section. Tags and categories are saved
+to the taxonomies given with --tags and --categories, if they are configured
+for the blog. Approved comments are added to the imported posts.
+
+Media files from the WordPress uploads are downloaded, saved to the configured
+media storage and links in the content get rewritten. The old URLs are added as
+aliases, so they redirect to the new paths.
+
+Importing the same file again updates the posts and skips existing comments.
+The flags --dry-run and --skip-hooks work like for the import command.
+
+Example:
+  ./GoBlog import-wordpress wordpress.xml --blog default --dry-run
+  ./GoBlog import-wordpress wordpress.xml --section posts --skip-hooks`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			opts := blogImportOptionsFromFlags(cmd)
+			app.runImport(&opts.importOptions, func() ([]*importResult, error) {
+				return app.importWordPress(args[0], opts)
+			})
+		},
+	}
+	addImportFlags(importWordPressCmd)
+	addBlogImportFlags(importWordPressCmd)
+	rootCmd.AddCommand(importWordPressCmd)
+
+	// Hugo import command
+	importHugoCmd := &cobra.Command{
+		Use:   "import-hugo <content directory>",
+		Short: "Import a Hugo site",
+		Long: `Import posts and pages from the content directory of a Hugo site.
+
+Files in subdirectories are imported as posts, the first directory is used as
+section if it's configured for the blog, otherwise the section given with
+--section (or the default section) is used. Files in the content directory
+itself are imported as pages without section. Page bundles are supported.
+
+YAML, TOML and JSON front matter is supported. Tags and categories are saved
+to the taxonomies given with --tags and --categories, other front matter keys
+are only kept if they match a taxonomy of the blog. Figure shortcodes are
+converted to Markdown images.
+
+Referenced media files are saved to the configured media storage and links in
+the content get rewritten. Absolute references are searched in the static
+directory (--static, defaults to the "static" directory next to the content
+directory). The old URLs and aliases are kept, so they redirect to the new paths.
+
+The flags --dry-run and --skip-hooks work like for the import command.
+
+Example:
+  ./GoBlog import-hugo ./site/content --dry-run
+  ./GoBlog import-hugo ./site/content --static ./site/static --tags tags`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			opts := blogImportOptionsFromFlags(cmd)
+			staticDir, _ := cmd.Flags().GetString("static")
+			app.runImport(&opts.importOptions, func() ([]*importResult, error) {
+				return app.importHugo(args[0], staticDir, opts)
+			})
+		},
+	}
+	addImportFlags(importHugoCmd)
+	addBlogImportFlags(importHugoCmd)
+	importHugoCmd.Flags().String("static", "", "Static directory of the Hugo site")
+	rootCmd.AddCommand(importHugoCmd)
+
+	// Backup command
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "backup [file]",
+		Short: "Create a backup archive",
+		Long: `Create a backup archive of the whole blog.
+
+The archive contains a consistent snapshot of the database (posts, comments,
+followers, settings, ...), all media files, the profile image and the
+ActivityPub private key. It can be restored using the restore command.
+
+If no file is specified, the archive is written to the current directory.
+
+Example:
+  ./GoBlog backup ./goblog-backup.tar.gz`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			file := backupFileName(time.Now())
+			if len(args) > 0 {
+				file = args[0]
+			}
+			pr, pw := io.Pipe()
+			go func() {
+				_ = pw.CloseWithError(app.writeBackup(pw))
+			}()
+			if err := utils.SaveToFile(pr, file); err != nil {
+				app.logErrAndQuit("Failed to create backup", "err", err)
+				return
+			}
+			fmt.Println("Backup written to", file)
+			app.shutdown.ShutdownAndWait()
+		},
+	})
+
+	// Restore command
+	restoreCmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore a backup archive",
+		Long: `Restore a backup archive created by the backup command.
+
+The database schema of the backup is checked first. Backups created by a newer
+version of GoBlog can't be restored. Older backups are migrated to the current
+schema.
+
+An existing database is only replaced when using --force. Stop GoBlog before
+restoring a backup.
+
+Example:
+  ./GoBlog restore ./goblog-backup.tar.gz
+  ./GoBlog restore ./goblog-backup.tar.gz --force`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := &goBlog{
+				httpClient: newHttpClient(),
+			}
+			configfile, _ := cmd.Flags().GetString("config")
+			if err := app.loadConfigFile(configfile); err != nil {
+				app.logErrAndQuit("Failed to load config file", "err", err)
+				return
+			}
+			force, _ := cmd.Flags().GetBool("force")
+			if err := app.restoreBackup(args[0], force); err != nil {
+				app.logErrAndQuit("Failed to restore backup", "err", err)
+				return
+			}
+			fmt.Println("Backup restored")
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	restoreCmd.Flags().Bool("force", false, "Replace an existing database")
+	rootCmd.AddCommand(restoreCmd)
+
 	activityPubCmd := &cobra.Command{
 		Use:   "activitypub",
 		Short: "ActivityPub related tasks",
@@ -416,6 +661,113 @@ Example:
 	})
 	rootCmd.AddCommand(activityPubCmd)
 
+	mediaCmd := &cobra.Command{
+		Use:   "media",
+		Short: "Media file related tasks",
+		Long: `Media file related tasks for managing the uploaded files.
+
+These commands work with every configured media storage.`,
+	}
+
+	mediaGCCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "List and optionally delete unused media files",
+		Long: `List the media files that no post, post revision or editor draft references.
+
+Responsive variants and originals of used files count as used. Files uploaded
+during the last 24 hours are never listed, because they are probably about
+to be used in a new post.
+
+Use --delete to delete the listed files.
+
+Example:
+  ./GoBlog media gc
+  ./GoBlog media gc --delete`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			if !app.mediaStorageEnabled() {
+				app.logErrAndQuit("No media storage configured")
+				return
+			}
+			files, err := app.unusedMediaFiles()
+			if err != nil {
+				app.logErrAndQuit("Failed to find unused media files", "err", err)
+				return
+			}
+			var size int64
+			for _, f := range files {
+				fmt.Printf("%s (%s)\n", f.Name, mBytesString(f.Size))
+				size += f.Size
+			}
+			fmt.Printf("%d unused files, %s\n", len(files), mBytesString(size))
+			if doDelete, _ := cmd.Flags().GetBool("delete"); doDelete && len(files) > 0 {
+				names := make([]string, 0, len(files))
+				for _, f := range files {
+					names = append(names, f.Name)
+				}
+				deleted, err := app.deleteUnusedMediaFiles(names)
+				fmt.Printf("Deleted %d files\n", len(deleted))
+				if err != nil {
+					app.logErrAndQuit("Failed to delete unused media files", "err", err)
+					return
+				}
+			}
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	mediaGCCmd.Flags().Bool("delete", false, "Delete the unused files")
+	mediaCmd.AddCommand(mediaGCCmd)
+
+	mediaDedupCmd := &cobra.Command{
+		Use:   "dedup",
+		Short: "Find duplicate media files and use a single URL in posts",
+		Long: `Find media files with identical content and replace the references in posts
+with a single canonical URL.
+
+The file named by its content hash or otherwise the oldest file is kept as the
+canonical one. Files uploaded before the media library existed are hashed and
+added to it. Posts are updated without changing the updated date and without
+sending webmentions or ActivityPub activities.
+
+The duplicates aren't deleted, use "media gc" to delete them afterwards.
+Use --dry-run to only report the duplicates and the affected posts.
+
+Example:
+  ./GoBlog media dedup --dry-run
+  ./GoBlog media dedup`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			if !app.mediaStorageEnabled() {
+				app.logErrAndQuit("No media storage configured")
+				return
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			duplicates, paths, err := app.deduplicateMediaFiles(dryRun)
+			if err != nil {
+				app.logErrAndQuit("Failed to deduplicate media files", "err", err)
+				return
+			}
+			for _, d := range duplicates {
+				for _, f := range d.duplicates {
+					fmt.Printf("%s\t%s\n", f.Name, d.canonical.Name)
+				}
+			}
+			action := "updated"
+			if dryRun {
+				action = "would update"
+			}
+			for _, p := range paths {
+				fmt.Printf("%s\t%s\n", action, p)
+			}
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	mediaDedupCmd.Flags().Bool("dry-run", false, "Only report the duplicates and the affected posts")
+	mediaCmd.AddCommand(mediaDedupCmd)
+	rootCmd.AddCommand(mediaCmd)
+
 	setupCmd := &cobra.Command{
 		Use:   "setup",
 		Short: "Set up user credentials (username, password, and optionally TOTP)",
@@ -501,7 +853,8 @@ Options:
 
 func initializeApp(cmd *cobra.Command) *goBlog {
 	app := &goBlog{
-		httpClient: newHttpClient(),
+		httpClient:       newHttpClient(),
+		publicHttpClient: newPublicHttpClient(),
 	}
 	configfile, _ := cmd.Flags().GetString("config")
 	if err := app.loadConfigFile(configfile); err != nil {
@@ -530,7 +883,7 @@ func initializeComponents(app *goBlog) {
 	for _, f := range []func(){
 		app.initWebmention, app.initTelegram, app.initAtproto,
 		app.initTTS, app.initSessions, app.startPostsScheduler, app.initPostsDeleter,
-		app.initIndexNow,
+		app.initIndexNow, app.initWebSub, app.initRelatedPosts, app.initPostingQueue,
 	} {
 		f()
 	}
@@ -538,6 +891,54 @@ func initializeComponents(app *goBlog) {
 	app.info("Initialized components")
 }
 
+func addImportFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Only report what would be imported")
+	cmd.Flags().Bool("skip-hooks", false, "Don't trigger hooks like webmention or ActivityPub sending")
+}
+
+func addBlogImportFlags(cmd *cobra.Command) {
+	cmd.Flags().String("blog", "", "Blog to import to (defaults to the default blog)")
+	cmd.Flags().String("section", "", "Section for imported posts (defaults to the default section)")
+	cmd.Flags().String("tags", "tags", "Taxonomy for tags")
+	cmd.Flags().String("categories", "categories", "Taxonomy for categories")
+}
+
+func blogImportOptionsFromFlags(cmd *cobra.Command) *blogImportOptions {
+	opts := &blogImportOptions{}
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.skipHooks, _ = cmd.Flags().GetBool("skip-hooks")
+	opts.blog, _ = cmd.Flags().GetString("blog")
+	opts.section, _ = cmd.Flags().GetString("section")
+	opts.tagsTaxonomy, _ = cmd.Flags().GetString("tags")
+	opts.categoriesTaxonomy, _ = cmd.Flags().GetString("categories")
+	return opts
+}
+
+// runImport initializes the hooks if needed, runs the import, prints the results and waits for the hooks.
+func (a *goBlog) runImport(opts *importOptions, f func() ([]*importResult, error)) {
+	if !opts.dryRun && !opts.skipHooks {
+		if err := a.initPlugins(); err != nil {
+			a.logErrAndQuit("Failed to init plugins", "err", err)
+			return
+		}
+		if err := a.initImportHooks(); err != nil {
+			a.logErrAndQuit("Failed to init hooks", "err", err)
+			return
+		}
+	}
+	results, err := f()
+	if err != nil {
+		a.logErrAndQuit("Failed to import", "err", err)
+		return
+	}
+	for _, r := range results {
+		fmt.Printf("%s\t%s\t%s\t%s\n", r.Action, r.File, r.Path, r.Reason)
+	}
+	// Wait for hooks to finish
+	a.postHooksWg.Wait()
+	a.shutdown.ShutdownAndWait()
+}
+
 func (a *goBlog) logErrAndQuit(msg string, args ...any) {
 	a.error(msg, args...)
 	a.shutdown.ShutdownAndWait()
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	marktag "git.jlel.se/jlelse/goldmark-mark"
+	"github.com/samber/lo"
 	"github.com/yuin/goldmark"
 	emoji "github.com/yuin/goldmark-emoji"
 	"github.com/yuin/goldmark/ast"
@@ -45,10 +46,12 @@ func (a *goBlog) initMarkdown() {
 			publicAddress = srv.PublicAddress
 		}
 		a.md = goldmark.New(append(defaultGoldmarkOptions, goldmark.WithExtensions(&customExtension{
+			app:           a,
 			absoluteLinks: false,
 			publicAddress: publicAddress,
 		}))...)
 		a.absoluteMd = goldmark.New(append(defaultGoldmarkOptions, goldmark.WithExtensions(&customExtension{
+			app:           a,
 			absoluteLinks: true,
 			publicAddress: publicAddress,
 		}))...)
@@ -123,6 +126,7 @@ func (a *goBlog) renderMdTitle(s string) string {
 
 // Links
 type customExtension struct {
+	app           *goBlog
 	publicAddress string
 	absoluteLinks bool
 }
@@ -130,6 +134,7 @@ type customExtension struct {
 func (l *customExtension) Extend(m goldmark.Markdown) {
 	m.Renderer().AddOptions(renderer.WithNodeRenderers(
 		util.Prioritized(&customRenderer{
+			app:           l.app,
 			absoluteLinks: l.absoluteLinks,
 			publicAddress: l.publicAddress,
 		}, 500),
@@ -137,6 +142,7 @@ func (l *customExtension) Extend(m goldmark.Markdown) {
 }
 
 type customRenderer struct {
+	app           *goBlog
 	publicAddress string
 	absoluteLinks bool
 }
@@ -196,11 +202,60 @@ func (c *customRenderer) renderImage(w util.BufWriter, source []byte, node ast.N
 	if len(n.Title) > 0 {
 		imgEls = append(imgEls, "title", string(n.Title))
 	}
+	// Reserve the space and show a placeholder while the image is loading
+	if metadata := c.mediaMetadata(string(n.Destination)); metadata != nil && metadata.width > 0 {
+		imgEls = append(imgEls, "width", metadata.width, "height", metadata.height)
+		if metadata.placeholder != "" {
+			// The placeholder gets shown by a script, inline styles aren't allowed
+			imgEls = append(imgEls, "data-placeholder", metadata.placeholder)
+		}
+	}
+	// Use responsive variants if available, the original stays the fallback
+	variants, fallbackFormat := c.mediaVariants(string(n.Destination))
+	if len(variants) > 0 {
+		hb.WriteElementOpen("picture")
+		for _, format := range mediaVariantSourceFormats {
+			if srcset := mediaVariantsSrcset(variants, format); srcset != "" {
+				hb.WriteElementOpen("source", "type", format, "srcset", srcset, "sizes", responsiveImageSizes)
+			}
+		}
+		if srcset := mediaVariantsSrcset(variants, fallbackFormat); srcset != "" {
+			imgEls = append(imgEls, "srcset", srcset, "sizes", responsiveImageSizes)
+		}
+	}
 	hb.WriteElementOpen("img", imgEls...)
+	if len(variants) > 0 {
+		hb.WriteElementClose("picture")
+	}
 	hb.WriteElementClose("a")
 	return ast.WalkSkipChildren, nil
 }
 
+// mediaVariants returns the responsive variants of an image and the format of the original
+func (c *customRenderer) mediaVariants(original string) ([]*mediaVariant, string) {
+	if c.app == nil || c.app.db == nil {
+		return nil, ""
+	}
+	variants, err := c.app.db.getMediaVariants(original)
+	if err != nil {
+		return nil, ""
+	}
+	fallback, ok := lo.Find(variants, func(v *mediaVariant) bool { return v.location == original })
+	if !ok {
+		return nil, ""
+	}
+	return variants, fallback.format
+}
+
+// mediaMetadata returns the saved metadata of an image or nil
+func (c *customRenderer) mediaMetadata(location string) *mediaMetadata {
+	if c.app == nil || c.app.db == nil {
+		return nil
+	}
+	m, _ := c.app.db.getMediaMetadata(location)
+	return m
+}
+
 func (r *customRenderer) extractTextFromChildren(node ast.Node, source []byte) string {
 	if node == nil {
 		return ""
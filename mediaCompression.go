@@ -5,9 +5,9 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
-	"image/png"
 	"io"
 	"net/http"
+	"path"
 
 	"github.com/carlmjohnson/requests"
 	"github.com/kovidgoyal/imaging"
@@ -31,6 +31,12 @@ func (a *goBlog) compressMediaFile(url string) (location string, err error) {
 			break
 		}
 	}
+	// Remember the original of the compressed file
+	if location != "" && err == nil {
+		if libErr := a.db.setMediaLibraryOriginal(path.Base(location), path.Base(url)); libErr != nil {
+			a.error("Failed to save original of compressed file", "location", location, "err", libErr)
+		}
+	}
 	// Return result
 	return location, err
 }
@@ -41,12 +47,19 @@ func (a *goBlog) initMediaCompressors() {
 	}
 	config := a.cfg.Micropub.MediaStorage
 	if config.LocalCompressionEnabled {
-		a.compressors = append(a.compressors, &localMediaCompressor{a: a})
+		widths := config.ResponsiveImageWidths
+		if len(widths) == 0 {
+			widths = defaultResponsiveImageWidths
+		}
+		a.compressors = append(a.compressors, &localMediaCompressor{a: a, widths: widths, encoders: modernMediaVariantEncoders()})
 	}
 }
 
 type localMediaCompressor struct {
 	a *goBlog
+	// Widths and additional formats of the responsive image variants
+	widths   []int
+	encoders []*mediaVariantEncoder
 }
 
 func (lc *localMediaCompressor) compress(url string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
@@ -68,20 +81,20 @@ func (lc *localMediaCompressor) compress(url string, upload mediaStorageSaveFunc
 	}
 	// Resize image
 	resizedImage := imaging.Fit(img, defaultCompressionWidth, defaultCompressionHeight, imaging.Lanczos)
-	// Encode image
-	pr, pw = io.Pipe()
-	go func() {
-		switch fileExtension {
-		case "png":
-			_ = pw.CloseWithError(imaging.Encode(pw, resizedImage, imaging.PNG, imaging.PNGCompressionLevel(png.BestCompression)))
-		default:
-			_ = pw.CloseWithError(imaging.Encode(pw, resizedImage, imaging.JPEG, imaging.JPEGQuality(75)))
-		}
-	}()
-	// Upload compressed file
-	res, err := uploadCompressedFile(fileExtension, pr, upload)
-	_ = pr.CloseWithError(err)
-	return res, err
+	// Encode and upload compressed file
+	fallback := fallbackMediaVariantEncoder(fileExtension)
+	res, err := fallback.upload(resizedImage, upload)
+	if err != nil {
+		return "", err
+	}
+	if err := lc.a.db.saveMediaDimensions(res, resizedImage); err != nil {
+		lc.a.error("Failed to save image dimensions", "location", res, "err", err)
+	}
+	// Create responsive variants, failing doesn't affect the compressed file
+	if err := lc.createVariants(res, resizedImage, fallback, upload); err != nil {
+		lc.a.error("Failed to create responsive image variants", "location", res, "err", err)
+	}
+	return res, nil
 }
 
 func uploadCompressedFile(fileExtension string, r io.Reader, upload mediaStorageSaveFunc) (string, error) {
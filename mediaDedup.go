@@ -0,0 +1,261 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// existingMediaLocation returns the location of an already uploaded file with the same content hash
+// or of the file compressed from it. It returns an empty string if there is no such file.
+func (a *goBlog) existingMediaLocation(hash string) (string, error) {
+	if a.db == nil {
+		return "", nil
+	}
+	f, err := a.db.getMediaLibraryFileByHash(hash)
+	if err != nil || f == nil {
+		return "", err
+	}
+	compressed, err := a.db.getMediaLibraryCompressed(f.name)
+	if err != nil {
+		return "", err
+	}
+	if compressed != nil {
+		f = compressed
+	}
+	return a.getFullAddress(a.mediaFileLocation(f.name)), nil
+}
+
+// mediaFileHash returns the SHA-256 hash of the file content
+func (a *goBlog) mediaFileHash(f *mediaFile) (string, error) {
+	hash := sha256.New()
+	if ls, ok := a.mediaStorage.(*localMediaStorage); ok {
+		// Read local files directly, the server might not be running
+		file, err := os.Open(filepath.Join(ls.path, f.Name))
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		if _, err = io.Copy(hash, file); err != nil {
+			return "", err
+		}
+	} else if err := requests.URL(a.getFullAddress(f.Location)).Client(a.httpClient).ToWriter(hash).Fetch(context.Background()); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// mediaDuplicate is a group of media files with identical content
+type mediaDuplicate struct {
+	canonical  *mediaFile
+	duplicates []*mediaFile
+}
+
+// mediaDuplicates finds the media files with identical content.
+// Files without a content hash in the media library are hashed and, unless it's a dry run, added to the library.
+func (a *goBlog) mediaDuplicates(dryRun bool) ([]*mediaDuplicate, error) {
+	files, err := a.mediaFiles()
+	if err != nil {
+		return nil, err
+	}
+	library, err := a.db.getMediaLibraryFiles()
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string][]*mediaFile{}
+	for _, f := range files {
+		var hash string
+		if lf, ok := library[f.Name]; ok {
+			hash = lf.hash
+		}
+		if hash == "" {
+			if hash, err = a.mediaFileHash(f); err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", f.Name, err)
+			}
+			// Remember the hash, the file was probably uploaded before the media library existed
+			if !dryRun {
+				if err = a.db.saveMediaLibraryFile(&mediaLibraryFile{
+					name:     f.Name,
+					location: a.getFullAddress(f.Location),
+					mimeType: mime.TypeByExtension(path.Ext(f.Name)),
+					hash:     hash,
+					uploaded: f.Time.UTC().Format(time.RFC3339),
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		groups[hash] = append(groups[hash], f)
+	}
+	var duplicates []*mediaDuplicate
+	for hash, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		// Prefer the file named by the hash, then the oldest one
+		slices.SortFunc(group, func(x, y *mediaFile) int {
+			if xh, yh := strings.HasPrefix(x.Name, hash), strings.HasPrefix(y.Name, hash); xh != yh {
+				if xh {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Or(x.Time.Compare(y.Time), strings.Compare(x.Name, y.Name))
+		})
+		duplicates = append(duplicates, &mediaDuplicate{canonical: group[0], duplicates: group[1:]})
+	}
+	slices.SortFunc(duplicates, func(x, y *mediaDuplicate) int { return strings.Compare(x.canonical.Name, y.canonical.Name) })
+	return duplicates, nil
+}
+
+// deduplicateMediaFiles replaces the references to duplicate media files in posts with the canonical location
+// and returns the duplicates and the paths of the changed posts. The duplicate files aren't deleted,
+// they are unused afterwards and can be removed using the unused files cleanup.
+func (a *goBlog) deduplicateMediaFiles(dryRun bool) ([]*mediaDuplicate, []string, error) {
+	duplicates, err := a.mediaDuplicates(dryRun)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Collect the replacements, absolute and relative locations
+	replacements := map[string]string{}
+	for _, d := range duplicates {
+		for _, f := range d.duplicates {
+			replacements[a.getFullAddress(f.Location)] = a.getFullAddress(d.canonical.Location)
+			if f.Location != a.getFullAddress(f.Location) {
+				replacements[f.Location] = d.canonical.Location
+			}
+		}
+		if !dryRun {
+			if err = a.mergeMediaLibraryText(d); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	// Find the posts that might use the duplicates
+	var candidates []string
+	for old := range replacements {
+		rows, err := a.db.Query(
+			"select path from posts where instr(content, @old) > 0 union select path from post_parameters where instr(value, @old) > 0",
+			sql.Named("old", old),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		for rows.Next() {
+			var p string
+			if err = rows.Scan(&p); err != nil {
+				_ = rows.Close()
+				return nil, nil, err
+			}
+			if !slices.Contains(candidates, p) {
+				candidates = append(candidates, p)
+			}
+		}
+		_ = rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+	slices.Sort(candidates)
+	// Replace longer locations first, so they aren't replaced partially
+	sortedOld := slices.SortedFunc(maps.Keys(replacements), func(a, b string) int { return len(b) - len(a) })
+	replace := func(s string) string {
+		for _, old := range sortedOld {
+			s = replaceMediaLocation(s, old, replacements[old])
+		}
+		return s
+	}
+	var paths []string
+	for _, postPath := range candidates {
+		p, err := a.getPost(postPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		before := p.contentWithParams()
+		p.Content = replace(p.Content)
+		for _, values := range p.Parameters {
+			for i, value := range values {
+				values[i] = replace(value)
+			}
+		}
+		if p.contentWithParams() == before {
+			// The location is only part of another URL
+			continue
+		}
+		paths = append(paths, p.Path)
+		if dryRun {
+			continue
+		}
+		// Only the locations change, so don't send updates
+		if err = a.savePostWithoutHooks(p, &postCreationOptions{
+			oldPath: p.Path, oldStatus: p.Status, oldVisibility: p.Visibility, noUpdated: true,
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return duplicates, paths, nil
+}
+
+// mergeMediaLibraryText keeps the alt text and caption of a duplicate if the canonical file has none
+func (a *goBlog) mergeMediaLibraryText(d *mediaDuplicate) error {
+	canonical, err := a.db.getMediaLibraryFile(d.canonical.Name)
+	if err != nil || canonical == nil {
+		return err
+	}
+	alt, caption := canonical.alt, canonical.caption
+	for _, f := range d.duplicates {
+		lf, err := a.db.getMediaLibraryFile(f.Name)
+		if err != nil {
+			return err
+		}
+		if lf != nil {
+			alt, caption = cmp.Or(alt, lf.alt), cmp.Or(caption, lf.caption)
+		}
+	}
+	if alt == canonical.alt && caption == canonical.caption {
+		return nil
+	}
+	return a.db.updateMediaLibraryText(canonical.name, alt, caption)
+}
+
+// replaceMediaLocation replaces a media file location in the text without replacing it as part of a longer location.
+// Relative locations are only replaced at the start of a link or image destination, not as part of URLs of other hosts.
+func replaceMediaLocation(text, old, replacement string) string {
+	relative := strings.HasPrefix(old, "/")
+	var result strings.Builder
+	for {
+		i := strings.Index(text, old)
+		if i < 0 {
+			result.WriteString(text)
+			return result.String()
+		}
+		end := i + len(old)
+		startOK := !relative || i == 0 || strings.ContainsRune(" \t\r\n([<\"'=,", rune(text[i-1]))
+		endOK := end == len(text) || !isURLPathChar(text[end])
+		result.WriteString(text[:i])
+		if startOK && endOK {
+			result.WriteString(replacement)
+		} else {
+			result.WriteString(old)
+		}
+		text = text[end:]
+	}
+}
+
+// isURLPathChar checks if the character can continue the file name or path of a URL
+func isURLPathChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-._~%/", c) >= 0
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mediaUploadDeduplication(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	mediaPath := t.TempDir()
+	app.mediaStorage = &localMediaStorage{path: mediaPath}
+	app.mediaStorageInit.Do(func() {})
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	photo, err := os.ReadFile("testdata/exif.jpg")
+	require.NoError(t, err)
+
+	upload := func(filename string) string {
+		body := &bytes.Buffer{}
+		mpw := multipart.NewWriter(body)
+		require.NoError(t, mpw.WriteField("editoraction", "upload"))
+		part, err := mpw.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, _ = part.Write(photo)
+		require.NoError(t, mpw.Close())
+		req := httptest.NewRequest(http.MethodPost, "/editor", body)
+		req.Header.Set(contentType, mpw.FormDataContentType())
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusFound, rec.Code)
+		redirect, err := url.Parse(rec.Header().Get("Location"))
+		require.NoError(t, err)
+		return redirect.Query().Get("uploaded")
+	}
+
+	first := upload("photo.jpg")
+	entries, err := os.ReadDir(mediaPath)
+	require.NoError(t, err)
+
+	// The same photo with another file extension reuses the uploaded file
+	second := upload("photo.jpeg")
+	assert.Equal(t, first, second)
+	newEntries, err := os.ReadDir(mediaPath)
+	require.NoError(t, err)
+	assert.Len(t, newEntries, len(entries))
+}
+
+func Test_deduplicateMediaFiles(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	mediaPath := t.TempDir()
+	app.mediaStorage = &localMediaStorage{path: mediaPath}
+	app.mediaStorageInit.Do(func() {})
+	require.NoError(t, app.initConfig(false))
+
+	// Files uploaded before the uploads were deduplicated
+	content := []byte("identical content")
+	hashName := fmt.Sprintf("%x.txt", sha256.Sum256(content))
+	for i, name := range []string{"old.txt", hashName, "new.txt", "other.txt"} {
+		data := content
+		if name == "other.txt" {
+			data = []byte("other content")
+		}
+		file := filepath.Join(mediaPath, name)
+		require.NoError(t, os.WriteFile(file, data, 0o644))
+		modTime := time.Now().Add(time.Duration(i-10) * time.Hour)
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	// Posts using the duplicates
+	require.NoError(t, app.createPost(&post{
+		Path:    "/absolute",
+		Section: "posts",
+		Content: "[File](" + app.getFullAddress("/m/old.txt") + ") and [other](/m/other.txt), not https://other.example/m/old.txt",
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:       "/relative",
+		Section:    "posts",
+		Content:    "[File](/m/new.txt)",
+		Parameters: map[string][]string{"link": {"/m/old.txt"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/unrelated",
+		Section: "posts",
+		Content: "No files, only [another site](https://other.example/m/new.txt)",
+	}))
+	// A library entry with alt text, but without hash
+	require.NoError(t, app.db.saveMediaLibraryFile(&mediaLibraryFile{name: "old.txt", location: app.getFullAddress("/m/old.txt")}))
+	require.NoError(t, app.db.updateMediaLibraryText("old.txt", "Alt text", ""))
+
+	t.Run("Dry run", func(t *testing.T) {
+		duplicates, paths, err := app.deduplicateMediaFiles(true)
+		require.NoError(t, err)
+		require.Len(t, duplicates, 1)
+		// The file named by the hash is preferred, the others are sorted by time
+		assert.Equal(t, hashName, duplicates[0].canonical.Name)
+		require.Len(t, duplicates[0].duplicates, 2)
+		assert.Equal(t, "old.txt", duplicates[0].duplicates[0].Name)
+		assert.Equal(t, "new.txt", duplicates[0].duplicates[1].Name)
+		assert.Equal(t, []string{"/absolute", "/relative"}, paths)
+
+		p, err := app.getPost("/relative")
+		require.NoError(t, err)
+		assert.Equal(t, "[File](/m/new.txt)", p.Content)
+
+		// The dry run doesn't change the library
+		lf, err := app.db.getMediaLibraryFile("new.txt")
+		require.NoError(t, err)
+		assert.Nil(t, lf)
+		lf, err = app.db.getMediaLibraryFile("old.txt")
+		require.NoError(t, err)
+		require.NotNil(t, lf)
+		assert.Empty(t, lf.hash)
+	})
+
+	t.Run("Rewrite posts", func(t *testing.T) {
+		_, paths, err := app.deduplicateMediaFiles(false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/absolute", "/relative"}, paths)
+
+		p, err := app.getPost("/absolute")
+		require.NoError(t, err)
+		assert.Equal(t, "[File]("+app.getFullAddress("/m/"+hashName)+") and [other](/m/other.txt), not https://other.example/m/old.txt", p.Content)
+		p, err = app.getPost("/relative")
+		require.NoError(t, err)
+		assert.Equal(t, "[File](/m/"+hashName+")", p.Content)
+		assert.Equal(t, []string{"/m/" + hashName}, p.Parameters["link"])
+
+		// Links to other sites are unchanged
+		p, err = app.getPost("/unrelated")
+		require.NoError(t, err)
+		assert.Equal(t, "No files, only [another site](https://other.example/m/new.txt)", p.Content)
+
+		// The alt text of the duplicate is kept
+		lf, err := app.db.getMediaLibraryFile(hashName)
+		require.NoError(t, err)
+		assert.Equal(t, "Alt text", lf.alt)
+
+		// Files without hash are added to the library
+		lf, err = app.db.getMediaLibraryFile("new.txt")
+		require.NoError(t, err)
+		require.NotNil(t, lf)
+		assert.Equal(t, hashName[:64], lf.hash)
+
+		// Nothing left to do
+		_, paths, err = app.deduplicateMediaFiles(false)
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+
+		// New uploads with the same content reuse the canonical file
+		location, err := app.existingMediaLocation(hashName[:64])
+		require.NoError(t, err)
+		assert.Equal(t, app.getFullAddress("/m/"+hashName), location)
+	})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Files uploaded recently are never unused, they are probably about to be used in a new post
+const unusedMediaFileMinAge = 24 * time.Hour
+
+// mediaLibraryFile contains the stored information about a media file
+type mediaLibraryFile struct {
+	name, location string
+	alt, caption   string
+	width, height  int
+	mimeType, hash string
+	uploaded       string
+	original       string // Name of the file this one was created from, e.g. by compression
+}
+
+func (db *database) saveMediaLibraryFile(f *mediaLibraryFile) error {
+	_, err := db.Exec(
+		"insert into media_library (name, location, mime, hash, uploaded) values (@name, @location, @mime, @hash, @uploaded) "+
+			"on conflict (name) do update set location = excluded.location, mime = excluded.mime, hash = excluded.hash",
+		sql.Named("name", f.name), sql.Named("location", f.location), sql.Named("mime", f.mimeType),
+		sql.Named("hash", f.hash), sql.Named("uploaded", f.uploaded),
+	)
+	return err
+}
+
+func (db *database) updateMediaLibraryText(name, alt, caption string) error {
+	_, err := db.Exec(
+		"update media_library set alt = @alt, caption = @caption where name = @name",
+		sql.Named("name", name), sql.Named("alt", alt), sql.Named("caption", caption),
+	)
+	return err
+}
+
+func (db *database) setMediaLibraryOriginal(name, original string) error {
+	_, err := db.Exec("update media_library set original = @original where name = @name", sql.Named("name", name), sql.Named("original", original))
+	return err
+}
+
+func (db *database) setMediaLibraryDimensions(location string, width, height int) error {
+	_, err := db.Exec(
+		"update media_library set width = @width, height = @height where location = @location",
+		sql.Named("location", location), sql.Named("width", width), sql.Named("height", height),
+	)
+	return err
+}
+
+// deleteMediaLibraryFile removes the file from the library and deletes its variants and metadata
+func (db *database) deleteMediaLibraryFile(name, location string) error {
+	_, err := db.Exec(
+		"begin; delete from media_library where name = ?; delete from media_variants where original = ? or location = ?; delete from media_metadata where location = ?; commit;",
+		name, location, location, location,
+	)
+	return err
+}
+
+const mediaLibrarySelect = "select name, location, alt, caption, width, height, mime, hash, uploaded, original from media_library"
+
+func scanMediaLibraryFile(row interface{ Scan(...any) error }) (*mediaLibraryFile, error) {
+	f := &mediaLibraryFile{}
+	err := row.Scan(&f.name, &f.location, &f.alt, &f.caption, &f.width, &f.height, &f.mimeType, &f.hash, &f.uploaded, &f.original)
+	return f, err
+}
+
+// getMediaLibraryFile returns the library entry of the file or nil if there is none
+func (db *database) getMediaLibraryFile(name string) (*mediaLibraryFile, error) {
+	return db.queryMediaLibraryFile(" where name = @name", sql.Named("name", name))
+}
+
+// getMediaLibraryFileByHash returns the library entry with the content hash or nil if there is none.
+// Files named by the hash are preferred, then the first uploaded one.
+func (db *database) getMediaLibraryFileByHash(hash string) (*mediaLibraryFile, error) {
+	return db.queryMediaLibraryFile(
+		" where hash = @hash order by substr(name, 1, length(@hash)) = @hash desc, uploaded, name limit 1", sql.Named("hash", hash),
+	)
+}
+
+// getMediaLibraryCompressed returns the library entry of the file created from the original or nil if there is none
+func (db *database) getMediaLibraryCompressed(original string) (*mediaLibraryFile, error) {
+	return db.queryMediaLibraryFile(" where original = @original order by uploaded, name limit 1", sql.Named("original", original))
+}
+
+func (db *database) queryMediaLibraryFile(filter string, args ...any) (*mediaLibraryFile, error) {
+	row, err := db.QueryRow(mediaLibrarySelect+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	f, err := scanMediaLibraryFile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// getMediaLibraryFiles returns all library entries by name
+func (db *database) getMediaLibraryFiles() (map[string]*mediaLibraryFile, error) {
+	rows, err := db.Query(mediaLibrarySelect)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	files := map[string]*mediaLibraryFile{}
+	for rows.Next() {
+		f, err := scanMediaLibraryFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files[f.name] = f
+	}
+	return files, rows.Err()
+}
+
+// mediaMarkdown returns the Markdown to insert an image with the stored alt text or a link for other files
+func (f *mediaLibraryFile) mediaMarkdown() string {
+	if !strings.HasPrefix(f.mimeType, "image/") {
+		return "[" + f.name + "](" + f.location + ")"
+	}
+	md := "![" + strings.NewReplacer("[", "\\[", "]", "\\]").Replace(f.alt) + "](" + f.location
+	if f.caption != "" {
+		md += ` "` + strings.ReplaceAll(f.caption, `"`, `\"`) + `"`
+	}
+	return md + ")"
+}
+
+// unusedMediaFiles returns the media files that aren't referenced by any post, revision or editor draft.
+// Variants and originals of used files count as used as well.
+func (a *goBlog) unusedMediaFiles() ([]*mediaFile, error) {
+	files, err := a.mediaFiles()
+	if err != nil {
+		return nil, err
+	}
+	// Collect all texts that can reference files
+	rows, err := a.db.Query(
+		"select content from posts union all select value from post_parameters "+
+			"union all select content || ' ' || parameters from post_revisions "+
+			"union all select cast(data as text) from persistent_cache where key like @drafts or key like @state",
+		sql.Named("drafts", editorPostDraftCacheKey+"%"), sql.Named("state", editorStateCacheKey+"%"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var texts strings.Builder
+	for rows.Next() {
+		var text sql.NullString
+		if err = rows.Scan(&text); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		texts.WriteString(text.String)
+		texts.WriteByte('\n')
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	allTexts := texts.String()
+	used := map[string]bool{}
+	for _, f := range files {
+		if strings.Contains(allTexts, f.Name) {
+			used[f.Name] = true
+		}
+	}
+	// Add the responsive variants and the originals of used files
+	related := map[string][]string{}
+	rows, err = a.db.Query("select original, location from media_variants")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var original, location string
+		if err = rows.Scan(&original, &location); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		related[path.Base(original)] = append(related[path.Base(original)], path.Base(location))
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	library, err := a.db.getMediaLibraryFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, lf := range library {
+		if lf.original != "" {
+			related[lf.name] = append(related[lf.name], lf.original)
+		}
+	}
+	queue := make([]string, 0, len(used))
+	for name := range used {
+		queue = append(queue, name)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, r := range related[name] {
+			if !used[r] {
+				used[r] = true
+				queue = append(queue, r)
+			}
+		}
+	}
+	// Unused and not recently uploaded
+	minAge := time.Now().Add(-unusedMediaFileMinAge)
+	var unused []*mediaFile
+	for _, f := range files {
+		if used[f.Name] || f.Time.After(minAge) {
+			continue
+		}
+		if lf, ok := library[f.Name]; ok {
+			if uploaded, err := time.Parse(time.RFC3339, lf.uploaded); err == nil && uploaded.After(minAge) {
+				continue
+			}
+		}
+		unused = append(unused, f)
+	}
+	slices.SortFunc(unused, func(x, y *mediaFile) int { return strings.Compare(x.Name, y.Name) })
+	return unused, nil
+}
+
+// deleteUnusedMediaFiles deletes the files if they are still unused and returns the deleted file names
+func (a *goBlog) deleteUnusedMediaFiles(names []string) ([]string, error) {
+	unused, err := a.unusedMediaFiles()
+	if err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for _, f := range unused {
+		if !slices.Contains(names, f.Name) {
+			continue
+		}
+		if err = a.deleteMediaFile(f.Name); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, f.Name)
+	}
+	return deleted, nil
+}
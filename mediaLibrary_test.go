@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mediaLibraryFile_mediaMarkdown(t *testing.T) {
+	f := &mediaLibraryFile{name: "a.jpg", location: "/m/a.jpg", mimeType: "image/jpeg", alt: "A [test]", caption: `The "caption"`}
+	assert.Equal(t, `![A \[test\]](/m/a.jpg "The \"caption\"")`, f.mediaMarkdown())
+
+	f = &mediaLibraryFile{name: "a.jpg", location: "/m/a.jpg", mimeType: "image/jpeg"}
+	assert.Equal(t, "![](/m/a.jpg)", f.mediaMarkdown())
+
+	f = &mediaLibraryFile{name: "a.pdf", location: "/m/a.pdf", mimeType: "application/pdf"}
+	assert.Equal(t, "[a.pdf](/m/a.pdf)", f.mediaMarkdown())
+}
+
+func Test_mediaLibrary(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	mediaPath := t.TempDir()
+	app.mediaStorage = &localMediaStorage{path: mediaPath}
+	app.mediaStorageInit.Do(func() {})
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	photo, err := os.ReadFile("testdata/exif.jpg")
+	require.NoError(t, err)
+
+	// Upload using the editor with alt text and caption
+	body := &bytes.Buffer{}
+	mpw := multipart.NewWriter(body)
+	require.NoError(t, mpw.WriteField("editoraction", "upload"))
+	require.NoError(t, mpw.WriteField("alt", "A test photo"))
+	require.NoError(t, mpw.WriteField("caption", "Taken in Berlin"))
+	part, err := mpw.CreateFormFile("file", "photo.jpg")
+	require.NoError(t, err)
+	_, _ = part.Write(photo)
+	require.NoError(t, mpw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/editor", body)
+	req.Header.Set(contentType, mpw.FormDataContentType())
+	setLoggedIn(req, true)
+	rec := httptest.NewRecorder()
+	app.d.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	redirect, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	location := redirect.Query().Get("uploaded")
+	name := filepath.Base(location)
+
+	// The library contains the file
+	saved, err := os.ReadFile(filepath.Join(mediaPath, name))
+	require.NoError(t, err)
+	hash := sha256.Sum256(saved)
+	f, err := app.db.getMediaLibraryFile(name)
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	assert.Equal(t, location, f.location)
+	assert.Equal(t, "A test photo", f.alt)
+	assert.Equal(t, "Taken in Berlin", f.caption)
+	assert.Equal(t, "image/jpeg", f.mimeType)
+	assert.Equal(t, hex.EncodeToString(hash[:]), f.hash)
+	assert.Positive(t, f.width)
+	assert.Positive(t, f.height)
+	assert.NotEmpty(t, f.uploaded)
+
+	t.Run("Editor suggests alt text", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, redirect.RequestURI(), nil)
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `id=uploadedmarkdown`)
+		assert.Contains(t, rec.Body.String(), fmt.Sprintf(`![A test photo](%s "Taken in Berlin")`, location))
+		// Only files of the media storage are shown
+		for _, uploaded := range []string{"https://example.com/m/" + name, "javascript:alert(1)", "http://localhost:8080/m/../editor"} {
+			req := httptest.NewRequest(http.MethodGet, editorPath+"?"+url.Values{"uploaded": {uploaded}}.Encode(), nil)
+			setLoggedIn(req, true)
+			rec := httptest.NewRecorder()
+			app.d.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), uploaded)
+			assert.NotContains(t, rec.Body.String(), `id=uploadedmarkdown`)
+		}
+	})
+
+	t.Run("Edit alt text", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, editorPath+editorFileEditPath, strings.NewReader(url.Values{
+			"filename": {name}, "alt": {"Another alt text"}, "caption": {""},
+		}.Encode()))
+		req.Header.Set(contentType, "application/x-www-form-urlencoded")
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		f, err := app.db.getMediaLibraryFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "Another alt text", f.alt)
+		assert.Empty(t, f.caption)
+
+		req = httptest.NewRequest(http.MethodGet, editorPath+editorFileEditPath+"?filename="+name, nil)
+		setLoggedIn(req, true)
+		rec = httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Another alt text")
+		assert.Contains(t, rec.Body.String(), f.hash)
+	})
+
+	t.Run("Attached files use alt text", func(t *testing.T) {
+		// The same photo is attached again
+		body := &bytes.Buffer{}
+		mpw := multipart.NewWriter(body)
+		require.NoError(t, mpw.WriteField("editoraction", "createpost"))
+		require.NoError(t, mpw.WriteField("content", "Post with attached photo"))
+		part, err := mpw.CreateFormFile("files1", "photo.jpg")
+		require.NoError(t, err)
+		_, _ = part.Write(photo)
+		require.NoError(t, mpw.Close())
+		req := httptest.NewRequest(http.MethodPost, "/editor", body)
+		req.Header.Set(contentType, mpw.FormDataContentType())
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusFound, rec.Code)
+
+		postURL, err := url.Parse(rec.Header().Get("Location"))
+		require.NoError(t, err)
+		p, err := app.getPost(postURL.Path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{location}, p.Parameters[app.cfg.Micropub.PhotoParam])
+		assert.Equal(t, []string{"Another alt text"}, p.Parameters[app.cfg.Micropub.PhotoDescriptionParam])
+		assert.Contains(t, p.Content, fmt.Sprintf(`![Another alt text](%s "Another alt text")`, location))
+		require.NoError(t, app.deletePost(p.Path))
+		require.NoError(t, app.deletePost(p.Path))
+	})
+
+	// Make all files old enough to count as unused
+	old := time.Now().Add(-2 * unusedMediaFileMinAge)
+	entries, err := os.ReadDir(mediaPath)
+	require.NoError(t, err)
+	for _, e := range entries {
+		require.NoError(t, os.Chtimes(filepath.Join(mediaPath, e.Name()), old, old))
+	}
+	_, err = app.db.Exec("update media_library set uploaded = ?", old.UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+
+	t.Run("Unused files", func(t *testing.T) {
+		unused, err := app.unusedMediaFiles()
+		require.NoError(t, err)
+		require.Len(t, unused, len(entries))
+
+		// Files that are recently uploaded are never unused
+		require.NoError(t, os.WriteFile(filepath.Join(mediaPath, "new.txt"), []byte("new"), 0o644))
+		unused, err = app.unusedMediaFiles()
+		require.NoError(t, err)
+		assert.Len(t, unused, len(entries))
+
+		// Referenced in an editor draft
+		require.NoError(t, app.db.cachePersistently(editorPostDraftCacheKey+"test", []byte("![]("+location+")")))
+		unused, err = app.unusedMediaFiles()
+		require.NoError(t, err)
+		assert.Empty(t, unused)
+		require.NoError(t, app.db.clearPersistentCache(editorPostDraftCacheKey+"test"))
+
+		// Referenced in a post
+		require.NoError(t, app.createPost(&post{
+			Path:    "/photo",
+			Section: "posts",
+			Content: "![Photo](" + location + ")",
+		}))
+		unused, err = app.unusedMediaFiles()
+		require.NoError(t, err)
+		assert.Empty(t, unused)
+
+		// Posts in the trash still use it
+		require.NoError(t, app.deletePost("/photo"))
+		unused, err = app.unusedMediaFiles()
+		require.NoError(t, err)
+		assert.Empty(t, unused)
+
+		// Deleting the post permanently removes the reference
+		require.NoError(t, app.deletePost("/photo"))
+		unused, err = app.unusedMediaFiles()
+		require.NoError(t, err)
+		assert.Len(t, unused, len(entries))
+	})
+
+	t.Run("Unused files page and deletion", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, editorPath+editorFilesUnusedPath, nil)
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), name)
+		assert.NotContains(t, rec.Body.String(), "new.txt")
+
+		require.NoError(t, app.db.saveMediaVariants(location, []*mediaVariant{{location: location, width: 100, format: "image/jpeg"}}))
+		m, err := app.db.getMediaMetadata(location)
+		require.NoError(t, err)
+		require.NotNil(t, m)
+
+		// Files that aren't unused are never deleted
+		req = httptest.NewRequest(http.MethodPost, editorPath+editorFilesUnusedPath, strings.NewReader(url.Values{
+			"filename": {name, "new.txt"},
+		}.Encode()))
+		req.Header.Set(contentType, "application/x-www-form-urlencoded")
+		setLoggedIn(req, true)
+		rec = httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		assert.NoFileExists(t, filepath.Join(mediaPath, name))
+		assert.FileExists(t, filepath.Join(mediaPath, "new.txt"))
+		f, err := app.db.getMediaLibraryFile(name)
+		require.NoError(t, err)
+		assert.Nil(t, f)
+
+		// The metadata and variants are deleted as well
+		m, err = app.db.getMediaMetadata(location)
+		require.NoError(t, err)
+		assert.Nil(t, m)
+		variants, err := app.db.getMediaVariants(location)
+		require.NoError(t, err)
+		assert.Empty(t, variants)
+	})
+}
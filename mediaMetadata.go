@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	"github.com/kovidgoyal/imaging"
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/blurhash"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"go.goblog.app/app/pkgs/imagemeta"
+)
+
+// mediaMetadata contains the selected EXIF fields of an uploaded photo, the location is never shown publicly.
+// The dimensions and the placeholder are saved separately for each image file.
+type mediaMetadata struct {
+	camera, lens, exposure, taken, geo string
+	width, height                      int
+	blurhash, placeholder              string
+}
+
+// photoExtensions are the file extensions of uploaded photos that may contain EXIF metadata
+var photoExtensions = []string{".jpg", ".jpeg", ".png", ".webp", ".heic", ".heif", ".avif", ".tif", ".tiff"}
+
+// processPhotoMetadata extracts the metadata of a photo and removes it from the file, unless configured otherwise
+func (a *goBlog) processPhotoMetadata(data []byte) ([]byte, *mediaMetadata, error) {
+	var metadata *mediaMetadata
+	if m, err := imagemeta.Read(data); err != nil {
+		a.debug("Failed to read photo metadata", "err", err)
+	} else if m != nil {
+		metadata = &mediaMetadata{camera: m.Camera, lens: m.Lens, exposure: m.Exposure}
+		if !m.Taken.IsZero() {
+			metadata.taken = m.Taken.Format("2006-01-02 15:04")
+		}
+		if m.HasLocation {
+			metadata.geo = fmt.Sprintf("geo:%.5f,%.5f", m.Latitude, m.Longitude)
+		}
+	}
+	if ms := a.cfg.Micropub.MediaStorage; ms != nil && ms.KeepExif {
+		return data, metadata, nil
+	}
+	stripped, err := imagemeta.Strip(data)
+	if errors.Is(err, imagemeta.ErrUnsupported) {
+		// Don't store photos with metadata that can't be removed
+		return nil, nil, errors.New("photo metadata can't be removed from this format, convert it to JPEG, PNG or WebP or enable keepExif")
+	} else if err != nil {
+		return nil, nil, errors.Join(errors.New("failed to remove photo metadata"), err)
+	}
+	return stripped, metadata, nil
+}
+
+// summary returns the publicly shown fields
+func (m *mediaMetadata) summary() string {
+	return strings.Join(lo.Compact([]string{m.camera, m.lens, m.exposure, m.taken}), " · ")
+}
+
+func (db *database) saveMediaMetadata(m *mediaMetadata, locations ...string) error {
+	for _, location := range lo.Uniq(locations) {
+		_, err := db.Exec(
+			"insert into media_metadata (location, camera, lens, exposure, taken, geo) values (@location, @camera, @lens, @exposure, @taken, @geo) "+
+				"on conflict (location) do update set camera = excluded.camera, lens = excluded.lens, exposure = excluded.exposure, taken = excluded.taken, geo = excluded.geo",
+			sql.Named("location", location), sql.Named("camera", m.camera), sql.Named("lens", m.lens),
+			sql.Named("exposure", m.exposure), sql.Named("taken", m.taken), sql.Named("geo", m.geo),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveMediaDimensions saves the size and the BlurHash placeholder of an image file,
+// the placeholder is also saved as data URI, so it doesn't need to be decoded for every rendering
+func (db *database) saveMediaDimensions(location string, img image.Image) error {
+	bounds := img.Bounds()
+	xComponents, yComponents := 4, 3
+	if bounds.Dy() > bounds.Dx() {
+		xComponents, yComponents = 3, 4
+	}
+	// The placeholder only needs a few pixels
+	hash, err := blurhash.Encode(imaging.Fit(img, 32, 32, imaging.Box), xComponents, yComponents)
+	if err != nil {
+		return err
+	}
+	placeholder := blurhashPlaceholder(hash, bounds.Dx(), bounds.Dy())
+	_, err = db.Exec(
+		"insert into media_metadata (location, width, height, blurhash, placeholder) values (@location, @width, @height, @blurhash, @placeholder) "+
+			"on conflict (location) do update set width = excluded.width, height = excluded.height, blurhash = excluded.blurhash, placeholder = excluded.placeholder",
+		sql.Named("location", location), sql.Named("width", bounds.Dx()), sql.Named("height", bounds.Dy()),
+		sql.Named("blurhash", hash), sql.Named("placeholder", placeholder),
+	)
+	if err != nil {
+		return err
+	}
+	return db.setMediaLibraryDimensions(location, bounds.Dx(), bounds.Dy())
+}
+
+// getMediaMetadata returns the metadata of the media file or nil if there is none
+func (db *database) getMediaMetadata(location string) (*mediaMetadata, error) {
+	row, err := db.QueryRow("select camera, lens, exposure, taken, geo, width, height, blurhash, placeholder from media_metadata where location = @location", sql.Named("location", location))
+	if err != nil {
+		return nil, err
+	}
+	m := &mediaMetadata{}
+	if err = row.Scan(&m.camera, &m.lens, &m.exposure, &m.taken, &m.geo, &m.width, &m.height, &m.blurhash, &m.placeholder); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// blurhashPlaceholder decodes the BlurHash to a tiny PNG data URI with the aspect ratio of the image
+func blurhashPlaceholder(hash string, width, height int) string {
+	if hash == "" || width == 0 || height == 0 {
+		return ""
+	}
+	img, err := blurhash.Decode(hash, 8, max(1, min(32, 8*height/width)))
+	if err != nil {
+		return ""
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(buf, img); err != nil {
+		return ""
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
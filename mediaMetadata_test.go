@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ap "go.goblog.app/app/pkgs/activitypub"
+	"go.goblog.app/app/pkgs/imagemeta"
+)
+
+func Test_mediaMetadata(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	mediaPath := t.TempDir()
+	app.mediaStorage = &localMediaStorage{path: mediaPath}
+	app.mediaStorageInit.Do(func() {})
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs["default"].Photos = &configPhotos{Enabled: true, ShowExif: true}
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	photo, err := os.ReadFile("testdata/exif.jpg")
+	require.NoError(t, err)
+
+	// Upload using the editor
+	body := &bytes.Buffer{}
+	mpw := multipart.NewWriter(body)
+	require.NoError(t, mpw.WriteField("editoraction", "upload"))
+	part, err := mpw.CreateFormFile("file", "photo.jpg")
+	require.NoError(t, err)
+	_, _ = part.Write(photo)
+	require.NoError(t, mpw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/editor", body)
+	req.Header.Set(contentType, mpw.FormDataContentType())
+	setLoggedIn(req, true)
+	rec := httptest.NewRecorder()
+	app.d.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	redirect, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/editor", redirect.Path)
+	location := redirect.Query().Get("uploaded")
+	require.True(t, strings.HasPrefix(location, app.cfg.Server.PublicAddress+"/m/"))
+
+	// The saved file doesn't contain the metadata except the orientation
+	saved, err := os.ReadFile(filepath.Join(mediaPath, filepath.Base(location)))
+	require.NoError(t, err)
+	assert.Less(t, len(saved), len(photo))
+	m, err := imagemeta.Read(saved)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.False(t, m.HasLocation)
+	assert.Empty(t, m.Camera)
+	assert.Equal(t, 6, m.Orientation)
+
+	// But the metadata is saved separately
+	metadata, err := app.db.getMediaMetadata(location)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "FUJIFILM X100V · 23mm F2 · 1/250 s · f/2.8 · ISO 400 · 23 mm · 2024-05-01 14:03", metadata.summary())
+	assert.Equal(t, "geo:52.50000,13.40000", metadata.geo)
+	// The dimensions and the placeholder as well
+	assert.Positive(t, metadata.width)
+	assert.Positive(t, metadata.height)
+	assert.NotEmpty(t, metadata.blurhash)
+	assert.True(t, strings.HasPrefix(metadata.placeholder, "data:image/png;base64,"))
+	assert.Equal(t, blurhashPlaceholder(metadata.blurhash, metadata.width, metadata.height), metadata.placeholder)
+
+	t.Run("Editor suggests location", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, redirect.RequestURI(), nil)
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "location: geo:52.50000,13.40000")
+		assert.Contains(t, rec.Body.String(), "p%3Alocation=geo%3A52.50000%2C13.40000")
+	})
+
+	t.Run("Photos index shows metadata", func(t *testing.T) {
+		require.NoError(t, app.createPost(&post{
+			Path:       "/photo",
+			Section:    "posts",
+			Content:    "Photo",
+			Parameters: map[string][]string{app.cfg.Micropub.PhotoParam: {location}},
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/photos", nil)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "FUJIFILM X100V")
+		assert.NotContains(t, rec.Body.String(), "geo:52.5")
+	})
+
+	t.Run("Image dimensions and placeholder", func(t *testing.T) {
+		rendered, err := app.renderMarkdown("![Photo]("+location+")", false)
+		require.NoError(t, err)
+		assert.Contains(t, string(rendered), fmt.Sprintf(`width=%d height=%d`, metadata.width, metadata.height))
+		assert.Contains(t, string(rendered), `data-placeholder="data:image/png;base64,`)
+		assert.NotContains(t, string(rendered), "style=")
+
+		// ActivityPub attachments
+		p, err := app.getPost("/photo")
+		require.NoError(t, err)
+		attachments, ok := app.toAPNote(p).Attachment.(ap.ItemCollection)
+		require.True(t, ok)
+		require.Len(t, attachments, 1)
+		image, ok := attachments[0].(*ap.Object)
+		require.True(t, ok)
+		assert.Equal(t, metadata.width, image.Width)
+		assert.Equal(t, metadata.height, image.Height)
+		assert.Equal(t, metadata.blurhash, image.Blurhash)
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		heic := []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00")
+		_, _, err := app.processPhotoMetadata(heic)
+		assert.ErrorContains(t, err, "keepExif")
+
+		// Stored unchanged when keeping the metadata
+		app.cfg.Micropub.MediaStorage = &configMicropubMedia{KeepExif: true}
+		defer func() { app.cfg.Micropub.MediaStorage = nil }()
+		data, _, err := app.processPhotoMetadata(heic)
+		require.NoError(t, err)
+		assert.Equal(t, heic, data)
+	})
+
+	t.Run("Keep EXIF", func(t *testing.T) {
+		app.cfg.Micropub.MediaStorage = &configMicropubMedia{KeepExif: true}
+		defer func() { app.cfg.Micropub.MediaStorage = nil }()
+		data, metadata, err := app.processPhotoMetadata(photo)
+		require.NoError(t, err)
+		assert.Equal(t, photo, data)
+		assert.NotNil(t, metadata)
+	})
+}
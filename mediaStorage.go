@@ -1,12 +1,18 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -14,14 +20,18 @@ import (
 	"github.com/araddon/dateparse"
 	"github.com/carlmjohnson/requests"
 	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
 	"go.goblog.app/app/pkgs/contenttype"
+	"go.goblog.app/app/pkgs/s3"
 	"go.goblog.app/app/pkgs/utils"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func (a *goBlog) initMediaStorage() {
 	a.mediaStorageInit.Do(func() {
 		type initFunc func() mediaStorage
-		for _, fc := range []initFunc{a.initBunnyCdnMediaStorage, a.initFtpMediaStorage, a.initLocalMediaStorage} {
+		for _, fc := range []initFunc{a.initBunnyCdnMediaStorage, a.initFtpMediaStorage, a.initSftpMediaStorage, a.initWebdavMediaStorage, a.initS3MediaStorage, a.initLocalMediaStorage} {
 			a.mediaStorage = fc()
 			if a.mediaStorage != nil {
 				break
@@ -44,11 +54,26 @@ func (a *goBlog) saveMediaFile(filename string, f io.Reader) (string, error) {
 	if a.mediaStorage == nil {
 		return "", errNoMediaStorageConfigured
 	}
-	loc, err := a.mediaStorage.save(filename, f)
+	hash := sha256.New()
+	loc, err := a.mediaStorage.save(filename, io.TeeReader(f, hash))
 	if err != nil {
 		return "", err
 	}
-	return a.getFullAddress(loc), nil
+	loc = a.getFullAddress(loc)
+	// Add to the media library
+	if a.db != nil {
+		err = a.db.saveMediaLibraryFile(&mediaLibraryFile{
+			name:     filename,
+			location: loc,
+			mimeType: mime.TypeByExtension(path.Ext(filename)),
+			hash:     fmt.Sprintf("%x", hash.Sum(nil)),
+			uploaded: utcNowString(),
+		})
+		if err != nil {
+			a.error("Failed to add file to media library", "file", filename, "err", err)
+		}
+	}
+	return loc, nil
 }
 
 func (a *goBlog) deleteMediaFile(filename string) error {
@@ -60,7 +85,13 @@ func (a *goBlog) deleteMediaFile(filename string) error {
 	if !isValidMediaFilename(filename) {
 		return fmt.Errorf("invalid filename: %s", filename)
 	}
-	return a.mediaStorage.delete(filename)
+	if err := a.mediaStorage.delete(filename); err != nil {
+		return err
+	}
+	if a.db != nil {
+		return a.db.deleteMediaLibraryFile(filename, a.getFullAddress(a.mediaStorage.location(filename)))
+	}
+	return nil
 }
 
 type mediaFile struct {
@@ -325,6 +356,322 @@ func (f *ftpMediaStorage) connection() (*ftp.ServerConn, error) {
 	return c, nil
 }
 
+type sftpMediaStorage struct {
+	address         string              // required
+	user            string              // required
+	signer          ssh.Signer          // required
+	hostKeyCallback ssh.HostKeyCallback // required
+	path            string              // optional
+	mediaURL        string              // required
+}
+
+func (a *goBlog) initSftpMediaStorage() mediaStorage {
+	config := a.cfg.Micropub.MediaStorage
+	if config == nil || config.SFTPAddress == "" || config.SFTPUser == "" || config.SFTPPrivateKey == "" || config.MediaURL == "" {
+		return nil
+	}
+	key, err := os.ReadFile(config.SFTPPrivateKey)
+	if err != nil {
+		a.error("Failed to read SFTP private key", "err", err)
+		return nil
+	}
+	var signer ssh.Signer
+	if config.SFTPPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(config.SFTPPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		a.error("Failed to parse SFTP private key", "err", err)
+		return nil
+	}
+	// The identity of the server has to be verified
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case config.SFTPHostKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.SFTPHostKey))
+		if err != nil {
+			a.error("Failed to parse SFTP host key", "err", err)
+			return nil
+		}
+		hostKeyCallback = ssh.FixedHostKey(hostKey)
+	case config.SFTPKnownHosts != "":
+		if hostKeyCallback, err = knownhosts.New(config.SFTPKnownHosts); err != nil {
+			a.error("Failed to read SFTP known hosts file", "err", err)
+			return nil
+		}
+	default:
+		a.error("SFTP media storage requires a host key or known hosts file")
+		return nil
+	}
+	return &sftpMediaStorage{
+		address:         config.SFTPAddress,
+		user:            config.SFTPUser,
+		signer:          signer,
+		hostKeyCallback: hostKeyCallback,
+		path:            cmp.Or(config.SFTPPath, "."),
+		mediaURL:        config.MediaURL,
+	}
+}
+
+func (f *sftpMediaStorage) save(filename string, file io.Reader) (location string, err error) {
+	c, closeFunc, err := f.connection()
+	if err != nil {
+		return "", err
+	}
+	defer closeFunc()
+	remoteFile, err := c.OpenFile(path.Join(f.path, filename), os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return "", err
+	}
+	if _, err = remoteFile.ReadFrom(file); err != nil {
+		_ = remoteFile.Close()
+		return "", err
+	}
+	if err = remoteFile.Close(); err != nil {
+		return "", err
+	}
+	return f.location(filename), nil
+}
+
+func (f *sftpMediaStorage) delete(filename string) (err error) {
+	c, closeFunc, err := f.connection()
+	if err != nil {
+		return err
+	}
+	defer closeFunc()
+	return c.Remove(path.Join(f.path, filename))
+}
+
+func (f *sftpMediaStorage) files() (files []*mediaFile, err error) {
+	c, closeFunc, err := f.connection()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+	entries, err := c.ReadDir(f.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range entries {
+		if s.Mode().IsRegular() {
+			files = append(files, &mediaFile{
+				Name:     s.Name(),
+				Location: f.location(s.Name()),
+				Time:     s.ModTime(),
+				Size:     s.Size(),
+			})
+		}
+	}
+	return files, nil
+}
+
+func (f *sftpMediaStorage) location(name string) string {
+	return fmt.Sprintf("%s/%s", f.mediaURL, name)
+}
+
+func (f *sftpMediaStorage) connection() (*sftp.Client, func(), error) {
+	if f.address == "" || f.user == "" || f.signer == nil || f.hostKeyCallback == nil {
+		return nil, nil, errors.New("missing SFTP config")
+	}
+	conn, err := ssh.Dial("tcp", f.address, &ssh.ClientConfig{
+		User:            f.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(f.signer)},
+		HostKeyCallback: f.hostKeyCallback,
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+	return c, func() {
+		_ = c.Close()
+		_ = conn.Close()
+	}, nil
+}
+
+type webdavMediaStorage struct {
+	address    string       // required
+	user       string       // required
+	password   string       // required
+	mediaURL   string       // required
+	httpClient *http.Client // required
+}
+
+func (a *goBlog) initWebdavMediaStorage() mediaStorage {
+	config := a.cfg.Micropub.MediaStorage
+	if config == nil || config.WebDAVURL == "" || config.WebDAVUser == "" || config.WebDAVPassword == "" || config.MediaURL == "" {
+		return nil
+	}
+	return &webdavMediaStorage{
+		address:    strings.TrimSuffix(config.WebDAVURL, "/") + "/",
+		user:       config.WebDAVUser,
+		password:   config.WebDAVPassword,
+		mediaURL:   config.MediaURL,
+		httpClient: a.httpClient,
+	}
+}
+
+type webdavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ContentLength int64  `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const webdavPropfind = `<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>`
+
+func (f *webdavMediaStorage) save(filename string, file io.Reader) (location string, err error) {
+	err = requests.URL(f.address+url.PathEscape(filename)).
+		Method(http.MethodPut).
+		Client(f.httpClient).
+		BasicAuth(f.user, f.password).
+		ContentType(cmp.Or(mime.TypeByExtension(path.Ext(filename)), "application/octet-stream")).
+		BodyReader(file).
+		Fetch(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return f.location(filename), nil
+}
+
+func (f *webdavMediaStorage) delete(filename string) (err error) {
+	return requests.URL(f.address+url.PathEscape(filename)).
+		Method(http.MethodDelete).
+		Client(f.httpClient).
+		BasicAuth(f.user, f.password).
+		Fetch(context.Background())
+}
+
+func (f *webdavMediaStorage) files() (files []*mediaFile, err error) {
+	var ms webdavMultistatus
+	err = requests.URL(f.address).
+		Method("PROPFIND").
+		Client(f.httpClient).
+		BasicAuth(f.user, f.password).
+		Header("Depth", "1").
+		ContentType("application/xml; charset=utf-8").
+		BodyBytes([]byte(webdavPropfind)).
+		CheckStatus(http.StatusMultiStatus).
+		ToDeserializer(xml.Unmarshal, &ms).
+		Fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ms.Responses {
+		href, err := url.PathUnescape(r.Href)
+		if err != nil || strings.HasSuffix(href, "/") {
+			// Invalid or a collection like the directory itself
+			continue
+		}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") || ps.Prop.ResourceType.Collection != nil {
+				continue
+			}
+			name := path.Base(href)
+			t, _ := http.ParseTime(ps.Prop.LastModified)
+			files = append(files, &mediaFile{
+				Name:     name,
+				Location: f.location(name),
+				Time:     t,
+				Size:     ps.Prop.ContentLength,
+			})
+		}
+	}
+	return files, nil
+}
+
+func (f *webdavMediaStorage) location(name string) string {
+	return fmt.Sprintf("%s/%s", f.mediaURL, name)
+}
+
+type s3MediaStorage struct {
+	client   *s3.Client // required
+	prefix   string     // optional
+	mediaURL string     // optional
+}
+
+func (a *goBlog) initS3MediaStorage() mediaStorage {
+	config := a.cfg.Micropub.MediaStorage
+	if config == nil || config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+		return nil
+	}
+	return &s3MediaStorage{
+		client: &s3.Client{
+			Endpoint:   config.S3Endpoint,
+			Region:     config.S3Region,
+			Bucket:     config.S3Bucket,
+			AccessKey:  config.S3AccessKey,
+			SecretKey:  config.S3SecretKey,
+			PathStyle:  config.S3PathStyle,
+			HTTPClient: a.httpClient,
+		},
+		prefix:   strings.Trim(config.S3Prefix, "/"),
+		mediaURL: config.MediaURL,
+	}
+}
+
+func (f *s3MediaStorage) save(filename string, file io.Reader) (location string, err error) {
+	mimeType := cmp.Or(mime.TypeByExtension(path.Ext(filename)), "application/octet-stream")
+	if err = f.client.Put(context.Background(), f.key(filename), file, mimeType); err != nil {
+		return "", err
+	}
+	return f.location(filename), nil
+}
+
+func (f *s3MediaStorage) delete(filename string) (err error) {
+	return f.client.Delete(context.Background(), f.key(filename))
+}
+
+func (f *s3MediaStorage) files() (files []*mediaFile, err error) {
+	objects, err := f.client.List(context.Background(), f.key(""))
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		name := strings.TrimPrefix(o.Key, f.key(""))
+		// Ignore objects in subfolders
+		if name == "" || strings.Contains(name, "/") {
+			continue
+		}
+		files = append(files, &mediaFile{
+			Name:     name,
+			Location: f.location(name),
+			Time:     o.LastModified,
+			Size:     o.Size,
+		})
+	}
+	return files, nil
+}
+
+func (f *s3MediaStorage) location(name string) string {
+	if f.mediaURL != "" {
+		// The media URL points to the root of the bucket, like the bucket URL
+		return f.mediaURL + "/" + f.key(name)
+	}
+	return f.client.URL(f.key(name))
+}
+
+func (f *s3MediaStorage) key(filename string) string {
+	if f.prefix == "" {
+		return filename
+	}
+	return f.prefix + "/" + filename
+}
+
 func isValidMediaFilename(filename string) bool {
 	if filename == "" || strings.Contains(filename, "/") || strings.Contains(filename, "\\") || strings.Contains(filename, "..") {
 		return false
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -14,8 +20,12 @@ import (
 	"github.com/jlaffaye/ftp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/mocksftp"
 	"goftp.io/server/v2"
 	"goftp.io/server/v2/driver/file"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/net/webdav"
 )
 
 func Test_localMediaStorage_location(t *testing.T) {
@@ -248,6 +258,282 @@ func Test_ftpMediaStorage_integration(t *testing.T) {
 	assert.Empty(t, files)
 }
 
+func Test_sftpMediaStorage_integration(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	keyBlock, err := ssh.MarshalPrivateKey(private, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(keyBlock), 0o600))
+
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "media"), 0o755))
+	address, hostKey, cancel, err := mocksftp.StartMockSFTPServer(root, "user", signer.PublicKey())
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		SFTPAddress:    address,
+		SFTPUser:       "user",
+		SFTPPrivateKey: keyFile,
+		SFTPHostKey:    string(ssh.MarshalAuthorizedKey(hostKey)),
+		SFTPPath:       "media",
+		MediaURL:       "https://media.example",
+	}
+	app.initMediaStorage()
+	storage, ok := app.mediaStorage.(*sftpMediaStorage)
+	require.True(t, ok)
+
+	const fname = "sample.txt"
+	loc, err := storage.save(fname, strings.NewReader("data"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://media.example/sample.txt", loc)
+
+	data, err := os.ReadFile(filepath.Join(root, "media", fname))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	files, err := storage.files()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, fname, files[0].Name)
+	assert.Equal(t, loc, files[0].Location)
+	assert.Equal(t, int64(4), files[0].Size)
+
+	require.NoError(t, storage.delete(fname))
+	_, err = os.Stat(filepath.Join(root, "media", fname))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	files, err = storage.files()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// A different host key is rejected
+	_, otherPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ssh.NewPublicKey(otherPrivate.Public())
+	require.NoError(t, err)
+	storage.hostKeyCallback = ssh.FixedHostKey(otherKey)
+	_, err = storage.files()
+	assert.Error(t, err)
+
+	// The host key can also be verified using a known hosts file
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{address}, hostKey)+"\n"), 0o600))
+	app.cfg.Micropub.MediaStorage.SFTPHostKey = ""
+	app.cfg.Micropub.MediaStorage.SFTPKnownHosts = knownHostsFile
+	storage, ok = app.initSftpMediaStorage().(*sftpMediaStorage)
+	require.True(t, ok)
+	_, err = storage.files()
+	require.NoError(t, err)
+
+	// Unknown hosts are rejected
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{address}, otherKey)+"\n"), 0o600))
+	storage, ok = app.initSftpMediaStorage().(*sftpMediaStorage)
+	require.True(t, ok)
+	_, err = storage.files()
+	assert.Error(t, err)
+
+	// Without host key the storage isn't initialized
+	app.cfg.Micropub.MediaStorage.SFTPKnownHosts = ""
+	assert.Nil(t, app.initSftpMediaStorage())
+}
+
+func Test_sftpMediaStorage_errorsWithoutConfig(t *testing.T) {
+	storage := &sftpMediaStorage{}
+
+	_, err := storage.save("file.txt", strings.NewReader("data"))
+	assert.Error(t, err)
+	assert.Error(t, storage.delete("file.txt"))
+	_, err = storage.files()
+	assert.Error(t, err)
+
+	// Invalid key file
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		SFTPAddress:    "localhost:22",
+		SFTPUser:       "user",
+		SFTPPrivateKey: filepath.Join(t.TempDir(), "missing"),
+		MediaURL:       "https://media.example",
+	}
+	assert.Nil(t, app.initSftpMediaStorage())
+}
+
+func Test_webdavMediaStorage_integration(t *testing.T) {
+	root := t.TempDir()
+	dav := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.Dir(root),
+		LockSystem: webdav.NewMemLS(),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		WebDAVURL:      ts.URL + "/dav",
+		WebDAVUser:     "user",
+		WebDAVPassword: "pass",
+		MediaURL:       "https://media.example",
+	}
+	app.httpClient = ts.Client()
+	app.initMediaStorage()
+	storage, ok := app.mediaStorage.(*webdavMediaStorage)
+	require.True(t, ok)
+
+	const fname = "sample file.txt"
+	loc, err := storage.save(fname, strings.NewReader("data"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://media.example/sample file.txt", loc)
+
+	data, err := os.ReadFile(filepath.Join(root, fname))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	// Directories are ignored
+	require.NoError(t, os.Mkdir(filepath.Join(root, "nested"), 0o755))
+
+	files, err := storage.files()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, fname, files[0].Name)
+	assert.Equal(t, loc, files[0].Location)
+	assert.Equal(t, int64(4), files[0].Size)
+	assert.False(t, files[0].Time.IsZero())
+
+	require.NoError(t, storage.delete(fname))
+	_, err = os.Stat(filepath.Join(root, fname))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	files, err = storage.files()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Wrong credentials
+	storage.password = "wrong"
+	_, err = storage.save(fname, strings.NewReader("data"))
+	assert.Error(t, err)
+}
+
+func Test_s3MediaStorage_integration(t *testing.T) {
+	bucket := startTestS3Server(t)
+
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		S3Endpoint:  bucket.URL,
+		S3Bucket:    "media",
+		S3Prefix:    "/blog/",
+		S3AccessKey: "key",
+		S3SecretKey: "secret",
+		S3PathStyle: true,
+	}
+	app.httpClient = bucket.Client()
+	app.initMediaStorage()
+	storage, ok := app.mediaStorage.(*s3MediaStorage)
+	require.True(t, ok)
+
+	const fname = "sample.png"
+	loc, err := storage.save(fname, strings.NewReader("data"))
+	require.NoError(t, err)
+	assert.Equal(t, bucket.URL+"/media/blog/sample.png", loc)
+	assert.Equal(t, "data", bucket.objects["blog/sample.png"])
+	assert.Equal(t, "image/png", bucket.contentTypes["blog/sample.png"])
+
+	// Objects outside of the prefix or in subfolders are ignored
+	bucket.objects["other/file.png"] = "other"
+	bucket.objects["blog/nested/file.png"] = "nested"
+
+	files, err := storage.files()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, fname, files[0].Name)
+	assert.Equal(t, loc, files[0].Location)
+	assert.Equal(t, int64(4), files[0].Size)
+	assert.False(t, files[0].Time.IsZero())
+
+	require.NoError(t, storage.delete(fname))
+	assert.NotContains(t, bucket.objects, "blog/sample.png")
+
+	files, err = storage.files()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// Public URL
+	storage.mediaURL = "https://media.example"
+	assert.Equal(t, "https://media.example/blog/sample.png", storage.location(fname))
+
+	// Virtual-hosted-style addressing
+	storage.mediaURL = ""
+	storage.client.PathStyle = false
+	storage.client.Endpoint = "https://s3.example.com"
+	assert.Equal(t, "https://media.s3.example.com/blog/sample.png", storage.location(fname))
+
+	// Invalid credentials
+	storage.client.Endpoint = bucket.URL
+	storage.client.PathStyle = true
+	storage.client.AccessKey = "wrong"
+	_, err = storage.save(fname, strings.NewReader("data"))
+	assert.ErrorContains(t, err, "AccessDenied")
+}
+
+type testS3Server struct {
+	*httptest.Server
+	objects      map[string]string
+	contentTypes map[string]string
+}
+
+// startTestS3Server starts a minimal in-memory S3 stand-in for the bucket "media" using path-style addressing
+func startTestS3Server(t *testing.T) *testS3Server {
+	t.Helper()
+	ts := &testS3Server{objects: map[string]string{}, contentTypes: map[string]string{}}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+			return
+		}
+		key, ok := strings.CutPrefix(r.URL.Path, "/media/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchBucket</Code><Message>The bucket does not exist</Message></Error>`))
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			ts.objects[key] = string(data)
+			ts.contentTypes[key] = r.Header.Get("Content-Type")
+		case http.MethodDelete:
+			delete(ts.objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			assert.Equal(t, "2", r.URL.Query().Get("list-type"))
+			var result strings.Builder
+			result.WriteString(`<ListBucketResult><IsTruncated>false</IsTruncated>`)
+			for _, k := range slices.Sorted(maps.Keys(ts.objects)) {
+				if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+					fmt.Fprintf(&result, `<Contents><Key>%s</Key><Size>%d</Size><LastModified>%s</LastModified></Contents>`, k, len(ts.objects[k]), time.Now().UTC().Format(time.RFC3339))
+				}
+			}
+			result.WriteString(`</ListBucketResult>`)
+			_, _ = w.Write([]byte(result.String()))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
 func Test_isValidMediaFilename(t *testing.T) {
 	tests := []struct {
 		desc  string
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kovidgoyal/imaging"
+	"github.com/samber/lo"
+)
+
+var defaultResponsiveImageWidths = []int{480, 960, 1440}
+
+// The content column is at most 700px wide (minus padding), on smaller screens it's the full viewport
+const responsiveImageSizes = "(max-width: 720px) 100vw, 680px"
+
+// Modern formats are offered as picture sources in this order, the first supported one is used by the browser
+var mediaVariantSourceFormats = []string{"image/avif", "image/webp"}
+
+type mediaVariant struct {
+	location string
+	width    int
+	format   string
+}
+
+type mediaVariantEncoder struct {
+	format, ext string
+	encode      func(w io.Writer, img image.Image) error
+}
+
+func fallbackMediaVariantEncoder(fileExtension string) *mediaVariantEncoder {
+	if fileExtension == "png" {
+		return &mediaVariantEncoder{format: "image/png", ext: fileExtension, encode: func(w io.Writer, img image.Image) error {
+			return imaging.Encode(w, img, imaging.PNG, imaging.PNGCompressionLevel(png.BestCompression))
+		}}
+	}
+	return &mediaVariantEncoder{format: "image/jpeg", ext: fileExtension, encode: func(w io.Writer, img image.Image) error {
+		return imaging.Encode(w, img, imaging.JPEG, imaging.JPEGQuality(75))
+	}}
+}
+
+// There are no pure Go encoders for AVIF and WebP, so use the command line tools if they are installed
+func modernMediaVariantEncoders() (encoders []*mediaVariantEncoder) {
+	if _, err := exec.LookPath("avifenc"); err == nil {
+		encoders = append(encoders, externalMediaVariantEncoder("image/avif", "avif", "avifenc", func(in, out string) []string {
+			return []string{"--speed", "6", "-q", "60", in, out}
+		}))
+	}
+	if _, err := exec.LookPath("cwebp"); err == nil {
+		encoders = append(encoders, externalMediaVariantEncoder("image/webp", "webp", "cwebp", func(in, out string) []string {
+			return []string{"-quiet", "-q", "75", in, "-o", out}
+		}))
+	}
+	return encoders
+}
+
+func externalMediaVariantEncoder(format, ext, command string, args func(in, out string) []string) *mediaVariantEncoder {
+	return &mediaVariantEncoder{format: format, ext: ext, encode: func(w io.Writer, img image.Image) error {
+		dir, err := os.MkdirTemp("", "goblog-media-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		// Pass the image losslessly to the encoder
+		in, out := filepath.Join(dir, "in.png"), filepath.Join(dir, "out."+ext)
+		if err = imaging.Save(img, in, imaging.PNGCompressionLevel(png.BestSpeed)); err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		if output, err := exec.CommandContext(ctx, command, args(in, out)...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", command, err, strings.TrimSpace(string(output)))
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	}}
+}
+
+func (e *mediaVariantEncoder) upload(img image.Image, upload mediaStorageSaveFunc) (string, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(e.encode(pw, img))
+	}()
+	res, err := uploadCompressedFile(e.ext, pr, upload)
+	_ = pr.CloseWithError(err)
+	return res, err
+}
+
+// createVariants creates smaller and modern format versions of the compressed image, which itself is used as fallback
+func (lc *localMediaCompressor) createVariants(original string, img image.Image, fallback *mediaVariantEncoder, upload mediaStorageSaveFunc) error {
+	fullWidth := img.Bounds().Dx()
+	widths := lo.Filter(lc.widths, func(w int, _ int) bool { return w > 0 && w < fullWidth })
+	widths = append(lo.Uniq(widths), fullWidth)
+	slices.Sort(widths)
+	variants := []*mediaVariant{{location: original, width: fullWidth, format: fallback.format}}
+	for _, width := range widths {
+		resized := img
+		if width != fullWidth {
+			resized = imaging.Resize(img, width, 0, imaging.Lanczos)
+		}
+		for _, encoder := range append([]*mediaVariantEncoder{fallback}, lc.encoders...) {
+			if encoder == fallback && width == fullWidth {
+				// Already uploaded
+				continue
+			}
+			location, err := encoder.upload(resized, upload)
+			if err != nil {
+				return err
+			}
+			variants = append(variants, &mediaVariant{location: location, width: width, format: encoder.format})
+		}
+	}
+	if len(variants) == 1 {
+		return nil
+	}
+	return lc.a.db.saveMediaVariants(original, variants)
+}
+
+func (db *database) saveMediaVariants(original string, variants []*mediaVariant) error {
+	for _, v := range variants {
+		_, err := db.Exec(
+			"insert or replace into media_variants (original, location, width, format) values (@original, @location, @width, @format)",
+			sql.Named("original", original), sql.Named("location", v.location), sql.Named("width", v.width), sql.Named("format", v.format),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *database) getMediaVariants(original string) ([]*mediaVariant, error) {
+	rows, err := db.Query("select location, width, format from media_variants where original = @original order by width", sql.Named("original", original))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var variants []*mediaVariant
+	for rows.Next() {
+		v := &mediaVariant{}
+		if err = rows.Scan(&v.location, &v.width, &v.format); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+// mediaVariantsSrcset builds the srcset attribute value for the variants of a format
+func mediaVariantsSrcset(variants []*mediaVariant, format string) string {
+	variants = lo.Filter(variants, func(v *mediaVariant, _ int) bool { return v.format == format })
+	slices.SortFunc(variants, func(a, b *mediaVariant) int { return cmp.Compare(a.width, b.width) })
+	return strings.Join(lo.Map(variants, func(v *mediaVariant, _ int) string {
+		return fmt.Sprintf("%s %dw", v.location, v.width)
+	}), ", ")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mediaVariants(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	require.NoError(t, app.initConfig(false))
+
+	// Serve a test image
+	img := image.NewRGBA(image.Rect(0, 0, 1200, 800))
+	for x := range 1200 {
+		for y := range 800 {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 100, 255})
+		}
+	}
+	imgBuf := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(imgBuf, img, nil))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(imgBuf.Bytes())
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	uploaded := map[string][]byte{}
+	upload := func(filename string, f io.Reader) (string, error) {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return "", err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		uploaded[filename] = data
+		return "https://media.example.com/" + filename, nil
+	}
+
+	// Fake WebP encoder, the real one requires cwebp
+	fakeWebp := &mediaVariantEncoder{format: "image/webp", ext: "webp", encode: func(w io.Writer, img image.Image) error {
+		_, err := w.Write([]byte{byte(img.Bounds().Dx() / 10)})
+		return err
+	}}
+	lc := &localMediaCompressor{a: app, widths: []int{480, 960, 1440}, encoders: []*mediaVariantEncoder{fakeWebp}}
+
+	location, err := lc.compress(srv.URL+"/image.jpg", upload, srv.Client())
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(location, "https://media.example.com/"))
+	assert.True(t, strings.HasSuffix(location, ".jpg"))
+
+	variants, err := app.db.getMediaVariants(location)
+	require.NoError(t, err)
+	// JPEG at 480, 960 and 1200 (the compressed file), WebP at all three widths, 1440 is larger than the image
+	require.Len(t, variants, 6)
+	assert.Len(t, uploaded, 6)
+	for _, v := range variants {
+		assert.Contains(t, []int{480, 960, 1200}, v.width)
+		assert.Contains(t, []string{"image/jpeg", "image/webp"}, v.format)
+		data := uploaded[strings.TrimPrefix(v.location, "https://media.example.com/")]
+		require.NotEmpty(t, data, v.location)
+		if v.format == "image/jpeg" {
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+			require.NoError(t, err)
+			assert.Equal(t, v.width, cfg.Width)
+		}
+	}
+
+	t.Run("Render", func(t *testing.T) {
+		rendered, err := app.renderMarkdown("![Alt text]("+location+")", false)
+		require.NoError(t, err)
+		html := string(rendered)
+
+		assert.Contains(t, html, "<picture>")
+		assert.Regexp(t, regexp.MustCompile(`<source type="image/webp" srcset="https://media\.example\.com/\w+\.webp 480w, https://media\.example\.com/\w+\.webp 960w, https://media\.example\.com/\w+\.webp 1200w" sizes="[^"]+">`), html)
+		assert.Regexp(t, regexp.MustCompile(`<img src="`+regexp.QuoteMeta(location)+`" alt="Alt text" loading="lazy" width=1200 height=800 data-placeholder="[^"]+" srcset="[^"]+ 480w, [^"]+ 960w, `+regexp.QuoteMeta(location)+` 1200w" sizes="[^"]+">`), html)
+		assert.NotContains(t, html, "image/avif")
+		assert.Contains(t, html, "</picture></a>")
+
+		// Images without variants are rendered as before
+		rendered, err = app.renderMarkdown("![Alt text](https://example.org/other.jpg)", false)
+		require.NoError(t, err)
+		assert.NotContains(t, string(rendered), "<picture>")
+		assert.NotContains(t, string(rendered), "srcset")
+	})
+
+	t.Run("Small image", func(t *testing.T) {
+		lc := &localMediaCompressor{a: app, widths: []int{480}}
+		require.NoError(t, lc.createVariants("https://media.example.com/small.jpg", image.NewRGBA(image.Rect(0, 0, 300, 200)), fallbackMediaVariantEncoder("jpg"), upload))
+		// Nothing to offer besides the original
+		variants, err := app.db.getMediaVariants("https://media.example.com/small.jpg")
+		require.NoError(t, err)
+		assert.Empty(t, variants)
+	})
+}
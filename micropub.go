@@ -1,10 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"cmp"
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"image"
 	"io"
 	"maps"
 	"mime"
@@ -17,6 +19,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/kovidgoyal/imaging"
 	"github.com/samber/lo"
 	"github.com/spf13/cast"
 	"go.goblog.app/app/pkgs/bodylimit"
@@ -256,12 +259,6 @@ func (s *micropubImplementation) Undelete(urlStr string) error {
 }
 
 func (s *micropubImplementation) UploadMedia(file multipart.File, header *multipart.FileHeader) (string, error) {
-	// Generate sha256 hash for file
-	hash := sha256.New()
-	_, err := io.Copy(hash, file)
-	if err != nil {
-		return "", fmt.Errorf("%w: failed to get file hash", micropub.ErrBadRequest)
-	}
 	// Get file extension
 	fileExtension := filepath.Ext(header.Filename)
 	if fileExtension == "" {
@@ -274,17 +271,54 @@ func (s *micropubImplementation) UploadMedia(file multipart.File, header *multip
 			}
 		}
 	}
+	// Remove the EXIF metadata of photos, selected fields are saved separately
+	var content io.ReadSeeker = file
+	var metadata *mediaMetadata
+	var img image.Image
+	if lo.Contains(photoExtensions, strings.ToLower(fileExtension)) {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return "", fmt.Errorf("%w: failed to read multipart file", micropub.ErrBadRequest)
+		}
+		data, metadata, err = s.a.processPhotoMetadata(data)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", micropub.ErrBadRequest, err)
+		}
+		content = bytes.NewReader(data)
+		// Decode for the dimensions and the placeholder
+		if img, err = imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true)); err != nil {
+			s.a.debug("Failed to decode uploaded image", "err", err)
+		}
+	}
+	// Generate sha256 hash for file
+	hash := sha256.New()
+	_, err := io.Copy(hash, content)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to get file hash", micropub.ErrBadRequest)
+	}
+	// Reuse an identical file that was already uploaded
+	if existing, err := s.a.existingMediaLocation(fmt.Sprintf("%x", hash.Sum(nil))); err != nil {
+		s.a.error("Failed to check for identical media file", "err", err)
+	} else if existing != "" {
+		return existing, nil
+	}
 	// Generate the file name
 	fileName := fmt.Sprintf("%x%s", hash.Sum(nil), fileExtension)
 	// Save file
-	_, err = file.Seek(0, io.SeekStart)
+	_, err = content.Seek(0, io.SeekStart)
 	if err != nil {
 		return "", fmt.Errorf("%w: failed to read multipart file", micropub.ErrBadRequest)
 	}
-	location, err := s.a.saveMediaFile(fileName, file)
+	location, err := s.a.saveMediaFile(fileName, content)
 	if err != nil {
 		return "", fmt.Errorf("%w: failed to save original file", micropub.ErrBadRequest)
 	}
+	originalLocation := location
+	if img != nil {
+		if err = s.a.db.saveMediaDimensions(location, img); err != nil {
+			s.a.error("Failed to save image dimensions", "location", location, "err", err)
+		}
+	}
 	// Try to compress file (only when not in private mode)
 	if !s.a.isPrivate() {
 		compressedLocation, compressionErr := s.a.compressMediaFile(location)
@@ -296,6 +330,12 @@ func (s *micropubImplementation) UploadMedia(file multipart.File, header *multip
 			location = compressedLocation
 		}
 	}
+	// Save the metadata for the original and the compressed file
+	if metadata != nil {
+		if err = s.a.db.saveMediaMetadata(metadata, originalLocation, location); err != nil {
+			s.a.error("Failed to save photo metadata", "location", location, "err", err)
+		}
+	}
 	return location, nil
 }
 
@@ -55,6 +55,12 @@ func (a *goBlog) serveOpenSearch(w http.ResponseWriter, r *http.Request) {
 					Name: "q", Value: "{searchTerms}",
 				},
 			},
+			{
+				Type: contentTypeSuggestions, Template: sURL + searchSuggestionsPath + "?q={searchTerms}",
+			},
+			{
+				Type: contenttype.JSON, Template: sURL + searchApiPath + "?q={searchTerms}&page={startPage?}",
+			},
 			{
 				Type: "application/opensearchdescription+xml", Rel: "self",
 				Template: a.getFullAddress(openSearchUrl(b)),
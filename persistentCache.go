@@ -51,3 +51,8 @@ func (db *database) clearPersistentCacheContext(c context.Context, pattern strin
 	_, err := db.ExecContext(c, "delete from persistent_cache where key like @pattern", sql.Named("pattern", pattern))
 	return err
 }
+
+func (db *database) deletePersistentCacheContext(c context.Context, key string) error {
+	_, err := db.ExecContext(c, "delete from persistent_cache where key = @key", sql.Named("key", key))
+	return err
+}
@@ -242,6 +242,31 @@ func TestNoteMarshaling(t *testing.T) {
 	assert.Equal(t, "Hello, world!", unmarshaled.Content.First().String())
 }
 
+func TestImageAttachmentMarshaling(t *testing.T) {
+	image := ObjectNew(ImageType)
+	image.URL = IRI("https://example.com/image.jpg")
+	image.Width = 1200
+	image.Height = 800
+	image.Blurhash = "LEHV6nWB2yk8pyo0adR*.7kCMdnj"
+
+	data, err := json.Marshal(image)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"width":1200`)
+	assert.Contains(t, string(data), `"blurhash":"LEHV6nWB2yk8pyo0adR*.7kCMdnj"`)
+
+	var unmarshaled Object
+	require.NoError(t, json.Unmarshal(data, &unmarshaled))
+	assert.Equal(t, 1200, unmarshaled.Width)
+	assert.Equal(t, 800, unmarshaled.Height)
+	assert.Equal(t, image.Blurhash, unmarshaled.Blurhash)
+
+	// Omitted when not set
+	data, err = json.Marshal(ObjectNew(NoteType))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "width")
+	assert.NotContains(t, string(data), "blurhash")
+}
+
 func TestPersonMarshaling(t *testing.T) {
 	person := PersonNew(IRI("https://example.com/users/alice"))
 	person.Name = NaturalLanguageValues{{Lang: "en", Value: "Alice"}}
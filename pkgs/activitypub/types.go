@@ -169,6 +169,10 @@ type Object struct {
 	Attachment   any                   `json:"attachment,omitempty"`
 	Published    time.Time             `json:"published,omitzero"`
 	Updated      time.Time             `json:"updated,omitzero"`
+	// Image attachments, the blurhash is a Mastodon extension
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Blurhash string `json:"blurhash,omitempty"`
 }
 
 // GetLink returns the object's ID
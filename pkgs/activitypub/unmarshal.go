@@ -74,6 +74,9 @@ func (o *Object) UnmarshalJSON(data []byte) error {
 		Attachment   any                   `json:"attachment,omitempty"`
 		Published    time.Time             `json:"published,omitzero"`
 		Updated      time.Time             `json:"updated,omitzero"`
+		Width        int                   `json:"width,omitempty"`
+		Height       int                   `json:"height,omitempty"`
+		Blurhash     string                `json:"blurhash,omitempty"`
 	}
 	var r raw
 	if err := json.Unmarshal(data, &r); err != nil {
@@ -102,6 +105,9 @@ func (o *Object) UnmarshalJSON(data []byte) error {
 	o.Attachment = r.Attachment
 	o.Published = r.Published
 	o.Updated = r.Updated
+	o.Width = r.Width
+	o.Height = r.Height
+	o.Blurhash = r.Blurhash
 
 	if len(r.AttributedTo) > 0 {
 		item, err := UnmarshalJSON(r.AttributedTo)
@@ -0,0 +1,179 @@
+// Package blurhash encodes images to compact BlurHash placeholders and decodes them again.
+// See https://github.com/woltapp/blurhash for the algorithm.
+package blurhash
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+var (
+	ErrComponents = errors.New("blurhash: components must be between 1 and 9")
+	ErrInvalid    = errors.New("blurhash: invalid hash")
+)
+
+const characters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Encode calculates the hash of the image using the given number of horizontal and vertical components.
+// Images should be downscaled before, as every pixel is used.
+func Encode(img image.Image, xComponents, yComponents int) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", ErrComponents
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return "", errors.New("blurhash: empty image")
+	}
+	// Convert to linear RGB once
+	linear := make([][3]float64, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			linear[y*width+x] = [3]float64{sRGBToLinear(r >> 8), sRGBToLinear(g >> 8), sRGBToLinear(b >> 8)}
+		}
+	}
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := range yComponents {
+		for i := range xComponents {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for y := range height {
+				basisY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+				for x := range width {
+					basis := normalisation * math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) * basisY
+					for c := range 3 {
+						factor[c] += basis * linear[y*width+x][c]
+					}
+				}
+			}
+			scale := 1 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+	b := &strings.Builder{}
+	encode83(b, (xComponents-1)+(yComponents-1)*9, 1)
+	// Quantised maximum value of the AC components
+	maximumValue := 1.0
+	if ac := factors[1:]; len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, f := range ac {
+			actualMaximum = max(actualMaximum, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantisedMaximum := max(0, min(82, int(math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		encode83(b, quantisedMaximum, 1)
+	} else {
+		encode83(b, 0, 1)
+	}
+	// DC component
+	dc := factors[0]
+	encode83(b, linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4)
+	// AC components
+	for _, f := range factors[1:] {
+		quant := func(v float64) int {
+			return max(0, min(18, int(math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		encode83(b, quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2)
+	}
+	return b.String(), nil
+}
+
+// Decode creates an image with the given size from the hash
+func Decode(hash string, width, height int) (image.Image, error) {
+	if len(hash) < 6 || width < 1 || height < 1 {
+		return nil, ErrInvalid
+	}
+	sizeFlag, err := decode83(hash[0:1])
+	if err != nil {
+		return nil, err
+	}
+	xComponents, yComponents := sizeFlag%9+1, sizeFlag/9+1
+	if len(hash) != 4+2*xComponents*yComponents {
+		return nil, ErrInvalid
+	}
+	quantisedMaximum, err := decode83(hash[1:2])
+	if err != nil {
+		return nil, err
+	}
+	maximumValue := float64(quantisedMaximum+1) / 166
+	colors := make([][3]float64, xComponents*yComponents)
+	dc, err := decode83(hash[2:6])
+	if err != nil {
+		return nil, err
+	}
+	colors[0] = [3]float64{sRGBToLinear(uint32(dc >> 16)), sRGBToLinear(uint32(dc >> 8 & 255)), sRGBToLinear(uint32(dc & 255))}
+	for i := 1; i < len(colors); i++ {
+		ac, err := decode83(hash[4+i*2 : 6+i*2])
+		if err != nil {
+			return nil, err
+		}
+		unquant := func(q int) float64 {
+			return signPow((float64(q)-9)/9, 2) * maximumValue
+		}
+		colors[i] = [3]float64{unquant(ac / (19 * 19)), unquant(ac / 19 % 19), unquant(ac % 19)}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			var pixel [3]float64
+			for j := range yComponents {
+				basisY := math.Cos(math.Pi * float64(y) * float64(j) / float64(height))
+				for i := range xComponents {
+					basis := math.Cos(math.Pi*float64(x)*float64(i)/float64(width)) * basisY
+					c := colors[i+j*xComponents]
+					for k := range 3 {
+						pixel[k] += c[k] * basis
+					}
+				}
+			}
+			img.SetNRGBA(x, y, color.NRGBA{uint8(linearToSRGB(pixel[0])), uint8(linearToSRGB(pixel[1])), uint8(linearToSRGB(pixel[2])), 255})
+		}
+	}
+	return img, nil
+}
+
+func encode83(b *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := value / int(math.Pow(83, float64(length-i))) % 83
+		b.WriteByte(characters[digit])
+	}
+}
+
+func decode83(s string) (int, error) {
+	value := 0
+	for _, c := range s {
+		digit := strings.IndexRune(characters, c)
+		if digit < 0 {
+			return 0, ErrInvalid
+		}
+		value = value*83 + digit
+	}
+	return value, nil
+}
+
+func sRGBToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := max(0, min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,61 @@
+package blurhash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 12, 8))
+	for x := range 12 {
+		for y := range 8 {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 20), uint8(y * 30), uint8((x + y) * 10), 255})
+		}
+	}
+	hash, err := Encode(img, 4, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "LiFP4p2+sTt6u.R-jujGf5fRfRfQ", hash)
+
+	_, err = Encode(img, 0, 3)
+	assert.ErrorIs(t, err, ErrComponents)
+	_, err = Encode(image.NewNRGBA(image.Rect(0, 0, 0, 0)), 4, 3)
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	// Left half black, right half white
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for x := range 32 {
+		for y := range 32 {
+			if x >= 16 {
+				img.SetNRGBA(x, y, color.NRGBA{255, 255, 255, 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	hash, err := Encode(img, 4, 4)
+	require.NoError(t, err)
+
+	decoded, err := Decode(hash, 8, 8)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 8, 8), decoded.Bounds())
+	left := color.NRGBAModel.Convert(decoded.At(0, 4)).(color.NRGBA)
+	right := color.NRGBAModel.Convert(decoded.At(7, 4)).(color.NRGBA)
+	assert.Less(t, left.R, uint8(128))
+	assert.Greater(t, right.R, uint8(128))
+
+	// Known hash from the reference implementation
+	decoded, err = Decode("LEHV6nWB2yk8pyo0adR*.7kCMdnj", 4, 3)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 3), decoded.Bounds())
+
+	for _, invalid := range []string{"", "LEHV6", "LEHV6nWB2yk8pyo0adR*.7kCMdn", "LEHV6nWB2yk8pyo0adR*.7kCMdn\""} {
+		_, err = Decode(invalid, 4, 3)
+		assert.ErrorIs(t, err, ErrInvalid, invalid)
+	}
+}
@@ -0,0 +1,374 @@
+// Package imagemeta reads and removes the EXIF metadata of JPEG, PNG and WebP images.
+package imagemeta
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+var (
+	ErrUnsupported = errors.New("imagemeta: unsupported image format")
+	ErrInvalid     = errors.New("imagemeta: invalid image")
+)
+
+var (
+	jpegSignature  = []byte{0xff, 0xd8}
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+	riffSignature  = []byte("RIFF")
+	webpSignature  = []byte("WEBP")
+	exifHeader     = []byte("Exif\x00\x00")
+	tiffSignatures = [][]byte{[]byte("II*\x00"), []byte("MM\x00*")}
+	// Brands of HEIF and AVIF images
+	heifBrands = []string{"heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1", "avif", "avis"}
+)
+
+const (
+	// Flags of the WebP VP8X chunk
+	webpExifFlag = 0x08
+	webpXmpFlag  = 0x04
+)
+
+// Metadata contains the selected EXIF fields of a photo
+type Metadata struct {
+	Camera      string // Make and model
+	Lens        string
+	Exposure    string // Exposure time, aperture, ISO and focal length
+	Taken       time.Time
+	HasLocation bool
+	Latitude    float64
+	Longitude   float64
+	Orientation int
+}
+
+// Read extracts the metadata of a JPEG, PNG or WebP image, it returns nil if the image has no EXIF data
+func Read(data []byte) (*Metadata, error) {
+	var raw []byte
+	switch {
+	case bytes.HasPrefix(data, jpegSignature):
+		err := walkJpeg(data, func(marker byte, segment []byte) bool {
+			if marker == 0xe1 && bytes.HasPrefix(segment, exifHeader) {
+				raw = segment[len(exifHeader):]
+				return false
+			}
+			return true
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+	case bytes.HasPrefix(data, pngSignature):
+		err := walkPng(data, func(typ string, chunk, _ []byte) bool {
+			if typ == "eXIf" {
+				raw = bytes.TrimPrefix(chunk, exifHeader)
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	case isWebp(data):
+		err := walkWebp(data, func(typ string, chunk, _ []byte) bool {
+			if typ == "EXIF" {
+				raw = bytes.TrimPrefix(chunk, exifHeader)
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupported
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	x, err := exif.Decode(bytes.NewReader(raw))
+	if err != nil && (x == nil || exif.IsCriticalError(err)) {
+		return nil, err
+	}
+	return fromExif(x), nil
+}
+
+func fromExif(x *exif.Exif) *Metadata {
+	m := &Metadata{}
+	str := func(name exif.FieldName) string {
+		if tag, err := x.Get(name); err == nil {
+			if s, err := tag.StringVal(); err == nil {
+				return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+			}
+		}
+		return ""
+	}
+	rat := func(name exif.FieldName) *big.Rat {
+		if tag, err := x.Get(name); err == nil {
+			if r, err := tag.Rat(0); err == nil && r.Sign() > 0 {
+				return r
+			}
+		}
+		return nil
+	}
+	// Camera, the model often already contains the make
+	cameraMake, cameraModel := str(exif.Make), str(exif.Model)
+	if cameraModel != "" && cameraMake != "" && !strings.HasPrefix(strings.ToLower(cameraModel), strings.ToLower(strings.Fields(cameraMake)[0])) {
+		m.Camera = cameraMake + " " + cameraModel
+	} else {
+		m.Camera = cmp.Or(cameraModel, cameraMake)
+	}
+	m.Lens = str(exif.LensModel)
+	// Exposure
+	var exposure []string
+	if r := rat(exif.ExposureTime); r != nil {
+		if f, _ := r.Float64(); f < 1 {
+			exposure = append(exposure, fmt.Sprintf("1/%.0f s", 1/f))
+		} else {
+			exposure = append(exposure, fmt.Sprintf("%s s", r.FloatString(1)))
+		}
+	}
+	if r := rat(exif.FNumber); r != nil {
+		exposure = append(exposure, "f/"+strings.TrimSuffix(r.FloatString(1), ".0"))
+	}
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil && iso > 0 {
+			exposure = append(exposure, fmt.Sprintf("ISO %d", iso))
+		}
+	}
+	if r := rat(exif.FocalLength); r != nil {
+		exposure = append(exposure, strings.TrimSuffix(r.FloatString(1), ".0")+" mm")
+	}
+	m.Exposure = strings.Join(exposure, " · ")
+	// Date, EXIF doesn't contain a time zone
+	if tag, err := x.Get(exif.DateTimeOriginal); err == nil && tag.Format() == tiff.StringVal {
+		m.Taken, _ = time.Parse("2006:01:02 15:04:05", strings.TrimRight(string(tag.Val), "\x00"))
+	}
+	// Location
+	if lat, lon, err := x.LatLong(); err == nil && (lat != 0 || lon != 0) {
+		m.HasLocation, m.Latitude, m.Longitude = true, lat, lon
+	}
+	// Orientation
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		m.Orientation, _ = tag.Int(0)
+	}
+	return m
+}
+
+// Strip removes the EXIF, XMP and IPTC metadata of a JPEG image, the EXIF and text chunks of a PNG image
+// or the EXIF and XMP chunks of a WebP image.
+// The orientation of JPEG images is kept, so they are still displayed correctly.
+// TIFF, HEIF and AVIF images usually contain EXIF data too, but it can't be removed, so they return ErrUnsupported.
+// Other formats are returned unchanged.
+func Strip(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, jpegSignature):
+		return stripJpeg(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPng(data)
+	case isWebp(data):
+		return stripWebp(data)
+	case isTiff(data), isHeif(data):
+		return nil, ErrUnsupported
+	default:
+		return data, nil
+	}
+}
+
+func stripJpeg(data []byte) ([]byte, error) {
+	orientation := 0
+	if m, err := Read(data); err == nil && m != nil {
+		orientation = m.Orientation
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(jpegSignature)
+	inserted := false
+	insertOrientation := func() {
+		if !inserted && orientation > 1 && orientation <= 8 {
+			out.Write(orientationSegment(orientation))
+		}
+		inserted = true
+	}
+	err := walkJpeg(data, func(marker byte, segment []byte) bool {
+		// The JFIF segment has to stay first
+		if marker != 0xe0 {
+			insertOrientation()
+		}
+		switch marker {
+		case 0xe1, 0xed, 0xfe:
+			// APP1 (EXIF and XMP), APP13 (IPTC) and comments
+		default:
+			out.Write([]byte{0xff, marker})
+			_ = binary.Write(out, binary.BigEndian, uint16(len(segment)+2))
+			out.Write(segment)
+		}
+		return true
+	}, func(rest []byte) {
+		insertOrientation()
+		out.Write(rest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// orientationSegment creates an APP1 segment with EXIF data that only contains the orientation
+func orientationSegment(orientation int) []byte {
+	b := &bytes.Buffer{}
+	b.Write([]byte{0xff, 0xe1})
+	_ = binary.Write(b, binary.BigEndian, uint16(2+len(exifHeader)+8+2+12+4))
+	b.Write(exifHeader)
+	// TIFF header, big endian, first IFD at offset 8
+	b.Write([]byte{'M', 'M', 0x00, 0x2a, 0x00, 0x00, 0x00, 0x08})
+	// IFD with one entry: tag, type SHORT, count, value (padded), no next IFD
+	_ = binary.Write(b, binary.BigEndian, []uint16{1, 0x0112, 3})
+	_ = binary.Write(b, binary.BigEndian, uint32(1))
+	_ = binary.Write(b, binary.BigEndian, []uint16{uint16(orientation), 0})
+	_ = binary.Write(b, binary.BigEndian, uint32(0))
+	return b.Bytes()
+}
+
+// walkJpeg calls segment for each marker segment before the image data until it returns false,
+// then rest is called with the remaining data starting at the start of scan marker
+func walkJpeg(data []byte, segment func(marker byte, segment []byte) bool, rest func(rest []byte)) error {
+	pos := len(jpegSignature)
+	for {
+		if pos+4 > len(data) || data[pos] != 0xff {
+			return ErrInvalid
+		}
+		marker := data[pos+1]
+		if marker == 0xff {
+			// Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xda {
+			// Start of scan, no more metadata
+			if rest != nil {
+				rest(data[pos:])
+			}
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return ErrInvalid
+		}
+		if !segment(marker, data[pos+4:pos+2+length]) {
+			return nil
+		}
+		pos += 2 + length
+	}
+}
+
+func stripPng(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	err := walkPng(data, func(typ string, _, raw []byte) bool {
+		switch typ {
+		case "eXIf", "tEXt", "zTXt", "iTXt":
+			// Metadata
+		default:
+			out.Write(raw)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// walkPng calls chunk with the data and the raw bytes (including length, type and checksum) of each chunk
+// until it returns false or the end of the image is reached
+func walkPng(data []byte, chunk func(typ string, chunk, raw []byte) bool) error {
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return ErrInvalid
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		if length < 0 || pos+12+length > len(data) {
+			return ErrInvalid
+		}
+		typ := string(data[pos+4 : pos+8])
+		if !chunk(typ, data[pos+8:pos+8+length], data[pos:pos+12+length]) || typ == "IEND" {
+			return nil
+		}
+		pos += 12 + length
+	}
+	return nil
+}
+
+func isWebp(data []byte) bool {
+	return len(data) >= 12 && bytes.HasPrefix(data, riffSignature) && bytes.Equal(data[8:12], webpSignature)
+}
+
+func isTiff(data []byte) bool {
+	return slices.ContainsFunc(tiffSignatures, func(signature []byte) bool {
+		return bytes.HasPrefix(data, signature)
+	})
+}
+
+// isHeif checks the major brand of the ISO base media file
+func isHeif(data []byte) bool {
+	return len(data) >= 12 && string(data[4:8]) == "ftyp" && slices.Contains(heifBrands, string(data[8:12]))
+}
+
+func stripWebp(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	err := walkWebp(data, func(typ string, chunk, raw []byte) bool {
+		switch typ {
+		case "EXIF", "XMP ":
+			// Metadata
+		case "VP8X":
+			// Remove the flags of the removed chunks
+			if len(chunk) > 0 {
+				raw = slices.Clone(raw)
+				raw[8] &^= webpExifFlag | webpXmpFlag
+			}
+			out.Write(raw)
+		default:
+			out.Write(raw)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Update the file size in the RIFF header
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped, nil
+}
+
+// walkWebp calls chunk with the data and the raw bytes (including header and padding) of each chunk
+// until it returns false or the end of the image is reached
+func walkWebp(data []byte, chunk func(typ string, chunk, raw []byte) bool) error {
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return ErrInvalid
+		}
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			return ErrInvalid
+		}
+		// Chunks are padded to an even size
+		rawEnd := min(end+length%2, len(data))
+		if !chunk(string(data[pos:pos+4]), data[pos+8:end], data[pos:rawEnd]) {
+			return nil
+		}
+		pos = rawEnd
+	}
+	return nil
+}
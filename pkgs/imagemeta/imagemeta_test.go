@@ -0,0 +1,227 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+func ascii(tag uint16, s string) testEntry {
+	return testEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func short(tag uint16, v uint16) testEntry {
+	return testEntry{tag, 3, 1, binary.BigEndian.AppendUint16(nil, v)}
+}
+
+func long(tag uint16, v uint32) testEntry {
+	return testEntry{tag, 4, 1, binary.BigEndian.AppendUint32(nil, v)}
+}
+
+func rational(tag uint16, values ...uint32) testEntry {
+	var data []byte
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint32(data, v)
+	}
+	return testEntry{tag, 5, uint32(len(values) / 2), data}
+}
+
+// ifd encodes an IFD starting at the offset, values that don't fit in the entry are placed after it
+func ifd(offset uint32, entries []testEntry) []byte {
+	table := binary.BigEndian.AppendUint16(nil, uint16(len(entries)))
+	var extra []byte
+	extraOffset := offset + 2 + 12*uint32(len(entries)) + 4
+	for _, e := range entries {
+		table = binary.BigEndian.AppendUint16(table, e.tag)
+		table = binary.BigEndian.AppendUint16(table, e.typ)
+		table = binary.BigEndian.AppendUint32(table, e.count)
+		if len(e.data) <= 4 {
+			table = append(table, append(e.data, make([]byte, 4-len(e.data))...)...)
+		} else {
+			table = binary.BigEndian.AppendUint32(table, extraOffset+uint32(len(extra)))
+			extra = append(extra, e.data...)
+		}
+	}
+	table = binary.BigEndian.AppendUint32(table, 0)
+	return append(table, extra...)
+}
+
+func testExif() []byte {
+	ifd0 := func(exifOffset, gpsOffset uint32) []testEntry {
+		return []testEntry{
+			ascii(0x010f, "FUJIFILM"),
+			ascii(0x0110, "X100V"),
+			short(0x0112, 6),
+			long(0x8769, exifOffset),
+			long(0x8825, gpsOffset),
+		}
+	}
+	exifStart := 8 + uint32(len(ifd(8, ifd0(0, 0))))
+	exifIfd := ifd(exifStart, []testEntry{
+		rational(0x829a, 1, 250),
+		rational(0x829d, 28, 10),
+		short(0x8827, 400),
+		ascii(0x9003, "2024:05:01 14:03:12"),
+		rational(0x920a, 23, 1),
+		ascii(0xa434, "23mm F2"),
+	})
+	gpsStart := exifStart + uint32(len(exifIfd))
+	gpsIfd := ifd(gpsStart, []testEntry{
+		ascii(0x0001, "N"),
+		rational(0x0002, 52, 1, 30, 1, 0, 1),
+		ascii(0x0003, "E"),
+		rational(0x0004, 13, 1, 24, 1, 0, 1),
+	})
+	tiff := append([]byte("MM\x00\x2a\x00\x00\x00\x08"), ifd(8, ifd0(exifStart, gpsStart))...)
+	tiff = append(tiff, exifIfd...)
+	return append(tiff, gpsIfd...)
+}
+
+func testJpeg(t *testing.T) []byte {
+	img := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(img, image.NewRGBA(image.Rect(0, 0, 10, 20)), nil))
+	segment := append([]byte("Exif\x00\x00"), testExif()...)
+	out := &bytes.Buffer{}
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xff, 0xe1})
+	_ = binary.Write(out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	// Comment
+	out.Write([]byte{0xff, 0xfe, 0x00, 0x06})
+	out.WriteString("test")
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	m, err := Read(testJpeg(t))
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, "FUJIFILM X100V", m.Camera)
+	assert.Equal(t, "23mm F2", m.Lens)
+	assert.Equal(t, "1/250 s · f/2.8 · ISO 400 · 23 mm", m.Exposure)
+	assert.Equal(t, time.Date(2024, 5, 1, 14, 3, 12, 0, time.UTC), m.Taken)
+	assert.True(t, m.HasLocation)
+	assert.InDelta(t, 52.5, m.Latitude, 0.0001)
+	assert.InDelta(t, 13.4, m.Longitude, 0.0001)
+	assert.Equal(t, 6, m.Orientation)
+
+	// No EXIF data
+	img := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(img, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil))
+	m, err = Read(img.Bytes())
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	_, err = Read([]byte("GIF89a"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestStripJpeg(t *testing.T) {
+	data := testJpeg(t)
+	stripped, err := Strip(data)
+	require.NoError(t, err)
+	assert.Less(t, len(stripped), len(data))
+	assert.NotContains(t, string(stripped), "FUJIFILM")
+	assert.NotContains(t, string(stripped), "test")
+
+	// Orientation is kept
+	m, err := Read(stripped)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, 6, m.Orientation)
+	assert.False(t, m.HasLocation)
+	assert.Empty(t, m.Camera)
+
+	// Still a valid image
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	assert.Equal(t, 10, img.Bounds().Dx())
+
+	_, err = Strip(data[:20])
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestStripPng(t *testing.T) {
+	img := &bytes.Buffer{}
+	require.NoError(t, png.Encode(img, image.NewRGBA(image.Rect(0, 0, 10, 10))))
+	// Insert an eXIf chunk after the header chunk
+	exifData := testExif()
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(exifData)))
+	chunk = append(chunk, "eXIf"...)
+	chunk = append(chunk, exifData...)
+	chunk = append(chunk, 0, 0, 0, 0)
+	headerEnd := 8 + 12 + 13
+	data := append(append(append([]byte{}, img.Bytes()[:headerEnd]...), chunk...), img.Bytes()[headerEnd:]...)
+
+	m, err := Read(data)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.True(t, m.HasLocation)
+
+	stripped, err := Strip(data)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bytes(), stripped)
+
+	// Other formats are unchanged
+	stripped, err = Strip([]byte("GIF89a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("GIF89a"), stripped)
+}
+
+func TestStripWebp(t *testing.T) {
+	chunk := func(typ string, data []byte) []byte {
+		c := append([]byte(typ), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	webp := func(chunks ...[]byte) []byte {
+		body := append([]byte("WEBP"), bytes.Join(chunks, nil)...)
+		return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+	}
+	canvas := []byte{0, 0, 0, 9, 0, 0, 9, 0, 0}
+	bitstream := chunk("VP8L", []byte("image"))
+
+	data := webp(chunk("VP8X", append([]byte{webpExifFlag | webpXmpFlag | 0x10}, canvas...)), chunk("EXIF", testExif()), chunk("XMP ", []byte("<x:xmpmeta/>")), bitstream)
+	m, err := Read(data)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.True(t, m.HasLocation)
+
+	stripped, err := Strip(data)
+	require.NoError(t, err)
+	// Only the alpha flag is kept
+	assert.Equal(t, webp(chunk("VP8X", append([]byte{0x10}, canvas...)), bitstream), stripped)
+
+	_, err = Strip(data[:40])
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestStripUnsupported(t *testing.T) {
+	// Formats that usually contain EXIF data
+	for _, data := range [][]byte{
+		[]byte("II*\x00\x08\x00\x00\x00"),
+		[]byte("MM\x00*\x00\x00\x00\x08"),
+		[]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"),
+		[]byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00"),
+	} {
+		_, err := Strip(data)
+		assert.ErrorIs(t, err, ErrUnsupported)
+	}
+}
@@ -0,0 +1,104 @@
+// This package contains code to mock an SFTP server with public key authentication and test file uploads.
+
+package mocksftp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Start a mock SFTP server on a random port that serves the files of the root directory
+//
+// Returns:
+// address: the host and port the server is listening on,
+// hostKey: the public key of the server,
+// cancelFunc: function to stop the server,
+// err: something went wrong
+func StartMockSFTPServer(root, user string, authorizedKey ssh.PublicKey) (address string, hostKey ssh.PublicKey, cancelFunc func(), err error) {
+
+	// Generate host key
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// Only allow the authorized key
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == user && bytes.Equal(key.Marshal(), authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(signer)
+
+	// Start server on random port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConnection(conn, config, root)
+		}
+	}()
+
+	// Create cancel function
+	cancelFunc = func() {
+		_ = listener.Close()
+	}
+
+	return listener.Addr().String(), signer.PublicKey(), cancelFunc, nil
+
+}
+
+func serveConnection(conn net.Conn, config *ssh.ServerConfig, root string) {
+	defer conn.Close()
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer channel.Close()
+			for req := range channelRequests {
+				// The payload is the length prefixed subsystem name
+				isSftp := req.Type == "subsystem" && string(req.Payload) == "\x00\x00\x00\x04sftp"
+				_ = req.Reply(isSftp, nil)
+				if isSftp {
+					go func() {
+						defer channel.Close()
+						server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(root))
+						if err != nil {
+							return
+						}
+						_ = server.Serve()
+					}()
+				}
+			}
+		}()
+	}
+}
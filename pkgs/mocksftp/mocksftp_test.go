@@ -0,0 +1,61 @@
+package mocksftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func Test_mocksftp(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+
+	// Start mock SFTP server
+	root := t.TempDir()
+	address, hostKey, cancel, err := StartMockSFTPServer(root, "user", signer.PublicKey())
+	require.NoError(t, err)
+	defer cancel()
+
+	// Upload file
+	conn, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            "user",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	client, err := sftp.NewClient(conn)
+	require.NoError(t, err)
+	defer client.Close()
+	file, err := client.Create("test.txt")
+	require.NoError(t, err)
+	_, err = file.ReadFrom(strings.NewReader("Test"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(filepath.Join(root, "test.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Test", string(data))
+
+	// Other keys are rejected
+	_, otherPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherPrivate)
+	require.NoError(t, err)
+	_, err = ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            "user",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(otherSigner)},
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	})
+	assert.Error(t, err)
+}
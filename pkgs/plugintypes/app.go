@@ -35,6 +35,8 @@ type App interface {
 	IsLoggedIn(req *http.Request) bool
 	// Get the full address of a path (including the domain)
 	GetFullAddress(path string) string
+	// Get posts related to the post with the given path (same blog, public visibility), count <= 0 uses the default
+	GetRelatedPosts(path string, count int) ([]Post, error)
 }
 
 // Database is used to provide access to GoBlog's database.
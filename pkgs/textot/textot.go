@@ -0,0 +1,296 @@
+// Package textot implements operational transformation for plain text.
+// Operations use the JSON format of ot.js: positive integers retain, negative integers delete and strings insert characters.
+// Lengths are counted in UTF-16 code units, like string lengths and textarea selections in JavaScript.
+package textot
+
+import (
+	"encoding/json"
+	"errors"
+	"unicode/utf16"
+)
+
+var (
+	ErrBaseLength = errors.New("textot: operation base length doesn't match")
+	ErrInvalid    = errors.New("textot: invalid operation")
+)
+
+// A single component of an operation, only one of the fields is set
+type component struct {
+	retain, delete int
+	insert         []uint16
+}
+
+// Operation is a sequence of retain, insert and delete components that transforms a document
+type Operation struct {
+	components []*component
+	baseLen    int
+	targetLen  int
+}
+
+// Length of the document the operation can be applied to
+func (o *Operation) BaseLen() int {
+	return o.baseLen
+}
+
+// Length of the document after applying the operation
+func (o *Operation) TargetLen() int {
+	return o.targetLen
+}
+
+// Checks if the operation doesn't change the document
+func (o *Operation) IsNoop() bool {
+	return len(o.components) == 0 || (len(o.components) == 1 && o.components[0].retain > 0)
+}
+
+// Retain skips n characters
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.baseLen += n
+	o.targetLen += n
+	if last := o.last(0); last != nil && last.retain > 0 {
+		last.retain += n
+	} else {
+		o.components = append(o.components, &component{retain: n})
+	}
+	return o
+}
+
+// Insert inserts the string at the current position
+func (o *Operation) Insert(s string) *Operation {
+	return o.insert(utf16.Encode([]rune(s)))
+}
+
+func (o *Operation) insert(s []uint16) *Operation {
+	if len(s) == 0 {
+		return o
+	}
+	o.targetLen += len(s)
+	last := o.last(0)
+	switch {
+	case last != nil && last.insert != nil:
+		last.insert = append(last.insert, s...)
+	case last != nil && last.delete > 0:
+		// Keep inserts before deletes, so equal operations have the same components
+		if beforeLast := o.last(1); beforeLast != nil && beforeLast.insert != nil {
+			beforeLast.insert = append(beforeLast.insert, s...)
+		} else {
+			o.components = append(o.components[:len(o.components)-1], &component{insert: s}, last)
+		}
+	default:
+		o.components = append(o.components, &component{insert: s})
+	}
+	return o
+}
+
+// Delete removes n characters
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+	o.baseLen += n
+	if last := o.last(0); last != nil && last.delete > 0 {
+		last.delete += n
+	} else {
+		o.components = append(o.components, &component{delete: n})
+	}
+	return o
+}
+
+func (o *Operation) last(offset int) *component {
+	if i := len(o.components) - 1 - offset; i >= 0 {
+		return o.components[i]
+	}
+	return nil
+}
+
+// Apply applies the operation to the document
+func (o *Operation) Apply(doc string) (string, error) {
+	in := utf16.Encode([]rune(doc))
+	if len(in) != o.baseLen {
+		return "", ErrBaseLength
+	}
+	out := make([]uint16, 0, o.targetLen)
+	pos := 0
+	for _, c := range o.components {
+		switch {
+		case c.retain > 0:
+			out = append(out, in[pos:pos+c.retain]...)
+			pos += c.retain
+		case c.delete > 0:
+			pos += c.delete
+		default:
+			out = append(out, c.insert...)
+		}
+	}
+	return string(utf16.Decode(out)), nil
+}
+
+// TransformIndex returns the new position of the index in the document after applying the operation, e.g. for cursors
+func (o *Operation) TransformIndex(index int) int {
+	newIndex, pos := index, 0
+	for _, c := range o.components {
+		if pos > index {
+			break
+		}
+		switch {
+		case c.retain > 0:
+			pos += c.retain
+		case c.delete > 0:
+			newIndex -= min(c.delete, index-pos)
+			pos += c.delete
+		default:
+			newIndex += len(c.insert)
+		}
+	}
+	return newIndex
+}
+
+// Transform transforms two concurrent operations a and b on the same document, so that
+// applying a and then b' results in the same document as applying b and then a'.
+// Inserts of a at the same position are placed before inserts of b.
+func Transform(a, b *Operation) (aPrime, bPrime *Operation, err error) {
+	if a.baseLen != b.baseLen {
+		return nil, nil, ErrBaseLength
+	}
+	aPrime, bPrime = &Operation{}, &Operation{}
+	as, bs := a.copyComponents(), b.copyComponents()
+	var ac, bc *component
+	next := func(cs *[]*component) *component {
+		if len(*cs) == 0 {
+			return nil
+		}
+		c := (*cs)[0]
+		*cs = (*cs)[1:]
+		return c
+	}
+	ac, bc = next(&as), next(&bs)
+	for ac != nil || bc != nil {
+		if ac != nil && ac.insert != nil {
+			aPrime.insert(ac.insert)
+			bPrime.Retain(len(ac.insert))
+			ac = next(&as)
+			continue
+		}
+		if bc != nil && bc.insert != nil {
+			aPrime.Retain(len(bc.insert))
+			bPrime.insert(bc.insert)
+			bc = next(&bs)
+			continue
+		}
+		if ac == nil || bc == nil {
+			return nil, nil, ErrInvalid
+		}
+		switch {
+		case ac.retain > 0 && bc.retain > 0:
+			n := min(ac.retain, bc.retain)
+			aPrime.Retain(n)
+			bPrime.Retain(n)
+			ac.retain -= n
+			bc.retain -= n
+		case ac.delete > 0 && bc.delete > 0:
+			// Both delete the same characters
+			n := min(ac.delete, bc.delete)
+			ac.delete -= n
+			bc.delete -= n
+		case ac.delete > 0 && bc.retain > 0:
+			n := min(ac.delete, bc.retain)
+			aPrime.Delete(n)
+			ac.delete -= n
+			bc.retain -= n
+		case ac.retain > 0 && bc.delete > 0:
+			n := min(ac.retain, bc.delete)
+			bPrime.Delete(n)
+			ac.retain -= n
+			bc.delete -= n
+		}
+		if ac.retain == 0 && ac.delete == 0 {
+			ac = next(&as)
+		}
+		if bc.retain == 0 && bc.delete == 0 {
+			bc = next(&bs)
+		}
+	}
+	return aPrime, bPrime, nil
+}
+
+func (o *Operation) copyComponents() []*component {
+	cs := make([]*component, len(o.components))
+	for i, c := range o.components {
+		cc := *c
+		cs[i] = &cc
+	}
+	return cs
+}
+
+// Diff creates an operation that replaces the changed part between the common prefix and suffix of the documents
+func Diff(from, to string) *Operation {
+	f, t := utf16.Encode([]rune(from)), utf16.Encode([]rune(to))
+	prefix := 0
+	for prefix < len(f) && prefix < len(t) && f[prefix] == t[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(f)-prefix && suffix < len(t)-prefix && f[len(f)-1-suffix] == t[len(t)-1-suffix] {
+		suffix++
+	}
+	// Don't split surrogate pairs
+	if prefix > 0 && utf16.IsSurrogate(rune(f[prefix-1])) && f[prefix-1] < 0xdc00 {
+		prefix--
+	}
+	if suffix > 0 && utf16.IsSurrogate(rune(f[len(f)-suffix])) && f[len(f)-suffix] >= 0xdc00 {
+		suffix--
+	}
+	o := &Operation{}
+	o.Retain(prefix)
+	o.insert(t[prefix : len(t)-suffix])
+	o.Delete(len(f) - prefix - suffix)
+	o.Retain(suffix)
+	return o
+}
+
+// MarshalJSON encodes the operation in the ot.js format
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	values := make([]any, 0, len(o.components))
+	for _, c := range o.components {
+		switch {
+		case c.retain > 0:
+			values = append(values, c.retain)
+		case c.delete > 0:
+			values = append(values, -c.delete)
+		default:
+			values = append(values, string(utf16.Decode(c.insert)))
+		}
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON decodes an operation in the ot.js format
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var values []any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*o = Operation{}
+	for _, value := range values {
+		switch v := value.(type) {
+		case float64:
+			if v != float64(int(v)) || v == 0 {
+				return ErrInvalid
+			} else if v > 0 {
+				o.Retain(int(v))
+			} else {
+				o.Delete(int(-v))
+			}
+		case string:
+			if v == "" {
+				return ErrInvalid
+			}
+			o.Insert(v)
+		default:
+			return ErrInvalid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package textot
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	o := (&Operation{}).Retain(6).Delete(5).Insert("GoBlog")
+	assert.Equal(t, 11, o.BaseLen())
+	assert.Equal(t, 12, o.TargetLen())
+
+	res, err := o.Apply("Hello World")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello GoBlog", res)
+
+	_, err = o.Apply("Hello")
+	assert.ErrorIs(t, err, ErrBaseLength)
+
+	// Lengths are counted in UTF-16 code units
+	o = (&Operation{}).Retain(2).Insert("!")
+	res, err = o.Apply("😀")
+	require.NoError(t, err)
+	assert.Equal(t, "😀!", res)
+}
+
+func TestJSON(t *testing.T) {
+	o := &Operation{}
+	require.NoError(t, json.Unmarshal([]byte(`[3,"abc",-2,1]`), o))
+	assert.Equal(t, 6, o.BaseLen())
+	assert.Equal(t, 7, o.TargetLen())
+
+	data, err := json.Marshal(o)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[3,"abc",-2,1]`, string(data))
+
+	// Inserts are placed before deletes
+	o = (&Operation{}).Delete(2).Insert("a")
+	data, err = json.Marshal(o)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["a",-2]`, string(data))
+
+	for _, invalid := range []string{`[0]`, `[1.5]`, `[""]`, `[true]`, `{}`} {
+		assert.Error(t, json.Unmarshal([]byte(invalid), &Operation{}), invalid)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"", ""},
+		{"", "abc"},
+		{"abc", ""},
+		{"Hello World", "Hello GoBlog World"},
+		{"aaa", "aaaa"},
+		{"Hello World", "Hello"},
+		{"😀", "😃"},
+		{"a😀b", "a😃b"},
+	} {
+		o := Diff(tc[0], tc[1])
+		res, err := o.Apply(tc[0])
+		require.NoError(t, err)
+		assert.Equal(t, tc[1], res)
+	}
+	assert.True(t, Diff("abc", "abc").IsNoop())
+	assert.False(t, Diff("abc", "abd").IsNoop())
+}
+
+func TestTransform(t *testing.T) {
+	doc := "Hello World"
+	a := (&Operation{}).Retain(5).Insert(",").Retain(6)
+	b := (&Operation{}).Retain(6).Delete(5).Insert("GoBlog")
+
+	aPrime, bPrime, err := Transform(a, b)
+	require.NoError(t, err)
+
+	ab := mustApply(t, mustApply(t, doc, a), bPrime)
+	ba := mustApply(t, mustApply(t, doc, b), aPrime)
+	assert.Equal(t, "Hello, GoBlog", ab)
+	assert.Equal(t, ab, ba)
+
+	// Inserts at the same position, a goes first
+	a = (&Operation{}).Insert("a")
+	b = (&Operation{}).Insert("b")
+	aPrime, bPrime, err = Transform(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, "ab", mustApply(t, mustApply(t, "", a), bPrime))
+	assert.Equal(t, "ab", mustApply(t, mustApply(t, "", b), aPrime))
+
+	_, _, err = Transform((&Operation{}).Retain(1), (&Operation{}).Retain(2))
+	assert.ErrorIs(t, err, ErrBaseLength)
+}
+
+func TestTransformRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 500 {
+		doc := randomString(r, r.IntN(20))
+		a, b := randomOperation(r, doc), randomOperation(r, doc)
+		aPrime, bPrime, err := Transform(a, b)
+		require.NoError(t, err)
+		assert.Equal(t, mustApply(t, mustApply(t, doc, a), bPrime), mustApply(t, mustApply(t, doc, b), aPrime))
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	// "Hello World" -> "Hi World"
+	o := (&Operation{}).Retain(1).Delete(4).Insert("i").Retain(6)
+	assert.Equal(t, 0, o.TransformIndex(0))
+	assert.Equal(t, 2, o.TransformIndex(3))
+	assert.Equal(t, 3, o.TransformIndex(6))
+	assert.Equal(t, 8, o.TransformIndex(11))
+}
+
+func mustApply(t *testing.T, doc string, o *Operation) string {
+	t.Helper()
+	res, err := o.Apply(doc)
+	require.NoError(t, err)
+	return res
+}
+
+func randomString(r *rand.Rand, n int) string {
+	const chars = "abc de\n"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[r.IntN(len(chars))]
+	}
+	return string(b)
+}
+
+func randomOperation(r *rand.Rand, doc string) *Operation {
+	o := &Operation{}
+	remaining := len(utf16.Encode([]rune(doc)))
+	for remaining > 0 {
+		n := 1 + r.IntN(remaining)
+		switch r.IntN(3) {
+		case 0:
+			o.Retain(n)
+			remaining -= n
+		case 1:
+			o.Delete(n)
+			remaining -= n
+		default:
+			o.Insert(randomString(r, 1+r.IntN(5)))
+		}
+	}
+	if r.IntN(2) == 0 {
+		o.Insert(randomString(r, 1+r.IntN(5)))
+	}
+	return o
+}
@@ -89,6 +89,7 @@ type _go_goblog_app_app_pkgs_plugintypes_App struct {
 	WGetFullAddress       func(path string) string
 	WGetHTTPClient        func() *http.Client
 	WGetPost              func(path string) (plugintypes.Post, error)
+	WGetRelatedPosts      func(path string, count int) ([]plugintypes.Post, error)
 	WIsLoggedIn           func(req *http.Request) bool
 	WPurgeCache           func()
 	WRenderMarkdownAsText func(markdown string) (text string, err error)
@@ -120,6 +121,9 @@ func (W _go_goblog_app_app_pkgs_plugintypes_App) GetHTTPClient() *http.Client {
 func (W _go_goblog_app_app_pkgs_plugintypes_App) GetPost(path string) (plugintypes.Post, error) {
 	return W.WGetPost(path)
 }
+func (W _go_goblog_app_app_pkgs_plugintypes_App) GetRelatedPosts(path string, count int) ([]plugintypes.Post, error) {
+	return W.WGetRelatedPosts(path, count)
+}
 func (W _go_goblog_app_app_pkgs_plugintypes_App) IsLoggedIn(req *http.Request) bool {
 	return W.WIsLoggedIn(req)
 }
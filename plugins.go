@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 
+	"github.com/samber/lo"
 	"go.goblog.app/app/pkgs/bufferpool"
 	"go.goblog.app/app/pkgs/plugins"
 	"go.goblog.app/app/pkgs/plugintypes"
@@ -183,6 +184,18 @@ func (a *goBlog) GetFullAddress(path string) string {
 	return a.getFullAddress(path)
 }
 
+func (a *goBlog) GetRelatedPosts(path string, count int) ([]plugintypes.Post, error) {
+	p, err := a.getPost(path)
+	if err != nil {
+		return nil, err
+	}
+	related, err := a.getRelatedPosts(p, count)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(related, func(rp *post, _ int) plugintypes.Post { return rp }), nil
+}
+
 func (p *post) GetPath() string {
 	return p.Path
 }
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	postPasswordParameter = "password"
+	postTokenQueryParam   = "token"
+
+	postUnlockPath = "/unlock"
+
+	// Limits against guessing post passwords
+	postUnlockRateLimitClient = 10
+	postUnlockRateLimitPost   = 30
+	postUnlockRateLimitWindow = 10 * time.Minute
+
+	editorShareLinksPath       = "/sharelinks"
+	editorShareLinksCreatePath = editorShareLinksPath + "/create"
+	editorShareLinksRevokePath = editorShareLinksPath + "/revoke"
+)
+
+type postToken struct {
+	token, path      string
+	created, expires string
+}
+
+func (t *postToken) expired() bool {
+	return t.expires != "" && t.expires <= utcNowString()
+}
+
+// createPostToken creates a new secret token to access a link-only or password-protected post, an empty expires means it never expires.
+func (db *database) createPostToken(path string, expires time.Time) (*postToken, error) {
+	t := &postToken{
+		token:   rand.Text(),
+		path:    path,
+		created: utcNowString(),
+	}
+	if !expires.IsZero() {
+		t.expires = expires.UTC().Format(time.RFC3339)
+	}
+	_, err := db.Exec(
+		"insert into post_tokens (token, path, created, expires) values (@token, @path, @created, @expires)",
+		sql.Named("token", t.token), sql.Named("path", t.path), sql.Named("created", t.created), sql.Named("expires", t.expires),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (db *database) getPostTokens(path string) ([]*postToken, error) {
+	rows, err := db.Query("select token, path, created, expires from post_tokens where path = @path order by created desc", sql.Named("path", path))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []*postToken
+	for rows.Next() {
+		t := &postToken{}
+		if err = rows.Scan(&t.token, &t.path, &t.created, &t.expires); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (db *database) deletePostToken(path, token string) error {
+	_, err := db.Exec("delete from post_tokens where path = @path and token = @token", sql.Named("path", path), sql.Named("token", token))
+	return err
+}
+
+// postTokenValid checks if the token exists for the post and isn't expired.
+func (db *database) postTokenValid(path, token string) bool {
+	if token == "" {
+		return false
+	}
+	row, err := db.QueryRow(
+		"select count(*) from post_tokens where path = @path and token = @token and (expires = '' or expires > @now)",
+		sql.Named("path", path), sql.Named("token", token), sql.Named("now", utcNowString()),
+	)
+	if err != nil {
+		return false
+	}
+	var count int
+	return row.Scan(&count) == nil && count > 0
+}
+
+// takePostPasswordHash removes a new password from the post parameters and returns its hash, so the password itself is never stored.
+func takePostPasswordHash(p *post) (string, error) {
+	password := p.firstParameter(postPasswordParameter)
+	delete(p.Parameters, postPasswordParameter)
+	return hashPassword(password)
+}
+
+// getPostPasswordHash returns the password hash of the post or an empty string if it has no password.
+func (db *database) getPostPasswordHash(path string) string {
+	row, err := db.QueryRow("select hash from post_passwords where path = @path", sql.Named("path", path))
+	if err != nil {
+		return ""
+	}
+	var hash string
+	_ = row.Scan(&hash)
+	return hash
+}
+
+// postPasswordSessionValue is stored in the unlock session, so changing the password locks the post again.
+func postPasswordSessionValue(passwordHash string) string {
+	hash := sha256.Sum256([]byte(passwordHash))
+	return hex.EncodeToString(hash[:])
+}
+
+func (a *goBlog) postUnlocked(r *http.Request, p *post) bool {
+	passwordHash := a.db.getPostPasswordHash(p.Path)
+	if passwordHash == "" {
+		return false
+	}
+	a.initSessionStores()
+	ses, err := a.postUnlockSessions.Get(r, "u")
+	if err != nil {
+		return false
+	}
+	hash, ok := ses.Values[p.Path].(string)
+	return ok && subtle.ConstantTimeCompare([]byte(hash), []byte(postPasswordSessionValue(passwordHash))) == 1
+}
+
+// serveRestrictedPost serves password-protected and link-only posts to users with access and a password form or 404 to everyone else.
+func (a *goBlog) serveRestrictedPost(w http.ResponseWriter, r *http.Request) {
+	if a.isLoggedIn(r) {
+		a.servePost(w, r)
+		return
+	}
+	p, err := a.getPost(r.URL.Path)
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if a.db.postTokenValid(p.Path, r.URL.Query().Get(postTokenQueryParam)) ||
+		(p.Visibility == visibilityProtected && a.postUnlocked(r, p)) {
+		// Don't cache and don't leak the token to other sites
+		w.Header().Set(cacheControl, "private,no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		a.servePost(w, r)
+		return
+	}
+	if p.Visibility != visibilityProtected {
+		a.serve404(w, r)
+		return
+	}
+	w.Header().Set(cacheControl, "no-store,max-age=0")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	a.renderWithStatusCode(w, r, http.StatusUnauthorized, a.renderPostUnlock, &renderData{
+		BlogString: p.Blog,
+		Data: &postUnlockRenderData{
+			path:  p.Path,
+			wrong: r.URL.Query().Get("wrong") != "",
+		},
+	})
+}
+
+func (a *goBlog) servePostUnlock(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path"))
+	if errors.Is(err, errPostNotFound) || (err == nil && p.Visibility != visibilityProtected) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Limit the attempts per client and per post, also against attempts from many addresses
+	clientLimited := a.postUnlockRateLimiter.limited("client:"+remoteIP(r), postUnlockRateLimitClient, postUnlockRateLimitWindow)
+	postLimited := a.postUnlockRateLimiter.limited("post:"+p.Path, postUnlockRateLimitPost, postUnlockRateLimitWindow)
+	if clientLimited || postLimited {
+		a.serveError(w, r, "too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	passwordHash := a.db.getPostPasswordHash(p.Path)
+	if !checkPasswordHash(r.FormValue(postPasswordParameter), passwordHash) {
+		http.Redirect(w, r, p.Path+"?wrong=1", http.StatusFound)
+		return
+	}
+	a.initSessionStores()
+	ses, err := a.postUnlockSessions.Get(r, "u")
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ses.Values[p.Path] = postPasswordSessionValue(passwordHash)
+	if err = a.postUnlockSessions.Save(r, w, ses); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, p.Path, http.StatusFound)
+}
+
+func (a *goBlog) postShareLink(p *post, token string) string {
+	return a.fullPostURL(p) + "?" + url.Values{postTokenQueryParam: {token}}.Encode()
+}
+
+func (a *goBlog) serveEditorShareLinks(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path"))
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tokens, err := a.db.getPostTokens(p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderEditorShareLinks, &renderData{
+		Data: &editorShareLinksRenderData{
+			post:   p,
+			tokens: tokens,
+		},
+	})
+}
+
+func (a *goBlog) serveEditorShareLinksCreate(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path"))
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var expires time.Time
+	if expiresStr := r.FormValue("expires"); expiresStr != "" {
+		expiresUTC, err := toUTC(expiresStr)
+		if err != nil {
+			a.serveError(w, r, "expires has wrong format", http.StatusBadRequest)
+			return
+		}
+		expires, _ = time.Parse(time.RFC3339, expiresUTC)
+	}
+	if _, err = a.db.createPostToken(p.Path, expires); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, a.getRelativePath(p.Blog, editorPath+editorShareLinksPath)+"?"+url.Values{"path": {p.Path}}.Encode(), http.StatusFound)
+}
+
+func (a *goBlog) serveEditorShareLinksRevoke(w http.ResponseWriter, r *http.Request) {
+	path := r.FormValue("path")
+	if err := a.db.deletePostToken(path, r.FormValue(postTokenQueryParam)); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	blog, _ := a.getBlog(r)
+	http.Redirect(w, r, a.getRelativePath(blog, editorPath+editorShareLinksPath)+"?"+url.Values{"path": {path}}.Encode(), http.StatusFound)
+}
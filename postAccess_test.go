@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postAccess(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/posts/protected",
+		Section:    "posts",
+		Content:    "Protected content",
+		Visibility: visibilityProtected,
+		Parameters: map[string][]string{"title": {"Protected"}, "password": {"secret"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:       "/posts/link",
+		Section:    "posts",
+		Content:    "Link-only content",
+		Visibility: visibilityLink,
+		Parameters: map[string][]string{"title": {"Link-only"}},
+	}))
+
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Password", func(t *testing.T) {
+		// Only the hash is stored, not the password itself
+		p, err := app.getPost("/posts/protected")
+		require.NoError(t, err)
+		assert.Empty(t, p.firstParameter("password"))
+		assert.NotContains(t, p.contentWithParams(), "secret")
+		hash := app.db.getPostPasswordHash("/posts/protected")
+		assert.NotEmpty(t, hash)
+		assert.NotContains(t, hash, "secret")
+
+		rec := get("/posts/protected")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "noindex", rec.Header().Get("X-Robots-Tag"))
+		assert.Contains(t, rec.Body.String(), "Password-protected post")
+		assert.NotContains(t, rec.Body.String(), "Protected content")
+
+		unlock := func(password string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/-/unlock", strings.NewReader(url.Values{"path": {"/posts/protected"}, "password": {password}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			app.d.ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec = unlock("wrong")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/posts/protected?wrong=1", rec.Header().Get("Location"))
+		assert.Contains(t, get("/posts/protected?wrong=1").Body.String(), "Wrong password")
+
+		rec = unlock("secret")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		cookies := rec.Result().Cookies()
+		require.NotEmpty(t, cookies)
+
+		rec = get("/posts/protected", cookies...)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Protected content")
+		assert.Equal(t, "noindex", rec.Header().Get("X-Robots-Tag"))
+
+		// Saving without a new password keeps the password
+		p, err = app.getPost("/posts/protected")
+		require.NoError(t, err)
+		require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+		assert.Equal(t, http.StatusOK, get("/posts/protected", cookies...).Code)
+
+		// Changing the password locks the post again
+		p.Parameters["password"] = []string{"new"}
+		require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+		assert.Equal(t, http.StatusUnauthorized, get("/posts/protected", cookies...).Code)
+		assert.Equal(t, "/posts/protected", unlock("new").Header().Get("Location"))
+	})
+
+	t.Run("Unlock rate limit", func(t *testing.T) {
+		app.postUnlockRateLimiter.reset()
+		unlock := func(remoteAddr string) int {
+			req := httptest.NewRequest(http.MethodPost, "/-/unlock", strings.NewReader(url.Values{"path": {"/posts/protected"}, "password": {"wrong"}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.RemoteAddr = remoteAddr
+			rec := httptest.NewRecorder()
+			app.d.ServeHTTP(rec, req)
+			return rec.Code
+		}
+		// Per client
+		for range postUnlockRateLimitClient {
+			app.postUnlockRateLimiter.limited("client:192.0.2.1", postUnlockRateLimitClient, postUnlockRateLimitWindow)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, unlock("192.0.2.1:1234"))
+		assert.Equal(t, http.StatusFound, unlock("192.0.2.2:1234"))
+		// Per post, also from other clients
+		for range postUnlockRateLimitPost {
+			app.postUnlockRateLimiter.limited("post:/posts/protected", postUnlockRateLimitPost, postUnlockRateLimitWindow)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, unlock("192.0.2.3:1234"))
+		app.postUnlockRateLimiter.reset()
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/posts/link").Code)
+		assert.Equal(t, http.StatusNotFound, get("/posts/link?token=invalid").Code)
+
+		token, err := app.db.createPostToken("/posts/link", time.Time{})
+		require.NoError(t, err)
+		rec := get("/posts/link?token=" + token.token)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Link-only content")
+		assert.Equal(t, "noindex", rec.Header().Get("X-Robots-Tag"))
+		assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+
+		// Tokens are only valid for their post
+		assert.Equal(t, http.StatusUnauthorized, get("/posts/protected?token="+token.token).Code)
+
+		// Revoked
+		require.NoError(t, app.db.deletePostToken("/posts/link", token.token))
+		assert.Equal(t, http.StatusNotFound, get("/posts/link?token="+token.token).Code)
+
+		// Expired
+		expired, err := app.db.createPostToken("/posts/link", time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, expired.expired())
+		assert.Equal(t, http.StatusNotFound, get("/posts/link?token="+expired.token).Code)
+
+		// Tokens also work for password-protected posts
+		token, err = app.db.createPostToken("/posts/protected", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, get("/posts/protected?token="+token.token).Code)
+
+		tokens, err := app.db.getPostTokens("/posts/link")
+		require.NoError(t, err)
+		assert.Len(t, tokens, 1)
+	})
+
+	t.Run("Excluded", func(t *testing.T) {
+		for _, path := range []string{"/", "/posts.rss", "/sitemap-blog-posts.xml", "/search/protected"} {
+			rec := get(path)
+			assert.NotContains(t, rec.Body.String(), "/posts/protected", path)
+			assert.NotContains(t, rec.Body.String(), "/posts/link", path)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/justinas/alice"
+)
+
+// updatePostRedirects remembers the old path of a renamed post, so it redirects to the new path.
+// Existing redirects to the old path follow the post via the foreign key.
+// Must be called within a transaction and while holding pcm lock, after the post was saved.
+func (db *database) updatePostRedirects(oldPath, newPath string) error {
+	// The new path isn't a redirect anymore
+	if _, err := db.Exec("delete from post_redirects where from_path = ?", newPath); err != nil {
+		return err
+	}
+	if oldPath == "" || oldPath == newPath {
+		return nil
+	}
+	_, err := db.Exec("insert or replace into post_redirects (from_path, path) values (?, ?)", oldPath, newPath)
+	return err
+}
+
+// getPostRedirects returns all previous paths of a post.
+func (db *database) getPostRedirects(path string) ([]string, error) {
+	rows, err := db.Query("select from_path from post_redirects where path = ? order by from_path", path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var from string
+		if err = rows.Scan(&from); err != nil {
+			return nil, err
+		}
+		paths = append(paths, from)
+	}
+	return paths, rows.Err()
+}
+
+// servePostRedirect permanently redirects to the current path of a renamed post.
+// ActivityPub fetches of the old ID get the current object instead.
+func (a *goBlog) servePostRedirect(path string) http.Handler {
+	return alice.New(a.privateModeHandler, a.checkActivityStreamsRequest).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		if asRequest, ok := r.Context().Value(asRequestKey).(bool); ok && asRequest {
+			p, err := a.getPost(path)
+			if err != nil {
+				a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if p.Status != statusPublished || (p.Visibility != visibilityPublic && p.Visibility != visibilityUnlisted) {
+				a.serve404(w, r)
+				return
+			}
+			a.serveActivityStreamsPost(w, r, http.StatusOK, p)
+			return
+		}
+		alice.New(cacheLoggedIn, a.cacheMiddleware).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, path, http.StatusMovedPermanently)
+		}).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postRedirects(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	app.cfg.ActivityPub = &configActivityPub{Enabled: true}
+	app.cfg.Blogs = map[string]*configBlog{
+		"en": {
+			Lang: "en",
+			Sections: map[string]*configSection{
+				"test": {},
+			},
+		},
+	}
+	app.cfg.DefaultBlog = "en"
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	require.NoError(t, app.initActivityPub())
+	app.d = app.buildRouter()
+
+	get := func(path string, accept string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		app.d.ServeHTTP(rec, req)
+		return rec
+	}
+
+	p := &post{
+		Path:    "/test/first",
+		Content: "Content",
+		Section: "test",
+	}
+	require.NoError(t, app.createPost(p))
+
+	// Rename
+	p.Path = "/test/second"
+	require.NoError(t, app.replacePost(p, "/test/first", p.Status, p.Visibility, false))
+
+	rec := get("/test/first", "")
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/test/second", rec.Header().Get("Location"))
+
+	// Rename again, both old paths redirect to the current path
+	p.Path = "/test/third"
+	require.NoError(t, app.replacePost(p, "/test/second", p.Status, p.Visibility, false))
+
+	for _, old := range []string{"/test/first", "/test/second"} {
+		rec = get(old, "")
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "/test/third", rec.Header().Get("Location"))
+	}
+
+	redirects, err := app.db.getPostRedirects("/test/third")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/test/first", "/test/second"}, redirects)
+
+	t.Run("ActivityPub", func(t *testing.T) {
+		rec := get("/test/first", "application/activity+json")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://example.com/test/third")
+	})
+
+	t.Run("Editor", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/editor", strings.NewReader(url.Values{
+			"editoraction": {"loadupdate"},
+			"path":         {"/test/third"},
+		}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		app.serveEditorPost(rec, req.WithContext(t.Context()))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "<code>/test/first</code>")
+		assert.Contains(t, rec.Body.String(), "<code>/test/second</code>")
+	})
+
+	t.Run("Rename back", func(t *testing.T) {
+		p.Path = "/test/first"
+		require.NoError(t, app.replacePost(p, "/test/third", p.Status, p.Visibility, false))
+
+		rec := get("/test/first", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		redirects, err := app.db.getPostRedirects("/test/first")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/test/second", "/test/third"}, redirects)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// First delete only marks as deleted, second deletes permanently
+		require.NoError(t, app.deletePost("/test/first"))
+		require.NoError(t, app.deletePost("/test/first"))
+
+		redirects, err := app.db.getPostRedirects("/test/first")
+		require.NoError(t, err)
+		assert.Empty(t, redirects)
+
+		rec := get("/test/second", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/samber/lo"
+)
+
+const (
+	editorRevisionsPath        = "/revisions"
+	editorRevisionsRestorePath = editorRevisionsPath + "/restore"
+)
+
+var errRevisionNotFound = errors.New("revision not found")
+
+type postRevision struct {
+	ID   int
+	Time string
+	Post *post
+}
+
+// savePostRevision records the given post state as a new revision.
+// Must be called within a transaction and while holding pcm lock.
+func (db *database) savePostRevision(p *post, revisionTime string) error {
+	params := map[string][]string{}
+	for param, values := range p.Parameters {
+		if filtered := lo.Filter(values, loStringNotEmpty); len(filtered) > 0 {
+			params[param] = filtered
+		}
+	}
+	paramsJson, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		"insert into post_revisions (path, time, content, parameters, published, updated, blog, section, status, visibility, priority) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		p.Path, revisionTime, p.Content, string(paramsJson), toUTCSafe(p.Published), toUTCSafe(p.Updated), p.Blog, p.Section, p.Status, p.Visibility, p.Priority,
+	)
+	return err
+}
+
+// backfillPostRevision records the current database state of a post as the first revision,
+// if there is no revision yet (e.g. for posts created before revisions were introduced).
+// Must be called within a transaction and while holding pcm lock.
+func (db *database) backfillPostRevision(path string) error {
+	// Query from writeDb to see in-flight transaction changes
+	rows, err := db.WriteQuery(
+		"select coalesce(content, ''), coalesce(published, ''), coalesce(updated, ''), blog, coalesce(section, ''), status, visibility, priority from posts where path = @path and not exists (select 1 from post_revisions where path = @path)",
+		sql.Named("path", path),
+	)
+	if err != nil {
+		return err
+	}
+	p := &post{Path: path, Parameters: map[string][]string{}}
+	found := false
+	for rows.Next() {
+		found = true
+		if err = rows.Scan(&p.Content, &p.Published, &p.Updated, &p.Blog, &p.Section, &p.Status, &p.Visibility, &p.Priority); err != nil {
+			_ = rows.Close()
+			return err
+		}
+	}
+	if !found {
+		return nil
+	}
+	paramRows, err := db.WriteQuery("select parameter, value from post_parameters where path = ? order by id", path)
+	if err != nil {
+		return err
+	}
+	var name, value string
+	for paramRows.Next() {
+		if err = paramRows.Scan(&name, &value); err != nil {
+			_ = paramRows.Close()
+			return err
+		}
+		p.Parameters[name] = append(p.Parameters[name], value)
+	}
+	return db.savePostRevision(p, toUTCSafe(cmp.Or(p.Updated, p.Published, utcNowString())))
+}
+
+func (db *database) getPostRevisions(path string) ([]*postRevision, error) {
+	return db.queryPostRevisions("where path = @path order by id desc", sql.Named("path", path))
+}
+
+func (db *database) getPostRevision(id int) (*postRevision, error) {
+	revisions, err := db.queryPostRevisions("where id = @id", sql.Named("id", id))
+	if err != nil {
+		return nil, err
+	} else if len(revisions) == 0 {
+		return nil, errRevisionNotFound
+	}
+	return revisions[0], nil
+}
+
+func (db *database) queryPostRevisions(filter string, args ...any) ([]*postRevision, error) {
+	rows, err := db.Query("select id, path, time, content, parameters, published, updated, blog, section, status, visibility, priority from post_revisions "+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revisions []*postRevision
+	var paramsJson, published, updated string
+	for rows.Next() {
+		rev := &postRevision{Post: &post{}}
+		if err = rows.Scan(
+			&rev.ID, &rev.Post.Path, &rev.Time, &rev.Post.Content, &paramsJson, &published, &updated,
+			&rev.Post.Blog, &rev.Post.Section, &rev.Post.Status, &rev.Post.Visibility, &rev.Post.Priority,
+		); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(paramsJson), &rev.Post.Parameters); err != nil {
+			return nil, err
+		}
+		rev.Time = toLocalSafe(rev.Time)
+		rev.Post.Published, rev.Post.Updated = toLocalSafe(published), toLocalSafe(updated)
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+// restorePostRevision replaces the current post with the state of the revision.
+// The normal update path is used, so hooks and cache purges are triggered.
+func (a *goBlog) restorePostRevision(id int) (*post, error) {
+	rev, err := a.db.getPostRevision(id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := a.getPost(rev.Post.Path)
+	if err != nil {
+		return nil, err
+	}
+	if current.Deleted() {
+		return nil, errors.New("post is marked as deleted, undelete it first")
+	}
+	restored := *rev.Post
+	restored.Path = current.Path
+	if err = a.replacePost(&restored, current.Path, current.Status, current.Visibility, false); err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+type revisionDiffLine struct {
+	op   byte // ' ' for unchanged, '-' for removed, '+' for added
+	text string
+}
+
+// revisionDiff computes a line based diff between two revisions, including the front matter.
+func revisionDiff(from, to *postRevision) []*revisionDiffLine {
+	return lineDiff(from.Post.contentWithParams(), to.Post.contentWithParams())
+}
+
+func lineDiff(from, to string) []*revisionDiffLine {
+	fromLines := strings.Split(from, "\n")
+	toLines := strings.Split(to, "\n")
+	var diff []*revisionDiffLine
+	add := func(op byte, lines []string) {
+		for _, line := range lines {
+			diff = append(diff, &revisionDiffLine{op: op, text: line})
+		}
+	}
+	for _, oc := range difflib.NewMatcher(fromLines, toLines).GetOpCodes() {
+		switch oc.Tag {
+		case 'e':
+			add(' ', fromLines[oc.I1:oc.I2])
+		case 'd':
+			add('-', fromLines[oc.I1:oc.I2])
+		case 'i':
+			add('+', toLines[oc.J1:oc.J2])
+		case 'r':
+			add('-', fromLines[oc.I1:oc.I2])
+			add('+', toLines[oc.J1:oc.J2])
+		}
+	}
+	return diff
+}
+
+func (a *goBlog) serveEditorRevisions(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path"))
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	revisions, err := a.db.getPostRevisions(p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rd := &editorRevisionsRenderData{post: p, revisions: revisions}
+	// Select the revisions to compare, defaults to the latest change
+	findRevision := func(param string, fallback int) *postRevision {
+		if id, err := strconv.Atoi(r.FormValue(param)); err == nil {
+			if rev, ok := lo.Find(revisions, func(rev *postRevision) bool { return rev.ID == id }); ok {
+				return rev
+			}
+		}
+		if fallback < len(revisions) {
+			return revisions[fallback]
+		}
+		return nil
+	}
+	rd.to = findRevision("to", 0)
+	rd.from = findRevision("from", 1)
+	if rd.from != nil && rd.to != nil {
+		rd.diff = revisionDiff(rd.from, rd.to)
+	}
+	a.render(w, r, a.renderEditorRevisions, &renderData{
+		Data: rd,
+	})
+}
+
+func (a *goBlog) serveEditorRevisionRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("revision"))
+	if err != nil {
+		a.serveError(w, r, "revision missing or wrong format", http.StatusBadRequest)
+		return
+	}
+	p, err := a.restorePostRevision(id)
+	if errors.Is(err, errRevisionNotFound) || errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, p.Path, http.StatusFound)
+}
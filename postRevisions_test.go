@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postRevisions(t *testing.T) {
+	updateHook := 0
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Blogs = map[string]*configBlog{
+		"en": {
+			Lang: "en",
+			Sections: map[string]*configSection{
+				"test": {},
+			},
+		},
+	}
+	app.cfg.DefaultBlog = "en"
+	app.pUpdateHooks = append(app.pUpdateHooks, func(p *post) {
+		updateHook++
+	})
+
+	require.NoError(t, app.initConfig(false))
+	_ = app.initTemplateStrings()
+
+	// Create post
+	p := &post{
+		Path:       "/test/abc",
+		Content:    "Line 1\nLine 2",
+		Blog:       "en",
+		Section:    "test",
+		Status:     statusPublished,
+		Visibility: visibilityPublic,
+		Parameters: map[string][]string{
+			"title": {"Title"},
+		},
+	}
+	require.NoError(t, app.createPost(p))
+
+	revisions, err := app.db.getPostRevisions("/test/abc")
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Line 1\nLine 2", revisions[0].Post.Content)
+	assert.Equal(t, []string{"Title"}, revisions[0].Post.Parameters["title"])
+
+	// Update post
+	p.Content = "Line 1\nLine 2 changed"
+	p.Parameters["tags"] = []string{"A"}
+	require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+
+	revisions, err = app.db.getPostRevisions("/test/abc")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Line 1\nLine 2 changed", revisions[0].Post.Content)
+	assert.Equal(t, []string{"A"}, revisions[0].Post.Parameters["tags"])
+	assert.Greater(t, revisions[0].ID, revisions[1].ID)
+
+	// Diff
+	diff := revisionDiff(revisions[1], revisions[0])
+	var added, removed []string
+	for _, line := range diff {
+		switch line.op {
+		case '+':
+			added = append(added, line.text)
+		case '-':
+			removed = append(removed, line.text)
+		}
+	}
+	assert.Contains(t, added, "Line 2 changed")
+	assert.Contains(t, added, "tags: A")
+	assert.Contains(t, removed, "Line 2")
+
+	t.Run("Revisions page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/editor/revisions?path=/test/abc", nil)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, "en"))
+		rec := httptest.NewRecorder()
+
+		app.serveEditorRevisions(rec, req)
+
+		res := rec.Result()
+		resBody, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		resString := string(resBody)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, resString, "<del>- Line 2</del>")
+		assert.Contains(t, resString, "<ins>+ Line 2 changed</ins>")
+	})
+
+	t.Run("Revisions page for missing post", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/editor/revisions?path=/test/missing", nil)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, "en"))
+		rec := httptest.NewRecorder()
+
+		app.serveEditorRevisions(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	// Restore first revision
+	hooksBefore := updateHook
+	form := url.Values{"revision": {strconv.Itoa(revisions[1].ID)}}
+	req := httptest.NewRequest(http.MethodPost, "/editor/revisions/restore", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(context.WithValue(req.Context(), blogKey, "en"))
+	rec := httptest.NewRecorder()
+
+	app.serveEditorRevisionRestore(rec, req)
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/test/abc", rec.Header().Get("Location"))
+
+	restored, err := app.getPost("/test/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "Line 1\nLine 2", restored.Content)
+	assert.Empty(t, restored.Parameters["tags"])
+	assert.Equal(t, "Title", restored.Title())
+
+	// Restoring goes through the normal update path
+	require.Eventually(t, func() bool { return updateHook > hooksBefore }, time.Second, 10*time.Millisecond)
+
+	// Restoring creates a new revision
+	revisions, err = app.db.getPostRevisions("/test/abc")
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3)
+}
+
+func Test_postRevisionsBackfill(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Blogs = map[string]*configBlog{
+		"en": {
+			Sections: map[string]*configSection{
+				"test": {},
+			},
+		},
+	}
+
+	require.NoError(t, app.initConfig(false))
+
+	require.NoError(t, app.db.savePost(&post{
+		Path:       "/test/old",
+		Content:    "Old content",
+		Published:  toLocalSafe(time.Now().Add(-24 * time.Hour).String()),
+		Blog:       "en",
+		Section:    "test",
+		Status:     statusPublished,
+		Visibility: visibilityPublic,
+		Parameters: map[string][]string{
+			"title": {"Old"},
+		},
+	}, &postCreationOptions{new: true}))
+
+	// Simulate a post created before revisions were tracked
+	_, err := app.db.Exec("delete from post_revisions")
+	require.NoError(t, err)
+
+	require.NoError(t, app.db.savePost(&post{
+		Path:       "/test/new",
+		Content:    "New content",
+		Blog:       "en",
+		Section:    "test",
+		Status:     statusPublished,
+		Visibility: visibilityPublic,
+	}, &postCreationOptions{oldPath: "/test/old"}))
+
+	// The previous state is backfilled and follows the path change
+	revisions, err := app.db.getPostRevisions("/test/new")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "New content", revisions[0].Post.Content)
+	assert.Equal(t, "Old content", revisions[1].Post.Content)
+	assert.Equal(t, []string{"Old"}, revisions[1].Post.Parameters["title"])
+	assert.Equal(t, "/test/new", revisions[1].Post.Path)
+}
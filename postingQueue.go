@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	editorPostingQueuePath = "/queue"
+
+	postingQueueWait = 30 * time.Second
+)
+
+func (bc *configBlog) postingQueueEnabled() bool {
+	return bc != nil && bc.PostingQueue != nil && bc.PostingQueue.Enabled && len(bc.PostingQueue.Slots) > 0
+}
+
+func postingQueueName(blog string) string {
+	return "postqueue-" + blog
+}
+
+// clock returns hour and minute of the slot.
+func (s *configPostingQueueSlot) clock() (hour, minute int, err error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s.Time))
+	if err != nil {
+		return 0, 0, err
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// onWeekday checks if the slot is active on the weekday, slots without days are active every day.
+func (s *configPostingQueueSlot) onWeekday(wd time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	day := strings.ToLower(wd.String()[:3])
+	return slices.ContainsFunc(s.Days, func(d string) bool {
+		d = strings.ToLower(strings.TrimSpace(d))
+		return len(d) >= 3 && d[:3] == day
+	})
+}
+
+func (pq *configPostingQueue) check() error {
+	for _, s := range pq.Slots {
+		if _, _, err := s.clock(); err != nil {
+			return errors.New("invalid posting queue slot time " + s.Time + ", use the format 15:04")
+		}
+	}
+	return nil
+}
+
+// nextSlot returns the first time slot after the given time or a zero time if there is none.
+func (pq *configPostingQueue) nextSlot(after time.Time) time.Time {
+	for day := range 8 {
+		date := after.AddDate(0, 0, day)
+		var next time.Time
+		for _, s := range pq.Slots {
+			hour, minute, err := s.clock()
+			if err != nil || !s.onWeekday(date.Weekday()) {
+				continue
+			}
+			slot := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, after.Location())
+			if slot.After(after) && (next.IsZero() || slot.Before(next)) {
+				next = slot
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (a *goBlog) initPostingQueue() {
+	hook := func(p *post) {
+		// Published or deleted posts don't need to stay in the queue
+		if p.Status != statusDraft {
+			if err := a.removePostFromPostingQueue(p.Blog, p.Path); err != nil {
+				a.error("Failed to remove post from posting queue", "path", p.Path, "err", err)
+			}
+		}
+	}
+	a.pPostHooks = append(a.pPostHooks, hook)
+	a.pDeleteHooks = append(a.pDeleteHooks, hook)
+	for blog, bc := range a.cfg.Blogs {
+		if !bc.postingQueueEnabled() {
+			continue
+		}
+		// Assign the slots again, so posts from missed slots aren't published all at once
+		if err := a.reassignPostingQueue(blog); err != nil {
+			a.error("Failed to reassign posting queue", "blog", blog, "err", err)
+		}
+		a.listenOnQueue(postingQueueName(blog), postingQueueWait, func(qi *queueItem, dequeue func(), _ func(time.Duration)) {
+			a.publishQueuedPost(qi)
+			dequeue()
+		})
+	}
+}
+
+// publishQueuedPost publishes the draft of the queue item, it's skipped if the post is no draft anymore.
+func (a *goBlog) publishQueuedPost(qi *queueItem) {
+	a.postingQueueMutex.Lock()
+	defer a.postingQueueMutex.Unlock()
+	p, err := a.getPost(string(qi.content))
+	if err != nil {
+		a.error("Failed to get queued post", "path", string(qi.content), "err", err)
+		return
+	}
+	if p.Status != statusDraft {
+		a.info("Queued post is no draft anymore", "path", p.Path)
+		return
+	}
+	p.Status = statusPublished
+	p.Published = utcNowString()
+	if err = a.replacePost(p, p.Path, statusDraft, p.Visibility, true); err != nil {
+		a.error("Error publishing queued post", "path", p.Path, "err", err)
+		return
+	}
+	a.info("Published queued post", "path", p.Path)
+}
+
+func (a *goBlog) addToPostingQueue(p *post) error {
+	bc := a.getBlogFromPost(p)
+	if !bc.postingQueueEnabled() {
+		return errors.New("posting queue not enabled")
+	}
+	if p.Status != statusDraft {
+		return errors.New("only drafts can be added to the posting queue")
+	}
+	a.postingQueueMutex.Lock()
+	defer a.postingQueueMutex.Unlock()
+	items, err := a.getQueueItems(postingQueueName(p.Blog))
+	if err != nil {
+		return err
+	}
+	if lo.ContainsBy(items, func(qi *queueItem) bool { return string(qi.content) == p.Path }) {
+		return errors.New("post is already in the posting queue")
+	}
+	// Use the next slot after the last queued post
+	after := time.Now()
+	if len(items) > 0 && items[len(items)-1].schedule.After(after) {
+		after = items[len(items)-1].schedule.Local()
+	}
+	slot := bc.PostingQueue.nextSlot(after)
+	if slot.IsZero() {
+		return errors.New("no posting queue slot found")
+	}
+	return a.enqueue(postingQueueName(p.Blog), []byte(p.Path), slot)
+}
+
+// reassignPostingQueue assigns the next free slots to all queued posts in their current order.
+// Must be called while holding the posting queue lock, except during initialization.
+func (a *goBlog) reassignPostingQueue(blog string) error {
+	pq := a.cfg.Blogs[blog].PostingQueue
+	items, err := a.getQueueItems(postingQueueName(blog))
+	if err != nil {
+		return err
+	}
+	slot := time.Now()
+	for _, qi := range items {
+		if slot = pq.nextSlot(slot); slot.IsZero() {
+			return errors.New("no posting queue slot found")
+		}
+		if err := a.setQueueSchedule(qi, slot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *goBlog) removeFromPostingQueue(blog string, id int) error {
+	a.postingQueueMutex.Lock()
+	defer a.postingQueueMutex.Unlock()
+	if err := a.dequeue(&queueItem{id: id}); err != nil {
+		return err
+	}
+	return a.reassignPostingQueue(blog)
+}
+
+func (a *goBlog) removePostFromPostingQueue(blog, path string) error {
+	if !a.cfg.Blogs[blog].postingQueueEnabled() {
+		return nil
+	}
+	items, err := a.getQueueItems(postingQueueName(blog))
+	if err != nil {
+		return err
+	}
+	if qi, ok := lo.Find(items, func(qi *queueItem) bool { return string(qi.content) == path }); ok {
+		return a.removeFromPostingQueue(blog, qi.id)
+	}
+	return nil
+}
+
+// movePostingQueueItem swaps the slot of the queued post with the previous or next one.
+func (a *goBlog) movePostingQueueItem(blog string, id int, up bool) error {
+	a.postingQueueMutex.Lock()
+	defer a.postingQueueMutex.Unlock()
+	items, err := a.getQueueItems(postingQueueName(blog))
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(items, func(qi *queueItem) bool { return qi.id == id })
+	if i < 0 {
+		return errors.New("queue item not found")
+	}
+	j := lo.Ternary(up, i-1, i+1)
+	if j < 0 || j >= len(items) {
+		return nil
+	}
+	first, second := items[i], items[j]
+	firstSchedule, secondSchedule := first.schedule, second.schedule
+	if err := a.setQueueSchedule(first, secondSchedule); err != nil {
+		return err
+	}
+	return a.setQueueSchedule(second, firstSchedule)
+}
+
+type postingQueueEntry struct {
+	id       int
+	post     *post
+	path     string
+	schedule time.Time
+}
+
+func (a *goBlog) serveEditorPostingQueue(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	if !bc.postingQueueEnabled() {
+		a.serve404(w, r)
+		return
+	}
+	items, err := a.getQueueItems(postingQueueName(blog))
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entries := lo.Map(items, func(qi *queueItem, _ int) *postingQueueEntry {
+		entry := &postingQueueEntry{id: qi.id, path: string(qi.content), schedule: qi.schedule}
+		entry.post, _ = a.getPost(entry.path)
+		return entry
+	})
+	a.render(w, r, a.renderEditorPostingQueue, &renderData{
+		Data: entries,
+	})
+}
+
+func (a *goBlog) serveEditorPostingQueuePost(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	if !bc.postingQueueEnabled() {
+		a.serve404(w, r)
+		return
+	}
+	var err error
+	switch action := r.FormValue("action"); action {
+	case "add":
+		var p *post
+		if p, err = a.getPost(r.FormValue("path")); err == nil {
+			err = a.addToPostingQueue(p)
+		}
+	case "up", "down", "remove":
+		var id int
+		if id, err = strconv.Atoi(r.FormValue("id")); err != nil {
+			break
+		}
+		if action == "remove" {
+			err = a.removeFromPostingQueue(blog, id)
+		} else {
+			err = a.movePostingQueueItem(blog, id, action == "up")
+		}
+	default:
+		err = errors.New("unknown action")
+	}
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, bc.getRelativePath(editorPath+editorPostingQueuePath), http.StatusFound)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postingQueueSlots(t *testing.T) {
+	pq := &configPostingQueue{
+		Enabled: true,
+		Slots: []*configPostingQueueSlot{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Time: "09:00"},
+			{Days: []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}, Time: "17:00"},
+		},
+	}
+	require.NoError(t, pq.check())
+
+	// Wednesday morning
+	wed := time.Date(2024, 5, 15, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC), pq.nextSlot(wed))
+	assert.Equal(t, time.Date(2024, 5, 15, 17, 0, 0, 0, time.UTC), pq.nextSlot(wed.Add(time.Hour)))
+	// Friday evening, next slot on Monday
+	fri := time.Date(2024, 5, 17, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC), pq.nextSlot(fri))
+
+	// Every day
+	daily := &configPostingQueue{Slots: []*configPostingQueueSlot{{Time: "12:30"}}}
+	assert.Equal(t, time.Date(2024, 5, 19, 12, 30, 0, 0, time.UTC), daily.nextSlot(time.Date(2024, 5, 18, 13, 0, 0, 0, time.UTC)))
+
+	assert.Error(t, (&configPostingQueue{Slots: []*configPostingQueueSlot{{Time: "9am"}}}).check())
+	assert.True(t, (&configPostingQueue{Slots: []*configPostingQueueSlot{{Days: []string{"sun"}, Time: "25:00"}}}).nextSlot(wed).IsZero())
+}
+
+func Test_postingQueue(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs["default"].PostingQueue = &configPostingQueue{
+		Enabled: true,
+		Slots:   []*configPostingQueueSlot{{Time: "09:00"}, {Time: "17:00"}},
+	}
+	require.NoError(t, app.initTemplateStrings())
+	app.initPostingQueue()
+	app.d = app.buildRouter()
+
+	for _, path := range []string{"/posts/one", "/posts/two", "/posts/three"} {
+		require.NoError(t, app.createPost(&post{
+			Path:    path,
+			Section: "posts",
+			Status:  statusDraft,
+			Content: "Content of " + path,
+		}))
+	}
+
+	queued := func() []string {
+		items, err := app.getQueueItems(postingQueueName("default"))
+		require.NoError(t, err)
+		return lo.Map(items, func(qi *queueItem, _ int) string { return string(qi.content) })
+	}
+	action := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/editor/queue", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{"/posts/one", "/posts/two", "/posts/three"} {
+		rec := action(url.Values{"action": {"add"}, "path": {path}})
+		assert.Equal(t, http.StatusFound, rec.Code)
+	}
+	assert.Equal(t, []string{"/posts/one", "/posts/two", "/posts/three"}, queued())
+
+	// Each post gets its own slot
+	items, err := app.getQueueItems(postingQueueName("default"))
+	require.NoError(t, err)
+	pq := app.cfg.Blogs["default"].PostingQueue
+	assert.Equal(t, pq.nextSlot(time.Now()), items[0].schedule.Local())
+	assert.Equal(t, pq.nextSlot(items[0].schedule.Local()), items[1].schedule.Local())
+	assert.Equal(t, pq.nextSlot(items[1].schedule.Local()), items[2].schedule.Local())
+
+	// Adding again fails
+	assert.Equal(t, http.StatusBadRequest, action(url.Values{"action": {"add"}, "path": {"/posts/one"}}).Code)
+
+	// Reorder keeps the slots
+	assert.Equal(t, http.StatusFound, action(url.Values{"action": {"up"}, "id": {strconv.Itoa(items[2].id)}}).Code)
+	assert.Equal(t, []string{"/posts/one", "/posts/three", "/posts/two"}, queued())
+	assert.Equal(t, http.StatusFound, action(url.Values{"action": {"down"}, "id": {strconv.Itoa(items[0].id)}}).Code)
+	assert.Equal(t, []string{"/posts/three", "/posts/one", "/posts/two"}, queued())
+	newItems, err := app.getQueueItems(postingQueueName("default"))
+	require.NoError(t, err)
+	assert.Equal(t, lo.Map(items, func(qi *queueItem, _ int) time.Time { return qi.schedule }), lo.Map(newItems, func(qi *queueItem, _ int) time.Time { return qi.schedule }))
+
+	// Page
+	req := httptest.NewRequest(http.MethodGet, "/editor/queue", nil)
+	setLoggedIn(req, true)
+	rec := httptest.NewRecorder()
+	app.d.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Regexp(t, "(?s)/posts/three.*/posts/one.*/posts/two", rec.Body.String())
+
+	// Publish the first queued post
+	app.publishQueuedPost(newItems[0])
+	app.postHooksWg.Wait()
+	p, err := app.getPost("/posts/three")
+	require.NoError(t, err)
+	assert.Equal(t, statusPublished, p.Status)
+	assert.NotEmpty(t, p.Published)
+	// The post hook removed it from the queue and the others moved up
+	assert.Equal(t, []string{"/posts/one", "/posts/two"}, queued())
+	items, err = app.getQueueItems(postingQueueName("default"))
+	require.NoError(t, err)
+	assert.Equal(t, pq.nextSlot(time.Now()), items[0].schedule.Local())
+
+	// Only drafts can be added
+	assert.Equal(t, http.StatusBadRequest, action(url.Values{"action": {"add"}, "path": {"/posts/three"}}).Code)
+
+	// Remove
+	assert.Equal(t, http.StatusFound, action(url.Values{"action": {"remove"}, "id": {strconv.Itoa(items[0].id)}}).Code)
+	assert.Equal(t, []string{"/posts/two"}, queued())
+	items, err = app.getQueueItems(postingQueueName("default"))
+	require.NoError(t, err)
+	assert.Equal(t, pq.nextSlot(time.Now()), items[0].schedule.Local())
+
+	// Published drafts are skipped
+	p, err = app.getPost("/posts/two")
+	require.NoError(t, err)
+	p.Status = statusPublished
+	require.NoError(t, app.db.savePost(p, &postCreationOptions{oldPath: p.Path, oldStatus: statusDraft, oldVisibility: p.Visibility}))
+	app.publishQueuedPost(items[0])
+}
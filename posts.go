@@ -33,6 +33,7 @@ type post struct {
 	// Not persisted
 	Slug          string
 	RenderedTitle string
+	searchSnippet *searchSnippet
 }
 
 type postStatus string
@@ -49,10 +50,12 @@ const (
 	statusScheduled        postStatus = "scheduled"
 	statusScheduledDeleted            = statusScheduled + statusDeletedSuffix
 
-	visibilityNil      postVisibility = ""
-	visibilityPublic   postVisibility = "public"
-	visibilityUnlisted postVisibility = "unlisted"
-	visibilityPrivate  postVisibility = "private"
+	visibilityNil       postVisibility = ""
+	visibilityPublic    postVisibility = "public"
+	visibilityUnlisted  postVisibility = "unlisted"
+	visibilityPrivate   postVisibility = "private"
+	visibilityProtected postVisibility = "protected" // Password-protected
+	visibilityLink      postVisibility = "link"      // Only accessible with a secret link
 )
 
 func validPostStatus(s postStatus) bool {
@@ -62,7 +65,8 @@ func validPostStatus(s postStatus) bool {
 }
 
 func validPostVisibility(v postVisibility) bool {
-	return v == visibilityPublic || v == visibilityUnlisted || v == visibilityPrivate
+	return v == visibilityPublic || v == visibilityUnlisted || v == visibilityPrivate ||
+		v == visibilityProtected || v == visibilityLink
 }
 
 func (a *goBlog) servePost(w http.ResponseWriter, r *http.Request) {
@@ -199,6 +203,17 @@ func (a *goBlog) serveUnlisted(w http.ResponseWriter, r *http.Request) {
 	})))
 }
 
+func (a *goBlog) serveShared(w http.ResponseWriter, r *http.Request) {
+	_, bc := a.getBlog(r)
+	a.serveIndex(w, r.WithContext(context.WithValue(r.Context(), indexConfigKey, &indexConfig{
+		path:        bc.getRelativePath("/editor/shared"),
+		title:       a.ts.GetTemplateStringVariant(bc.Lang, "sharedposts"),
+		description: a.ts.GetTemplateStringVariant(bc.Lang, "sharedpostsdesc"),
+		status:      []postStatus{statusPublished},
+		visibility:  []postVisibility{visibilityProtected, visibilityLink},
+	})))
+}
+
 func (a *goBlog) serveScheduled(w http.ResponseWriter, r *http.Request) {
 	_, bc := a.getBlog(r)
 	a.serveIndex(w, r.WithContext(context.WithValue(r.Context(), indexConfigKey, &indexConfig{
@@ -334,7 +349,7 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 		paramUrlQuery += "?" + paramUrlValues.Encode()
 	}
 	// Create paginator
-	p := paginator.New(&postPaginationAdapter{config: &postsRequestConfig{
+	config := &postsRequestConfig{
 		blogs:          lo.If(!ic.allBlogs, []string{blog}).Else([]string{}),
 		sections:       sections,
 		taxonomy:       ic.tax,
@@ -342,7 +357,6 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 		parameter:      ic.parameter,
 		allParams:      params,
 		allParamValues: paramValues,
-		search:         ic.search,
 		publishedYear:  ic.year,
 		publishedMonth: ic.month,
 		publishedDay:   ic.day,
@@ -350,7 +364,13 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 		status:         status,
 		visibility:     visibility,
 		priorityOrder:  true,
-	}, a: a}, bc.Pagination)
+	}
+	var search *searchQuery
+	if ic.search != "" {
+		search = a.parseSearchQuery(ic.search, bc)
+		search.apply(config)
+	}
+	p := paginator.New(&postPaginationAdapter{config: config, a: a}, bc.Pagination)
 	p.SetPage(stringToInt(chi.URLParam(r, "page")))
 	var posts []*post
 	err := p.Results(&posts)
@@ -377,6 +397,11 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 	} else if ic.section != nil {
 		description = ic.section.Description
 	}
+	// Advertise WebSub hubs
+	webSub := a.isWebSubIndex(r, ic, bc)
+	if webSub {
+		a.setWebSubLinkHeaders(w, a.webSubTopicURL(ic.path, feedType(chi.URLParam(r, "feed"))))
+	}
 	// Check if feed
 	if ft := feedType(chi.URLParam(r, "feed")); ft != noFeed {
 		a.generateFeed(blog, ft, w, r, posts, title, description, ic.path, paramUrlQuery)
@@ -404,6 +429,20 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 		nextPage, _ = p.Page()
 	}
 	nextPath = fmt.Sprintf("%s/page/%d", strings.TrimSuffix(ic.path, "/"), nextPage)
+	// Search snippets and sorting
+	var searchSorting *indexSearchSorting
+	if search != nil && search.text != "" {
+		if err = a.db.loadSearchSnippets(posts, search.text); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		searchPath := path.Dir(ic.path)
+		searchSorting = &indexSearchSorting{
+			byDate:    search.sort == searchSortDate,
+			relevance: path.Join(searchPath, searchEncode(withSearchSort(ic.search, searchSortRelevance))),
+			date:      path.Join(searchPath, searchEncode(withSearchSort(ic.search, searchSortDate))),
+		}
+	}
 	summaryTemplate := ic.summaryTemplate
 	if summaryTemplate == "" {
 		summaryTemplate = defaultSummary
@@ -422,6 +461,8 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 			summaryTemplate: summaryTemplate,
 			paramUrlQuery:   paramUrlQuery,
 			withoutFeeds:    ic.withoutFeeds,
+			searchSorting:   searchSorting,
+			webSub:          webSub,
 		},
 	})
 }
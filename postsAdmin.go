@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
+	"github.com/vcraescu/go-paginator/v2"
+)
+
+const (
+	editorPostsPath     = "/posts"
+	editorPostsBulkPath = editorPostsPath + "/bulk"
+
+	postsAdminPageSize = 50
+)
+
+// postsAdminFilter holds the filters of the admin post browser, read from the query string.
+type postsAdminFilter struct {
+	blog, section, taxonomy, taxonomyValue, from, to, search string
+	status                                                   postStatus
+	visibility                                               postVisibility
+}
+
+func (a *goBlog) postsAdminFilterFromRequest(r *http.Request) *postsAdminFilter {
+	q := r.URL.Query()
+	blog, _ := a.getBlog(r)
+	f := &postsAdminFilter{
+		blog:          blog,
+		section:       q.Get("section"),
+		taxonomy:      q.Get("taxonomy"),
+		taxonomyValue: q.Get("taxvalue"),
+		from:          q.Get("from"),
+		to:            q.Get("to"),
+		search:        q.Get("q"),
+		status:        postStatus(q.Get("status")),
+		visibility:    postVisibility(q.Get("visibility")),
+	}
+	if qBlog := q.Get("blog"); a.cfg.Blogs[qBlog] != nil {
+		f.blog = qBlog
+	}
+	if !validPostStatus(f.status) {
+		f.status = ""
+	}
+	if !validPostVisibility(f.visibility) {
+		f.visibility = ""
+	}
+	return f
+}
+
+func (f *postsAdminFilter) query() string {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"blog": f.blog, "section": f.section, "taxonomy": f.taxonomy, "taxvalue": f.taxonomyValue,
+		"from": f.from, "to": f.to, "q": f.search, "status": string(f.status), "visibility": string(f.visibility),
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+func (a *goBlog) postsAdminRequestConfig(f *postsAdminFilter) *postsRequestConfig {
+	c := &postsRequestConfig{
+		blogs: []string{f.blog},
+	}
+	if f.section != "" {
+		c.sections = []string{f.section}
+	}
+	if f.status != "" {
+		c.status = []postStatus{f.status}
+	}
+	if f.visibility != "" {
+		c.visibility = []postVisibility{f.visibility}
+	}
+	if f.taxonomy != "" && f.taxonomyValue != "" {
+		c.taxonomy = &configTaxonomy{Name: f.taxonomy}
+		c.taxonomyValue = f.taxonomyValue
+	}
+	if from, err := time.ParseInLocation(isoDateFormat, f.from, time.Local); err == nil {
+		c.publishedAfter = from
+	}
+	if to, err := time.ParseInLocation(isoDateFormat, f.to, time.Local); err == nil {
+		// Include the whole day
+		c.publishedBefore = to.AddDate(0, 0, 1)
+	}
+	if f.search != "" {
+		c.search = f.search
+	}
+	return c
+}
+
+func (a *goBlog) servePostsAdmin(w http.ResponseWriter, r *http.Request) {
+	_, bc := a.getBlog(r)
+	f := a.postsAdminFilterFromRequest(r)
+	adapter := &postPaginationAdapter{config: a.postsAdminRequestConfig(f), a: a}
+	p := paginator.New(adapter, postsAdminPageSize)
+	p.SetPage(stringToInt(chi.URLParam(r, "page")))
+	var posts []*post
+	if err := p.Results(&posts); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	count, _ := adapter.Nums()
+	// Navigation
+	basePath := bc.getRelativePath(editorPath + editorPostsPath)
+	pagePath := func(page int) string {
+		if page < 2 {
+			return basePath + f.query()
+		}
+		return fmt.Sprintf("%s/page/%d%s", basePath, page, f.query())
+	}
+	hasPrev, _ := p.HasPrev()
+	prevPage, _ := p.PrevPage()
+	hasNext, _ := p.HasNext()
+	nextPage, _ := p.NextPage()
+	currentPage, _ := p.Page()
+	a.render(w, r, a.renderPostsAdmin, &renderData{
+		Data: &postsAdminRenderData{
+			filter:  f,
+			posts:   posts,
+			count:   count,
+			hasPrev: hasPrev,
+			hasNext: hasNext,
+			prev:    pagePath(prevPage),
+			next:    pagePath(nextPage),
+			current: pagePath(currentPage),
+		},
+	})
+}
+
+const (
+	postsBulkSection     = "section"
+	postsBulkAddTax      = "addtax"
+	postsBulkRemoveTax   = "removetax"
+	postsBulkVisibility  = "visibility"
+	postsBulkDelete      = "delete"
+	postsBulkUndelete    = "undelete"
+	postsBulkWebmentions = "webmentions"
+)
+
+// bulkEditPost applies a bulk action to a single post using the normal update, delete and undelete functions, so hooks fire and caches get purged.
+func (a *goBlog) bulkEditPost(path, action string, form url.Values) error {
+	p, err := a.getPost(path)
+	if err != nil {
+		return err
+	}
+	oldStatus, oldVisibility := p.Status, p.Visibility
+	switch action {
+	case postsBulkSection:
+		section := form.Get("section")
+		if _, ok := a.getBlogFromPost(p).Sections[section]; !ok {
+			return errors.New("unknown section " + section)
+		}
+		if p.Section == section {
+			return nil
+		}
+		p.Section = section
+	case postsBulkAddTax, postsBulkRemoveTax:
+		taxonomy, value := form.Get("taxonomy"), strings.TrimSpace(form.Get("taxvalue"))
+		if taxonomy == "" || value == "" {
+			return errors.New("taxonomy and value required")
+		}
+		values := p.Parameters[taxonomy]
+		hasValue := slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+		if action == postsBulkAddTax {
+			if hasValue {
+				return nil
+			}
+			values = append(values, value)
+		} else {
+			if !hasValue {
+				return nil
+			}
+			values = lo.Reject(values, func(v string, _ int) bool { return strings.EqualFold(v, value) })
+		}
+		if p.Parameters == nil {
+			p.Parameters = map[string][]string{}
+		}
+		p.Parameters[taxonomy] = values
+	case postsBulkVisibility:
+		visibility := postVisibility(form.Get("visibility"))
+		if !validPostVisibility(visibility) {
+			return errors.New("invalid visibility")
+		}
+		if p.Visibility == visibility {
+			return nil
+		}
+		p.Visibility = visibility
+	case postsBulkDelete:
+		// Only mark as deleted, deleting an already deleted post again would remove it permanently
+		if p.Deleted() {
+			return nil
+		}
+		return a.deletePost(p.Path)
+	case postsBulkUndelete:
+		if !p.Deleted() {
+			return nil
+		}
+		return a.undeletePost(p.Path)
+	case postsBulkWebmentions:
+		a.postHooksWg.Go(func() {
+			if err := a.sendWebmentions(p); err != nil {
+				a.error("Failed to send webmentions", "path", p.Path, "err", err)
+			}
+		})
+		return nil
+	default:
+		return errors.New("unknown action")
+	}
+	return a.replacePost(p, p.Path, oldStatus, oldVisibility, false)
+}
+
+func (a *goBlog) servePostsAdminBulk(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paths := lo.Uniq(lo.Compact(r.PostForm["path"]))
+	if len(paths) == 0 {
+		a.serveError(w, r, "no posts selected", http.StatusBadRequest)
+		return
+	}
+	action := r.PostForm.Get("bulkaction")
+	var errs []error
+	for _, path := range paths {
+		if err := a.bulkEditPost(path, action, r.PostForm); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, bc := a.getBlog(r)
+	redirectTo := r.PostForm.Get("redir")
+	if !strings.HasPrefix(redirectTo, bc.getRelativePath(editorPath+editorPostsPath)) {
+		redirectTo = bc.getRelativePath(editorPath + editorPostsPath)
+	}
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
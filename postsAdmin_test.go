@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postsAdmin(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs["default"].Sections["notes"] = &configSection{Name: "notes", Title: "Notes"}
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/posts/one",
+		Section:    "posts",
+		Published:  "2024-01-10T10:00:00Z",
+		Content:    "First post about cats",
+		Parameters: map[string][]string{"title": {"One"}, "tags": {"Animals"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:       "/posts/two",
+		Section:    "posts",
+		Published:  "2024-02-10T10:00:00Z",
+		Content:    "Second post about dogs",
+		Parameters: map[string][]string{"title": {"Two"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/notes/three",
+		Section: "notes",
+		Status:  statusDraft,
+		Content: "A draft note",
+	}))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/editor/posts"+query, nil)
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		return rec
+	}
+	bulk := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/editor/posts/bulk", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		setLoggedIn(req, true)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		app.postHooksWg.Wait()
+		return rec
+	}
+	listed := func(query string) (paths []string) {
+		rec := get(query)
+		require.Equal(t, http.StatusOK, rec.Code)
+		for _, path := range []string{"/posts/one", "/posts/two", "/notes/three"} {
+			if regexp.MustCompile(`name="?path"? value="?` + regexp.QuoteMeta(path) + `[" ]`).MatchString(rec.Body.String()) {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+
+	t.Run("Filter", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"/posts/one", "/posts/two", "/notes/three"}, listed(""))
+		assert.ElementsMatch(t, []string{"/notes/three"}, listed("?status=draft"))
+		assert.ElementsMatch(t, []string{"/posts/one", "/posts/two"}, listed("?section=posts"))
+		assert.ElementsMatch(t, []string{"/posts/one"}, listed("?taxonomy=tags&taxvalue=animals"))
+		assert.ElementsMatch(t, []string{"/posts/two"}, listed("?from=2024-02-01&to=2024-02-10"))
+		assert.ElementsMatch(t, []string{"/posts/one"}, listed("?q=cats"))
+		assert.Contains(t, get("?status=draft").Body.String(), "Posts found: 1")
+		// Unauthenticated
+		req := httptest.NewRequest(http.MethodGet, "/editor/posts", nil)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.NotContains(t, rec.Body.String(), "/posts/one")
+	})
+
+	t.Run("Section", func(t *testing.T) {
+		rec := bulk(url.Values{"path": {"/posts/one", "/posts/two"}, "bulkaction": {"section"}, "section": {"notes"}, "redir": {"/editor/posts?section=notes"}})
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/editor/posts?section=notes", rec.Header().Get("Location"))
+		assert.ElementsMatch(t, []string{"/posts/one", "/posts/two", "/notes/three"}, listed("?section=notes"))
+
+		assert.Equal(t, http.StatusBadRequest, bulk(url.Values{"path": {"/posts/one"}, "bulkaction": {"section"}, "section": {"unknown"}}).Code)
+	})
+
+	t.Run("Taxonomy", func(t *testing.T) {
+		rec := bulk(url.Values{"path": {"/posts/one", "/posts/two"}, "bulkaction": {"addtax"}, "taxonomy": {"tags"}, "taxvalue": {"animals"}})
+		assert.Equal(t, http.StatusFound, rec.Code)
+		p, err := app.getPost("/posts/one")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Animals"}, p.Parameters["tags"])
+		p, err = app.getPost("/posts/two")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"animals"}, p.Parameters["tags"])
+
+		rec = bulk(url.Values{"path": {"/posts/one", "/posts/two"}, "bulkaction": {"removetax"}, "taxonomy": {"tags"}, "taxvalue": {"Animals"}})
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Empty(t, listed("?taxonomy=tags&taxvalue=animals"))
+	})
+
+	t.Run("Visibility", func(t *testing.T) {
+		assert.Equal(t, http.StatusFound, bulk(url.Values{"path": {"/posts/two"}, "bulkaction": {"visibility"}, "visibility": {"unlisted"}}).Code)
+		assert.ElementsMatch(t, []string{"/posts/two"}, listed("?visibility=unlisted"))
+		assert.Equal(t, http.StatusBadRequest, bulk(url.Values{"path": {"/posts/two"}, "bulkaction": {"visibility"}, "visibility": {"secret"}}).Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		var deleted atomic.Int32
+		app.pDeleteHooks = append(app.pDeleteHooks, func(*post) { deleted.Add(1) })
+
+		assert.Equal(t, http.StatusFound, bulk(url.Values{"path": {"/posts/one", "/notes/three"}, "bulkaction": {"delete"}}).Code)
+		assert.Equal(t, int32(2), deleted.Load())
+		assert.ElementsMatch(t, []string{"/posts/one"}, listed("?status=published-deleted"))
+		// Deleting again doesn't remove the posts permanently
+		assert.Equal(t, http.StatusFound, bulk(url.Values{"path": {"/posts/one"}, "bulkaction": {"delete"}}).Code)
+		_, err := app.getPost("/posts/one")
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusFound, bulk(url.Values{"path": {"/posts/one", "/notes/three"}, "bulkaction": {"undelete"}}).Code)
+		assert.ElementsMatch(t, []string{"/posts/one", "/posts/two"}, listed("?status=published"))
+		assert.ElementsMatch(t, []string{"/notes/three"}, listed("?status=draft"))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, bulk(url.Values{"bulkaction": {"delete"}}).Code)
+		assert.Equal(t, http.StatusBadRequest, bulk(url.Values{"path": {"/posts/one"}, "bulkaction": {"unknown"}}).Code)
+		// Redirects only to the post browser
+		rec := bulk(url.Values{"path": {"/posts/one"}, "bulkaction": {"undelete"}, "redir": {"https://example.org/"}})
+		assert.Equal(t, "/editor/posts", rec.Header().Get("Location"))
+	})
+}
@@ -195,7 +195,8 @@ func (a *goBlog) createOrReplacePost(p *post, o *postCreationOptions) error {
 	}
 	// Trigger hooks
 	if p.Status == statusPublished && (p.Visibility == visibilityPublic || p.Visibility == visibilityUnlisted) {
-		if o.new || o.oldStatus == statusScheduled || (o.oldStatus != statusPublished && o.oldVisibility != visibilityPublic && o.oldVisibility != visibilityUnlisted) {
+		// New if it wasn't published and visible before (e.g. a published draft or scheduled post)
+		if o.new || o.oldStatus != statusPublished || (o.oldVisibility != visibilityPublic && o.oldVisibility != visibilityUnlisted) {
 			defer a.postPostHooks(p)
 		} else {
 			defer a.postUpdateHooks(p)
@@ -207,6 +208,19 @@ func (a *goBlog) createOrReplacePost(p *post, o *postCreationOptions) error {
 	return nil
 }
 
+// savePostWithoutHooks checks and saves the post without triggering hooks like webmentions or ActivityPub
+func (a *goBlog) savePostWithoutHooks(p *post, o *postCreationOptions) error {
+	if err := a.checkPost(p, o.new, o.noUpdated); err != nil {
+		return err
+	}
+	if err := a.db.savePost(p, o); err != nil {
+		return err
+	}
+	a.purgeCache()
+	a.deleteReactionsCache(p.Path)
+	return nil
+}
+
 // Save check post to database
 func (db *database) savePost(p *post, o *postCreationOptions) error {
 	// Check
@@ -217,6 +231,11 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 	contentText := db.a.renderTextSafe(p.Content)
 	wc := wordCount(contentText)
 	cc := charCount(contentText)
+	// Hash a new password before acquiring the lock, only the hash is stored
+	passwordHash, err := takePostPasswordHash(p)
+	if err != nil {
+		return err
+	}
 	// Lock post creation
 	db.pcm.Lock()
 	defer db.pcm.Unlock()
@@ -237,7 +256,12 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 			return err
 		}
 	} else {
-		// Update post (path update cascades to post_parameters via foreign key)
+		// Keep the previous state as revision if not already tracked
+		if err := db.backfillPostRevision(o.oldPath); err != nil {
+			db.Exec("rollback")
+			return err
+		}
+		// Update post (path update cascades to post_parameters and post_revisions via foreign key)
 		if _, err := db.Exec(
 			"update posts set path = ?, content = ?, published = ?, updated = ?, blog = ?, section = ?, status = ?, visibility = ?, priority = ?, wordcount = ?, charcount = ? where path = ?",
 			p.Path, p.Content, toUTCSafe(p.Published), toUTCSafe(p.Updated), p.Blog, p.Section, p.Status, p.Visibility, p.Priority, wc, cc, o.oldPath,
@@ -251,6 +275,11 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 			return err
 		}
 	}
+	// Redirect from the old path if the post was renamed
+	if err := db.updatePostRedirects(o.oldPath, p.Path); err != nil {
+		db.Exec("rollback")
+		return err
+	}
 	if o.new {
 		// Insert all parameters for new posts
 		for param, values := range p.Parameters {
@@ -262,6 +291,18 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 			}
 		}
 	}
+	// Save password hash, keep the existing one if no new password is set
+	if passwordHash != "" {
+		if _, err := db.Exec("insert or replace into post_passwords (path, hash) values (?, ?)", p.Path, passwordHash); err != nil {
+			db.Exec("rollback")
+			return err
+		}
+	}
+	// Save revision
+	if err := db.savePostRevision(p, utcNowString()); err != nil {
+		db.Exec("rollback")
+		return err
+	}
 	// Commit transaction
 	if _, err := db.Exec("commit"); err != nil {
 		db.Exec("rollback")
@@ -328,6 +369,11 @@ func (db *database) updatePostParameters(p *post) error {
 }
 
 func (a *goBlog) deletePost(path string) error {
+	return a.deletePostAndParams(path)
+}
+
+// deletePostAndParams deletes the post like deletePost and removes the given parameters in the same transaction.
+func (a *goBlog) deletePostAndParams(path string, params ...string) error {
 	if path == "" {
 		return errors.New("path required")
 	}
@@ -362,11 +408,18 @@ func (a *goBlog) deletePost(path string) error {
 			p.Parameters = map[string][]string{}
 		}
 		p.Parameters["deleted"] = []string{deletedTime}
-		// Mark post as deleted
-		if _, err = a.db.Exec(
-			`begin;	update posts set status = ? where path = ?; delete from post_parameters where path = ? and parameter = 'deleted'; insert into post_parameters (path, parameter, value) values (?, 'deleted', ?); commit;`,
-			p.Status, p.Path, p.Path, p.Path, deletedTime,
-		); err != nil {
+		// Mark post as deleted and remove the parameters
+		sqlBuilder := builderpool.Get()
+		defer builderpool.Put(sqlBuilder)
+		sqlBuilder.WriteString(`begin;	update posts set status = ? where path = ?; delete from post_parameters where path = ? and parameter = 'deleted'; insert into post_parameters (path, parameter, value) values (?, 'deleted', ?);`)
+		sqlArgs := []any{p.Status, p.Path, p.Path, p.Path, deletedTime}
+		for _, param := range params {
+			sqlBuilder.WriteString("delete from post_parameters where path = ? and parameter = ?;")
+			sqlArgs = append(sqlArgs, p.Path, param)
+			delete(p.Parameters, param)
+		}
+		sqlBuilder.WriteString("commit;")
+		if _, err = a.db.Exec(sqlBuilder.String(), sqlArgs...); err != nil {
 			return err
 		}
 		// Rebuild FTS index
@@ -443,6 +496,60 @@ func (db *database) replacePostParam(path, param string, values []string) error
 	return nil
 }
 
+// postsParamChunkSize is the number of posts saved per transaction when replacing a parameter of many posts
+const postsParamChunkSize = 100
+
+// savePostsParam saves the values of a parameter of the given posts and records a revision for each post, so the change can be undone.
+// The posts are saved in chunks, so the lock isn't held too long.
+func (db *database) savePostsParam(param string, posts []*post) error {
+	for chunk := range slices.Chunk(posts, postsParamChunkSize) {
+		if err := db.savePostsParamChunk(param, chunk); err != nil {
+			return err
+		}
+	}
+	// Update FTS index
+	db.rebuildFTSIndex()
+	return nil
+}
+
+func (db *database) savePostsParamChunk(param string, posts []*post) error {
+	// Lock post creation
+	db.pcm.Lock()
+	defer db.pcm.Unlock()
+	// Begin transaction
+	if _, err := db.Exec("begin"); err != nil {
+		return err
+	}
+	revisionTime := utcNowString()
+	for _, p := range posts {
+		// Keep the previous state as revision if not already tracked
+		if err := db.backfillPostRevision(p.Path); err != nil {
+			db.Exec("rollback")
+			return err
+		}
+		if _, err := db.Exec("delete from post_parameters where path = ? and parameter = ?", p.Path, param); err != nil {
+			db.Exec("rollback")
+			return err
+		}
+		for _, value := range lo.Filter(p.Parameters[param], loStringNotEmpty) {
+			if _, err := db.Exec("insert into post_parameters (path, parameter, value) values (?, ?, ?)", p.Path, param, value); err != nil {
+				db.Exec("rollback")
+				return err
+			}
+		}
+		if err := db.savePostRevision(p, revisionTime); err != nil {
+			db.Exec("rollback")
+			return err
+		}
+	}
+	// Commit transaction
+	if _, err := db.Exec("commit"); err != nil {
+		db.Exec("rollback")
+		return err
+	}
+	return nil
+}
+
 type postsRequestConfig struct {
 	search                                      string
 	blogs                                       []string
@@ -452,6 +559,7 @@ type postsRequestConfig struct {
 	sections                                    []string
 	status                                      []postStatus
 	visibility                                  []postVisibility
+	taxonomyFilters                             []*postsTaxonomyFilter // filter for posts with all these taxonomy values (case-insensitive)
 	taxonomy                                    *configTaxonomy
 	taxonomyValue                               string
 	anyParams                                   []string   // filter for posts that have any of these parameters (with non-empty values)
@@ -462,16 +570,21 @@ type postsRequestConfig struct {
 	excludeParameter                            string     // exclude posts that have this parameter (with non-empty value)
 	excludeParameterValue                       string     // ... with exactly this value
 	publishedYear, publishedMonth, publishedDay int
-	publishedBefore                             time.Time
+	publishedBefore, publishedAfter             time.Time
 	randomOrder                                 bool
 	priorityOrder                               bool
 	ascendingOrder                              bool
+	relevanceOrder                              bool     // order by the relevance for the search
 	fetchWithoutParams                          bool     // fetch posts without parameters
 	fetchParams                                 []string // only fetch these parameters
 	withoutRenderedTitle                        bool     // fetch posts without rendered title
 	usesFile                                    string
 }
 
+type postsTaxonomyFilter struct {
+	taxonomy, value string
+}
+
 func buildPostsQuery(c *postsRequestConfig, selection string) (query string, args []any, err error) {
 	queryBuilder := builderpool.Get()
 	defer builderpool.Put(queryBuilder)
@@ -588,6 +701,15 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 		queryBuilder.WriteString(" and path in (select path from post_parameters where parameter = @taxname and lowerx(value) = lowerx(@taxval))")
 		args = append(args, sql.Named("taxname", c.taxonomy.Name), sql.Named("taxval", c.taxonomyValue))
 	}
+	for i, tf := range c.taxonomyFilters {
+		named := "taxfilter" + strconv.Itoa(i)
+		queryBuilder.WriteString(" and path in (select path from post_parameters where parameter = @")
+		queryBuilder.WriteString(named)
+		queryBuilder.WriteString("name and lowerx(value) = lowerx(@")
+		queryBuilder.WriteString(named)
+		queryBuilder.WriteString("val))")
+		args = append(args, sql.Named(named+"name", tf.taxonomy), sql.Named(named+"val", tf.value))
+	}
 	if len(c.sections) > 0 {
 		queryBuilder.WriteString(" and section in (")
 		for i, section := range c.sections {
@@ -617,6 +739,10 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 		queryBuilder.WriteString(" and toutc(published) < @publishedbefore")
 		args = append(args, sql.Named("publishedbefore", c.publishedBefore.UTC().Format(time.RFC3339)))
 	}
+	if !c.publishedAfter.IsZero() {
+		queryBuilder.WriteString(" and toutc(published) >= @publishedafter")
+		args = append(args, sql.Named("publishedafter", c.publishedAfter.UTC().Format(time.RFC3339)))
+	}
 	if c.usesFile != "" {
 		queryBuilder.WriteString(" and path in (select ps.path from posts_fts ps where ps.content MATCH '\"' || @usesfile || '\"' union all select pp.path from post_parameters pp where pp.value LIKE '%' || @usesfile || '%' )")
 		args = append(args, sql.Named("usesfile", c.usesFile))
@@ -625,6 +751,8 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 	queryBuilder.WriteString(" order by ")
 	if c.randomOrder {
 		queryBuilder.WriteString("random()")
+	} else if c.relevanceOrder && c.search != "" {
+		queryBuilder.WriteString("(select ps.rank from posts_fts(@search) ps where ps.rowid = posts.rowid), published desc")
 	} else {
 		if c.priorityOrder {
 			queryBuilder.WriteString("priority desc, published")
@@ -98,6 +98,8 @@ func (a *goBlog) feedHtml(w io.Writer, p *post) {
 	}
 	// Add post HTML
 	a.postHtmlToWriter(hb, &postHtmlOptions{p: p, absolute: true})
+	// Add series
+	hb.WriteUnescaped(a.postSeriesHtml(p))
 	// Add link to interactions and comments
 	blogConfig := a.getBlogFromPost(p)
 	if cc := blogConfig.Comments; cc != nil && cc.Enabled {
@@ -193,7 +195,7 @@ func (a *goBlog) postMfProperties(p *post, contentWithParams bool) map[string][]
 		mfVisibility = "public"
 	case visibilityUnlisted:
 		mfVisibility = "unlisted"
-	case visibilityPrivate:
+	case visibilityPrivate, visibilityProtected, visibilityLink:
 		mfVisibility = "private"
 	}
 
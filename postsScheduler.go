@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -14,6 +15,7 @@ func (a *goBlog) startPostsScheduler() {
 				return
 			case <-ticker.C:
 				a.checkScheduledPosts()
+				a.checkExpiredPosts()
 			}
 		}
 	}()
@@ -43,3 +45,80 @@ func (a *goBlog) checkScheduledPosts() {
 		a.info("Published scheduled post", "path", post.Path)
 	}
 }
+
+const (
+	postExpiresParam      = "expires"
+	postExpiryActionParam = "expiryaction"
+
+	postExpiryActionDraft    = "draft"
+	postExpiryActionUnlisted = "unlisted"
+	postExpiryActionDelete   = "delete"
+)
+
+// expiry returns the time the post expires or a zero time if it doesn't expire.
+func (p *post) expiry() time.Time {
+	return toLocalTime(p.firstParameter(postExpiresParam))
+}
+
+// expiryAction returns what happens with the post when it expires, defaults to turning it into a draft.
+func (p *post) expiryAction() string {
+	switch action := p.firstParameter(postExpiryActionParam); action {
+	case postExpiryActionUnlisted, postExpiryActionDelete:
+		return action
+	default:
+		return postExpiryActionDraft
+	}
+}
+
+func (a *goBlog) checkExpiredPosts() {
+	postsToExpire, err := a.getPosts(&postsRequestConfig{
+		status:    []postStatus{statusPublished},
+		parameter: postExpiresParam,
+	})
+	if err != nil {
+		a.error("Error getting expiring posts", "err", err)
+		return
+	}
+	now := time.Now()
+	for _, post := range postsToExpire {
+		if expiry := post.expiry(); expiry.IsZero() || expiry.After(now) {
+			continue
+		}
+		if err := a.expirePost(post); err != nil {
+			a.error("Error expiring post", "path", post.Path, "err", err)
+			continue
+		}
+		a.info("Expired post", "path", post.Path, "action", post.expiryAction())
+	}
+}
+
+func (a *goBlog) expirePost(p *post) error {
+	// Remove the expiry, so it isn't applied again when the post gets published or undeleted
+	if p.expiryAction() == postExpiryActionDelete {
+		// Soft-delete, triggers the delete hooks
+		return a.deletePostAndParams(p.Path, postExpiresParam, postExpiryActionParam)
+	}
+	oldStatus, oldVisibility := p.Status, p.Visibility
+	switch p.expiryAction() {
+	case postExpiryActionUnlisted:
+		p.Visibility = visibilityUnlisted
+	default:
+		p.Status = statusDraft
+	}
+	delete(p.Parameters, postExpiresParam)
+	delete(p.Parameters, postExpiryActionParam)
+	// Save like any other status or visibility change, so the regular hooks run
+	return a.replacePost(p, p.Path, oldStatus, oldVisibility, true)
+}
+
+// formatCountdown formats the remaining duration like "2d 3h 4m", the editor script keeps it updated.
+func formatCountdown(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Truncate(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -68,3 +69,80 @@ func Test_postsScheduler(t *testing.T) {
 	assert.Empty(t, p.Updated)
 
 }
+
+func Test_postsExpiry(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+
+	var mu sync.Mutex
+	deleted := []string{}
+	app.pDeleteHooks = append(app.pDeleteHooks, func(p *post) {
+		mu.Lock()
+		defer mu.Unlock()
+		deleted = append(deleted, p.Path)
+	})
+	updated := []string{}
+	app.pUpdateHooks = append(app.pUpdateHooks, func(p *post) {
+		mu.Lock()
+		defer mu.Unlock()
+		updated = append(updated, p.Path)
+	})
+
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	future := time.Now().Add(49 * time.Hour).UTC().Format(time.RFC3339)
+	for path, params := range map[string]map[string][]string{
+		"/posts/draft":    {"expires": {past}},
+		"/posts/unlisted": {"expires": {past}, "expiryaction": {"unlisted"}},
+		"/posts/delete":   {"expires": {past}, "expiryaction": {"delete"}},
+		"/posts/future":   {"expires": {future}},
+	} {
+		require.NoError(t, app.createPost(&post{
+			Path:       path,
+			Section:    "posts",
+			Content:    "Test",
+			Parameters: params,
+		}))
+	}
+	app.postHooksWg.Wait()
+
+	app.checkExpiredPosts()
+	app.postHooksWg.Wait()
+
+	p, err := app.getPost("/posts/draft")
+	require.NoError(t, err)
+	assert.Equal(t, statusDraft, p.Status)
+	assert.Empty(t, p.firstParameter("expires"))
+
+	p, err = app.getPost("/posts/unlisted")
+	require.NoError(t, err)
+	assert.Equal(t, statusPublished, p.Status)
+	assert.Equal(t, visibilityUnlisted, p.Visibility)
+	assert.Empty(t, p.firstParameter("expires"))
+	assert.Empty(t, p.firstParameter("expiryaction"))
+
+	p, err = app.getPost("/posts/delete")
+	require.NoError(t, err)
+	assert.True(t, p.Deleted())
+	assert.Empty(t, p.firstParameter("expires"))
+
+	p, err = app.getPost("/posts/future")
+	require.NoError(t, err)
+	assert.Equal(t, statusPublished, p.Status)
+	assert.Equal(t, visibilityPublic, p.Visibility)
+
+	// Only the deleted post triggers the delete hooks, the unlisted post is updated
+	assert.Equal(t, []string{"/posts/delete"}, deleted)
+	assert.Equal(t, []string{"/posts/unlisted"}, updated)
+
+	// Nothing happens on the next check
+	app.checkExpiredPosts()
+	app.postHooksWg.Wait()
+	assert.Len(t, deleted, 1)
+	assert.Len(t, updated, 1)
+
+	assert.Equal(t, "2d 1h 0m", formatCountdown(49*time.Hour+30*time.Second))
+	assert.Equal(t, "0d 0h 0m", formatCountdown(-time.Minute))
+}
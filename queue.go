@@ -77,6 +77,42 @@ func (a *goBlog) peekQueue(ctx context.Context, name string) (*queueItem, error)
 	return &qi, nil
 }
 
+func (a *goBlog) getQueueItems(name string) ([]*queueItem, error) {
+	rows, err := a.db.Query(
+		"select id, name, content, schedule from queue where name = @name order by schedule asc, id asc",
+		sql.Named("name", name),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*queueItem
+	for rows.Next() {
+		qi := &queueItem{}
+		var timeString string
+		if err := rows.Scan(&qi.id, &qi.name, &qi.content, &timeString); err != nil {
+			return nil, fmt.Errorf("scan queue item: %w", err)
+		}
+		if qi.schedule, err = dateparse.ParseIn(timeString, time.UTC); err != nil {
+			return nil, fmt.Errorf("parse schedule time: %w", err)
+		}
+		items = append(items, qi)
+	}
+	return items, rows.Err()
+}
+
+func (a *goBlog) setQueueSchedule(qi *queueItem, schedule time.Time) error {
+	_, err := a.db.Exec(
+		"update queue set schedule = @schedule where id = @id",
+		sql.Named("schedule", schedule.UTC().Format(time.RFC3339Nano)),
+		sql.Named("id", qi.id),
+	)
+	if err == nil {
+		qi.schedule = schedule
+	}
+	return err
+}
+
 func (a *goBlog) listenOnQueue(queueName string, wait time.Duration, process queueProcessFunc) {
 	if process == nil {
 		return
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	c "go.goblog.app/app/pkgs/cache"
+)
+
+// rateLimiter counts requests per key in fixed windows that start with the first request, the zero value is ready to use
+type rateLimiter struct {
+	init     sync.Once
+	mutex    sync.Mutex
+	requests *c.Cache[string, *int]
+}
+
+// limited counts the request and checks if there were more than limit requests for the key in the current window
+func (l *rateLimiter) limited(key string, limit int, window time.Duration) bool {
+	l.init.Do(func() {
+		l.requests = c.New[string, *int](time.Minute, 10000)
+	})
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	requests, ok := l.requests.Get(key)
+	if !ok {
+		requests = new(int)
+		l.requests.Set(key, requests, window, 1)
+	}
+	*requests++
+	return *requests > limit
+}
+
+// reset forgets all counted requests
+func (l *rateLimiter) reset() {
+	if l.requests != nil {
+		l.requests.Clear()
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/samber/lo"
+	c "go.goblog.app/app/pkgs/cache"
+)
+
+const (
+	defaultRelatedPostsCount = 5
+	relatedPostsCacheTTL     = 24 * time.Hour
+	// Number of significant terms of a post used for the full-text query
+	relatedPostsTerms = 15
+	// Number of full-text results considered for scoring
+	relatedPostsCandidates = 50
+)
+
+func (bc *configBlog) relatedPostsEnabled() bool {
+	return bc != nil && bc.RelatedPosts != nil && bc.RelatedPosts.Enabled
+}
+
+func (a *goBlog) initRelatedPosts() {
+	a.relatedPostsInit.Do(func() {
+		// Every change to a post can change the related posts of other posts, the cache gets cleared with the cache purge
+		a.relatedPostsCache = c.New[string, []string](time.Minute, 1000)
+	})
+}
+
+func (a *goBlog) clearRelatedPostsCache() {
+	if a.relatedPostsCache != nil {
+		a.relatedPostsCache.Clear()
+	}
+}
+
+// getRelatedPosts returns published public posts of the same blog that are similar to the post,
+// scored by full-text similarity and shared taxonomy values.
+func (a *goBlog) getRelatedPosts(p *post, count int) ([]*post, error) {
+	if count <= 0 {
+		count = defaultRelatedPostsCount
+	}
+	cacheKey := p.Path + "|" + strconv.Itoa(count)
+	if a.relatedPostsCache != nil {
+		if paths, cached := a.relatedPostsCache.Get(cacheKey); cached {
+			return a.getRelatedPostsByPaths(paths), nil
+		}
+	}
+	scores := map[string]float64{}
+	// Full-text similarity, better ranked results get a higher score (1 to near 0)
+	if query := a.relatedPostsQuery(p); query != "" {
+		rows, err := a.db.Query(
+			"select path from posts_fts where posts_fts match @query and path != @path and path in (select path from posts where blog = @blog and status = @status and visibility = @visibility) order by rank limit @limit",
+			sql.Named("query", query), sql.Named("blog", p.Blog), sql.Named("path", p.Path),
+			sql.Named("status", statusPublished), sql.Named("visibility", visibilityPublic), sql.Named("limit", relatedPostsCandidates),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var paths []string
+		for rows.Next() {
+			var path string
+			if err = rows.Scan(&path); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			paths = append(paths, path)
+		}
+		_ = rows.Close()
+		for i, path := range paths {
+			scores[path] += 1 - float64(i)/float64(len(paths))
+		}
+	}
+	// Shared taxonomy values, each one adds 1 to the score
+	if bc := a.getBlogFromPost(p); bc != nil {
+		for _, tax := range bc.Taxonomies {
+			values := p.Parameters[tax.Name]
+			if tax.Name == "" || len(values) == 0 {
+				continue
+			}
+			args := []any{sql.Named("tax", tax.Name), sql.Named("blog", p.Blog), sql.Named("path", p.Path), sql.Named("status", statusPublished), sql.Named("visibility", visibilityPublic)}
+			placeholders := make([]string, len(values))
+			for i, value := range values {
+				named := "value" + strconv.Itoa(i)
+				placeholders[i] = "lowerx(@" + named + ")"
+				args = append(args, sql.Named(named, value))
+			}
+			rows, err := a.db.Query(
+				"select path, count(distinct lowerx(value)) from post_parameters where parameter = @tax and lowerx(value) in ("+strings.Join(placeholders, ", ")+") and path != @path and path in (select path from posts where blog = @blog and status = @status and visibility = @visibility) group by path",
+				args...,
+			)
+			if err != nil {
+				return nil, err
+			}
+			for rows.Next() {
+				var path string
+				var shared int
+				if err = rows.Scan(&path, &shared); err != nil {
+					_ = rows.Close()
+					return nil, err
+				}
+				scores[path] += float64(shared)
+			}
+			_ = rows.Close()
+		}
+	}
+	// Sort by score, then path for stable results
+	paths := lo.Keys(scores)
+	slices.SortFunc(paths, func(x, y string) int {
+		return cmp.Or(cmp.Compare(scores[y], scores[x]), cmp.Compare(x, y))
+	})
+	if len(paths) > count {
+		paths = paths[:count]
+	}
+	if a.relatedPostsCache != nil {
+		a.relatedPostsCache.Set(cacheKey, paths, relatedPostsCacheTTL, 1)
+	}
+	return a.getRelatedPostsByPaths(paths), nil
+}
+
+func (a *goBlog) getRelatedPostsByPaths(paths []string) []*post {
+	posts := make([]*post, 0, len(paths))
+	for _, path := range paths {
+		// Skip posts that aren't published and public (anymore)
+		if rp, err := a.getPost(path); err == nil && rp.Status == statusPublished && rp.Visibility == visibilityPublic {
+			posts = append(posts, rp)
+		}
+	}
+	return posts
+}
+
+// relatedPostsQuery builds a full-text query with the most frequent significant terms of the post.
+func (a *goBlog) relatedPostsQuery(p *post) string {
+	text := strings.ToLower(p.Title() + " " + a.renderTextSafe(p.Content))
+	frequencies := map[string]int{}
+	for _, term := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		// Skip short words, they are mostly stop words
+		if len([]rune(term)) >= 4 {
+			frequencies[term]++
+		}
+	}
+	terms := lo.Keys(frequencies)
+	slices.SortFunc(terms, func(x, y string) int {
+		return cmp.Or(cmp.Compare(frequencies[y], frequencies[x]), cmp.Compare(x, y))
+	})
+	if len(terms) > relatedPostsTerms {
+		terms = terms[:relatedPostsTerms]
+	}
+	return strings.Join(lo.Map(terms, func(term string, _ int) string { return `"` + term + `"` }), " OR ")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_relatedPosts(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs["default"].RelatedPosts = &configRelatedPosts{Enabled: true, Count: 2}
+	require.NoError(t, app.initTemplateStrings())
+	app.initRelatedPosts()
+	app.d = app.buildRouter()
+
+	for _, p := range []*post{
+		{Path: "/posts/channels", Content: "Golang channels make concurrency simple. Channels connect goroutines.", Parameters: map[string][]string{"title": {"Channels"}, "tags": {"Go"}}},
+		{Path: "/posts/goroutines", Content: "Goroutines and channels are the building blocks of concurrency.", Parameters: map[string][]string{"title": {"Goroutines"}}},
+		{Path: "/posts/generics", Content: "Generics arrived late.", Parameters: map[string][]string{"title": {"Generics"}, "tags": {"go"}}},
+		{Path: "/posts/cooking", Content: "Pasta with tomato sauce.", Parameters: map[string][]string{"title": {"Cooking"}}},
+		{Path: "/posts/private", Content: "Channels, goroutines and concurrency.", Visibility: visibilityPrivate, Parameters: map[string][]string{"title": {"Private"}, "tags": {"Go"}}},
+	} {
+		p.Section = "posts"
+		require.NoError(t, app.createPost(p))
+	}
+	app.postHooksWg.Wait()
+
+	paths := func(posts []*post) []string {
+		return lo.Map(posts, func(p *post, _ int) string { return p.Path })
+	}
+
+	p, err := app.getPost("/posts/channels")
+	require.NoError(t, err)
+	related, err := app.getRelatedPosts(p, 5)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/posts/goroutines", "/posts/generics"}, paths(related))
+
+	t.Run("Cache", func(t *testing.T) {
+		_, cached := app.relatedPostsCache.Get("/posts/channels|5")
+		assert.True(t, cached)
+
+		require.NoError(t, app.createPost(&post{
+			Path:       "/posts/select",
+			Section:    "posts",
+			Content:    "Select waits on multiple channels in concurrency code with goroutines.",
+			Parameters: map[string][]string{"title": {"Select"}, "tags": {"Go"}},
+		}))
+		app.postHooksWg.Wait()
+
+		_, cached = app.relatedPostsCache.Get("/posts/channels|5")
+		assert.False(t, cached)
+
+		related, err := app.getRelatedPosts(p, 5)
+		require.NoError(t, err)
+		assert.Equal(t, "/posts/select", related[0].Path)
+		assert.NotContains(t, paths(related), "/posts/private")
+		assert.NotContains(t, paths(related), "/posts/cooking")
+	})
+
+	t.Run("Unpublished posts", func(t *testing.T) {
+		// Changing a post to draft doesn't trigger hooks, but clears the cache
+		_, err := app.getRelatedPosts(p, 5)
+		require.NoError(t, err)
+		gp, err := app.getPost("/posts/goroutines")
+		require.NoError(t, err)
+		gp.Status = statusDraft
+		require.NoError(t, app.replacePost(gp, gp.Path, statusPublished, visibilityPublic, false))
+		_, cached := app.relatedPostsCache.Get("/posts/channels|5")
+		assert.False(t, cached)
+
+		related, err := app.getRelatedPosts(p, 5)
+		require.NoError(t, err)
+		assert.NotContains(t, paths(related), "/posts/goroutines")
+
+		// Cached paths of posts that aren't public anymore are skipped
+		app.relatedPostsCache.Set("/posts/channels|5", []string{"/posts/goroutines", "/posts/private", "/posts/select"}, relatedPostsCacheTTL, 1)
+		related, err = app.getRelatedPosts(p, 5)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/posts/select"}, paths(related))
+
+		gp.Status = statusPublished
+		require.NoError(t, app.replacePost(gp, gp.Path, statusDraft, visibilityPublic, false))
+		app.postHooksWg.Wait()
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/channels", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "Related posts")
+		assert.Contains(t, body, `href=/posts/select`)
+		assert.Equal(t, 2, strings.Count(body[strings.Index(body, "Related posts"):], "<li>"))
+	})
+
+	t.Run("Plugins", func(t *testing.T) {
+		related, err := app.GetRelatedPosts("/posts/channels", 1)
+		require.NoError(t, err)
+		if assert.Len(t, related, 1) {
+			assert.Equal(t, "/posts/select", related[0].GetPath())
+		}
+	})
+}
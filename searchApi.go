@@ -0,0 +1,162 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vcraescu/go-paginator/v2"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+const (
+	searchApiPath         = "/api"
+	searchSuggestionsPath = "/suggestions"
+
+	searchSuggestionsLimit = 10
+
+	contentTypeSuggestions = "application/x-suggestions+json"
+)
+
+type searchApiResponse struct {
+	Query   string             `json:"query"`
+	Page    int                `json:"page"`
+	Pages   int                `json:"pages"`
+	Total   int64              `json:"total"`
+	Prev    string             `json:"prev,omitempty"`
+	Next    string             `json:"next,omitempty"`
+	Results []*searchApiResult `json:"results"`
+}
+
+type searchApiResult struct {
+	Path       string              `json:"path"`
+	URL        string              `json:"url"`
+	Title      string              `json:"title,omitempty"`
+	Snippet    string              `json:"snippet,omitempty"`
+	Published  string              `json:"published,omitempty"`
+	Updated    string              `json:"updated,omitempty"`
+	Taxonomies map[string][]string `json:"taxonomies,omitempty"`
+}
+
+// searchPostsConfig returns the config to query the posts matching the search query
+func (a *goBlog) searchPostsConfig(r *http.Request, q string) (*postsRequestConfig, *searchQuery) {
+	blog, bc := a.getBlog(r)
+	status, visibility := a.getDefaultPostStates(r)
+	config := &postsRequestConfig{
+		blogs:      []string{blog},
+		status:     status,
+		visibility: visibility,
+	}
+	search := a.parseSearchQuery(q, bc)
+	search.apply(config)
+	return config, search
+}
+
+// serveSearchApi serves the search results as JSON, the query uses the same syntax as the HTML search
+func (a *goBlog) serveSearchApi(w http.ResponseWriter, r *http.Request) {
+	_, bc := a.getBlog(r)
+	query := r.URL.Query()
+	q := cleanHTMLText(query.Get("q"))
+	if q == "" {
+		a.serveError(w, r, "missing search query", http.StatusBadRequest)
+		return
+	}
+	config, search := a.searchPostsConfig(r, q)
+	p := paginator.New(&postPaginationAdapter{config: config, a: a}, bc.Pagination)
+	p.SetPage(stringToInt(query.Get("page")))
+	var posts []*post
+	if err := p.Results(&posts); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := a.db.loadSearchSnippets(posts, search.text); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := &searchApiResponse{Query: q, Results: []*searchApiResult{}}
+	resp.Page, _ = p.Page()
+	resp.Pages, _ = p.PageNums()
+	resp.Total, _ = p.Nums()
+	pageUrl := func(page int) string {
+		return a.getFullAddress(r.Context().Value(pathKey).(string) + searchApiPath + "?" + url.Values{
+			"q": {q}, "page": {strconv.Itoa(page)},
+		}.Encode())
+	}
+	if hasPrev, _ := p.HasPrev(); hasPrev {
+		prevPage, _ := p.PrevPage()
+		resp.Prev = pageUrl(prevPage)
+	}
+	if hasNext, _ := p.HasNext(); hasNext {
+		nextPage, _ := p.NextPage()
+		resp.Next = pageUrl(nextPage)
+	}
+	for _, p := range posts {
+		result := &searchApiResult{
+			Path:      p.Path,
+			URL:       a.fullPostURL(p),
+			Title:     p.RenderedTitle,
+			Snippet:   a.searchResultSnippet(p),
+			Published: p.Published,
+			Updated:   p.Updated,
+		}
+		for _, tax := range bc.Taxonomies {
+			if values := p.Parameters[tax.Name]; len(values) > 0 {
+				if result.Taxonomies == nil {
+					result.Taxonomies = map[string][]string{}
+				}
+				result.Taxonomies[tax.Name] = values
+			}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	a.respondWithMinifiedJson(w, resp)
+}
+
+// serveSearchSuggestions serves OpenSearch suggestions for the search-as-you-type in browsers.
+// Spec: https://github.com/dewitt/opensearch/blob/master/mediawiki/Specifications/OpenSearch/Extensions/Suggestions/1.1/Draft%201.wiki
+func (a *goBlog) serveSearchSuggestions(w http.ResponseWriter, r *http.Request) {
+	q := cleanHTMLText(r.URL.Query().Get("q"))
+	completions, descriptions, urls := []string{}, []string{}, []string{}
+	if search := searchSuggestionsQuery(q); search != "" {
+		config, sq := a.searchPostsConfig(r, search)
+		config.limit = searchSuggestionsLimit
+		posts, err := a.getPosts(config)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err = a.db.loadSearchSnippets(posts, sq.text); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, p := range posts {
+			completions = append(completions, cmp.Or(p.RenderedTitle, a.fallbackTitle(p)))
+			descriptions = append(descriptions, a.searchResultSnippet(p))
+			urls = append(urls, a.fullPostURL(p))
+		}
+	}
+	w.Header().Set(contentType, contentTypeSuggestions+contenttype.CharsetUtf8Suffix)
+	_ = json.NewEncoder(w).Encode([]any{q, completions, descriptions, urls})
+}
+
+// searchSuggestionsQuery returns the query to find suggestions, the last unfinished term is searched as prefix
+func searchSuggestionsQuery(q string) string {
+	q = strings.TrimLeftFunc(q, unicode.IsSpace)
+	if last, _ := utf8.DecodeLastRuneInString(q); q == "" || unicode.IsSpace(last) || last == '"' || last == '*' {
+		return q
+	}
+	return q + "*"
+}
+
+// searchResultSnippet returns the search snippet as plain text or the summary if there's no match in the content
+func (a *goBlog) searchResultSnippet(p *post) string {
+	if s := p.searchSnippet; s != nil && strings.Contains(s.content, searchHighlightStart) {
+		return strings.NewReplacer(searchHighlightStart, "", searchHighlightEnd, "").Replace(a.renderTextSafe(s.content))
+	}
+	return a.postSummary(p)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_searchApi(t *testing.T) {
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	require.NoError(t, app.initConfig(false))
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+	bc.Search = &configSearch{Enabled: true, Path: "/search", Title: "Search"}
+	bc.Pagination = 1
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/first",
+		Section:    "posts",
+		Published:  "2023-05-01T10:00:00Z",
+		Content:    "A post about the query language. The query language is great.",
+		Parameters: map[string][]string{"title": {"Query language"}, "tags": {"Go", "Web"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:      "/second",
+		Section:   "posts",
+		Published: "2024-05-01T10:00:00Z",
+		Content:   "Another language post.",
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/draft",
+		Section: "posts",
+		Status:  statusDraft,
+		Content: "A language draft.",
+	}))
+
+	get := func(path string, q url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path+"?"+q.Encode(), nil)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Search API", func(t *testing.T) {
+		rec := get("/search"+searchApiPath, url.Values{"q": {"language"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(contentType), "application/json")
+
+		var resp searchApiResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "language", resp.Query)
+		assert.Equal(t, 1, resp.Page)
+		assert.Equal(t, 2, resp.Pages)
+		assert.EqualValues(t, 2, resp.Total)
+		assert.Empty(t, resp.Prev)
+		assert.Equal(t, "http://localhost:8080/search/api?page=2&q=language", resp.Next)
+		require.Len(t, resp.Results, 1)
+		result := resp.Results[0]
+		assert.Equal(t, "/first", result.Path)
+		assert.Equal(t, "http://localhost:8080/first", result.URL)
+		assert.Equal(t, "Query language", result.Title)
+		assert.Contains(t, result.Snippet, "The query language is great.")
+		assert.NotContains(t, result.Snippet, searchHighlightStart)
+		assert.Equal(t, "2023-05-01T10:00:00Z", result.Published)
+		assert.Equal(t, map[string][]string{"tags": {"Go", "Web"}}, result.Taxonomies)
+
+		rec = get("/search"+searchApiPath, url.Values{"q": {"language"}, "page": {"2"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		resp = searchApiResponse{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, "http://localhost:8080/search/api?page=1&q=language", resp.Prev)
+		assert.Empty(t, resp.Next)
+		require.Len(t, resp.Results, 1)
+		assert.Equal(t, "/second", resp.Results[0].Path)
+		assert.Empty(t, resp.Results[0].Taxonomies)
+
+		// Filters work like in the HTML search
+		rec = get("/search"+searchApiPath, url.Values{"q": {"tag:web"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		resp = searchApiResponse{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 1)
+		assert.Equal(t, "/first", resp.Results[0].Path)
+
+		rec = get("/search"+searchApiPath, url.Values{"q": {"nothing"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"results":[]`)
+
+		rec = get("/search"+searchApiPath, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Suggestions", func(t *testing.T) {
+		rec := get("/search"+searchSuggestionsPath, url.Values{"q": {"langu"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-suggestions+json; charset=utf-8", rec.Header().Get(contentType))
+
+		var resp []any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp, 4)
+		assert.Equal(t, "langu", resp[0])
+		assert.Equal(t, []any{"Query language", "Another language post."}, resp[1])
+		assert.Len(t, resp[2], 2)
+		assert.Equal(t, []any{"http://localhost:8080/first", "http://localhost:8080/second"}, resp[3])
+
+		rec = get("/search"+searchSuggestionsPath, url.Values{"q": {""}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `["",[],[],[]]`, rec.Body.String())
+	})
+
+	t.Run("OpenSearch description", func(t *testing.T) {
+		rec := get("/search/opensearch.xml", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `type="application/x-suggestions+json"`)
+		assert.Contains(t, rec.Body.String(), "http://localhost:8080/search/suggestions?q={searchTerms}")
+		assert.Contains(t, rec.Body.String(), "http://localhost:8080/search/api?q={searchTerms}")
+	})
+}
+
+func Test_searchSuggestionsQuery(t *testing.T) {
+	assert.Equal(t, "", searchSuggestionsQuery(" "))
+	assert.Equal(t, "quer*", searchSuggestionsQuery("quer"))
+	assert.Equal(t, "query ", searchSuggestionsQuery("query "))
+	assert.Equal(t, `"query language"`, searchSuggestionsQuery(`"query language"`))
+	assert.Equal(t, "größ*", searchSuggestionsQuery("größ"))
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.goblog.app/app/pkgs/builderpool"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+type searchSort string
+
+const (
+	searchSortRelevance searchSort = "relevance"
+	searchSortDate      searchSort = "date"
+)
+
+// searchQuery is a parsed search query like `tag:go type:reply after:2024-01 "query language"`
+type searchQuery struct {
+	text          string // FTS5 expression for the full-text search
+	sections      []string
+	taxonomies    []*postsTaxonomyFilter
+	types         []string // Parameters of the post types
+	before, after time.Time
+	sort          searchSort
+}
+
+// searchToken is a term, a quoted phrase or a filter (key:value) of the query
+type searchToken struct {
+	key, value      string
+	quoted, negated bool
+}
+
+func tokenizeSearchQuery(q string) (tokens []*searchToken) {
+	runes := []rune(q)
+	i := 0
+	// readQuoted reads until the closing quote, the opening quote is already consumed
+	readQuoted := func() string {
+		start := i
+		for i < len(runes) && runes[i] != '"' {
+			i++
+		}
+		value := string(runes[start:i])
+		i++ // Skip the closing quote
+		return value
+	}
+	for i < len(runes) {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		t := &searchToken{}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			t.negated = true
+			i++
+		}
+		if runes[i] == '"' {
+			i++
+			t.value, t.quoted = readQuoted(), true
+			tokens = append(tokens, t)
+			continue
+		}
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] == ':' && t.key == "" && i > start && i+1 < len(runes) && runes[i+1] == '"' {
+				// Filter with quoted value
+				t.key = string(runes[start:i])
+				i += 2
+				t.value, t.quoted = readQuoted(), true
+				break
+			}
+			i++
+		}
+		if t.key == "" {
+			t.value = string(runes[start:i])
+			if key, value, ok := strings.Cut(t.value, ":"); ok && key != "" && value != "" {
+				t.key, t.value = key, value
+			}
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// parseSearchQuery parses the query, filters that don't match the blog configuration are searched as text
+func (a *goBlog) parseSearchQuery(q string, bc *configBlog) *searchQuery {
+	sq := &searchQuery{}
+	var terms []string
+	isOperator := func(s string) bool { return s == "AND" || s == "OR" || s == "NOT" }
+	lastIsOperator := func() bool { return len(terms) > 0 && isOperator(terms[len(terms)-1]) }
+	for _, t := range tokenizeSearchQuery(q) {
+		if t.key != "" {
+			if !t.negated && sq.applyFilter(a, bc, strings.ToLower(t.key), t.value) {
+				continue
+			}
+			t.value = t.key + ":" + t.value
+		}
+		if !t.quoted && isOperator(t.value) {
+			// Operators are only allowed between terms
+			if len(terms) > 0 && !lastIsOperator() {
+				terms = append(terms, t.value)
+			}
+			continue
+		}
+		if t.value == "" {
+			continue
+		}
+		term := ftsString(t.value)
+		if prefix, ok := strings.CutSuffix(t.value, "*"); ok && !t.quoted && prefix != "" {
+			term = ftsString(prefix) + "*"
+		}
+		if t.negated {
+			// FTS5 can only exclude terms from other results
+			if len(terms) == 0 {
+				continue
+			}
+			if lastIsOperator() {
+				terms = terms[:len(terms)-1]
+			}
+			terms = append(terms, "NOT")
+		}
+		terms = append(terms, term)
+	}
+	if lastIsOperator() {
+		terms = terms[:len(terms)-1]
+	}
+	sq.text = strings.Join(terms, " ")
+	return sq
+}
+
+// applyFilter applies the filter and returns false if the filter is unknown or the value invalid
+func (sq *searchQuery) applyFilter(a *goBlog, bc *configBlog, key, value string) bool {
+	switch key {
+	case "section":
+		if _, ok := bc.Sections[value]; !ok {
+			return false
+		}
+		sq.sections = append(sq.sections, value)
+		return true
+	case "type":
+		param := map[string]string{
+			"reply":    a.cfg.Micropub.ReplyParam,
+			"like":     a.cfg.Micropub.LikeParam,
+			"bookmark": a.cfg.Micropub.BookmarkParam,
+			"photo":    a.cfg.Micropub.PhotoParam,
+		}[strings.ToLower(value)]
+		if param == "" {
+			return false
+		}
+		sq.types = append(sq.types, param)
+		return true
+	case "before", "after":
+		start, end, ok := parseSearchDate(value)
+		if !ok {
+			return false
+		}
+		if key == "before" {
+			sq.before = start
+		} else {
+			sq.after = end
+		}
+		return true
+	case "sort":
+		switch s := searchSort(strings.ToLower(value)); s {
+		case searchSortRelevance, searchSortDate:
+			sq.sort = s
+			return true
+		}
+		return false
+	}
+	// Taxonomies by name or the singular of the name, e.g. "tag:" for "tags"
+	for _, tax := range bc.Taxonomies {
+		if key == tax.Name || key+"s" == tax.Name {
+			sq.taxonomies = append(sq.taxonomies, &postsTaxonomyFilter{taxonomy: tax.Name, value: value})
+			return true
+		}
+	}
+	return false
+}
+
+// parseSearchDate parses a day, month or year and returns its start and the start of the following period
+func parseSearchDate(value string) (start, end time.Time, ok bool) {
+	for _, f := range []struct {
+		layout           string
+		years, months, d int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	} {
+		if t, err := time.ParseInLocation(f.layout, value, time.Local); err == nil {
+			return t, t.AddDate(f.years, f.months, f.d), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func ftsString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// apply sets the filters of the search query
+func (sq *searchQuery) apply(c *postsRequestConfig) {
+	c.search = sq.text
+	c.sections = append(c.sections, sq.sections...)
+	c.taxonomyFilters = append(c.taxonomyFilters, sq.taxonomies...)
+	c.anyParams = append(c.anyParams, sq.types...)
+	c.publishedBefore, c.publishedAfter = sq.before, sq.after
+	c.relevanceOrder = sq.text != "" && sq.sort != searchSortDate
+}
+
+var searchSortRegex = regexp.MustCompile(`(?i)(^|\s)sort:\S*`)
+
+// withSearchSort returns the query with the sort filter replaced
+func withSearchSort(q string, sort searchSort) string {
+	return strings.TrimSpace(searchSortRegex.ReplaceAllString(q, "")) + " sort:" + string(sort)
+}
+
+// Private use characters to mark the highlighted terms in snippets
+const (
+	searchHighlightStart = "\ue000"
+	searchHighlightEnd   = "\ue001"
+)
+
+// searchSnippet contains the title and an excerpt of the content with the search terms highlighted
+type searchSnippet struct {
+	title, content string
+}
+
+// loadSearchSnippets adds the highlighted snippets for the full-text search to the posts
+func (db *database) loadSearchSnippets(posts []*post, search string) error {
+	if search == "" || len(posts) == 0 {
+		return nil
+	}
+	queryBuilder := builderpool.Get()
+	defer builderpool.Put(queryBuilder)
+	queryBuilder.WriteString("select path, highlight(posts_fts, 1, @start, @end), snippet(posts_fts, 2, @start, @end, '…', 24) from posts_fts(@search) where path in (")
+	args := []any{sql.Named("search", search), sql.Named("start", searchHighlightStart), sql.Named("end", searchHighlightEnd)}
+	byPath := map[string]*post{}
+	for i, p := range posts {
+		if i > 0 {
+			queryBuilder.WriteString(", ")
+		}
+		named := "path" + strconv.Itoa(i)
+		queryBuilder.WriteString("@")
+		queryBuilder.WriteString(named)
+		args = append(args, sql.Named(named, p.Path))
+		byPath[p.Path] = p
+	}
+	queryBuilder.WriteString(")")
+	rows, err := db.Query(queryBuilder.String(), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		s := &searchSnippet{}
+		if err = rows.Scan(&path, &s.title, &s.content); err != nil {
+			return err
+		}
+		if p, ok := byPath[path]; ok {
+			p.searchSnippet = s
+		}
+	}
+	return rows.Err()
+}
+
+// writeSearchHighlighted writes the escaped text with the highlighted terms marked
+func writeSearchHighlighted(hb *htmlbuilder.HtmlBuilder, s string) {
+	for {
+		before, rest, found := strings.Cut(s, searchHighlightStart)
+		hb.WriteEscaped(strings.ReplaceAll(before, searchHighlightEnd, ""))
+		if !found {
+			return
+		}
+		marked, after, _ := strings.Cut(rest, searchHighlightEnd)
+		hb.WriteElementOpen("mark")
+		hb.WriteEscaped(marked)
+		hb.WriteElementClose("mark")
+		s = after
+	}
+}
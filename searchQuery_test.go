@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSearchQuery(t *testing.T) {
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	require.NoError(t, app.initConfig(false))
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+
+	t.Run("Terms and phrases", func(t *testing.T) {
+		sq := app.parseSearchQuery(`hello "query language" wor* -spam`, bc)
+		assert.Equal(t, `"hello" "query language" "wor"* NOT "spam"`, sq.text)
+		assert.Empty(t, sq.sort)
+
+		sq = app.parseSearchQuery(`OR first OR second AND -third OR`, bc)
+		assert.Equal(t, `"first" OR "second" NOT "third"`, sq.text)
+
+		// FTS5 syntax is escaped
+		sq = app.parseSearchQuery(`a"b (c) d:e`, bc)
+		assert.Equal(t, `"a""b" "(c)" "d:e"`, sq.text)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		sq := app.parseSearchQuery(`section:posts tag:"Go Lang" tags:web type:reply type:Photo before:2024-02-01 after:2023 sort:date text`, bc)
+		assert.Equal(t, `"text"`, sq.text)
+		assert.Equal(t, []string{"posts"}, sq.sections)
+		require.Len(t, sq.taxonomies, 2)
+		assert.Equal(t, &postsTaxonomyFilter{taxonomy: "tags", value: "Go Lang"}, sq.taxonomies[0])
+		assert.Equal(t, &postsTaxonomyFilter{taxonomy: "tags", value: "web"}, sq.taxonomies[1])
+		assert.Equal(t, []string{app.cfg.Micropub.ReplyParam, app.cfg.Micropub.PhotoParam}, sq.types)
+		assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local), sq.before)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), sq.after)
+		assert.Equal(t, searchSortDate, sq.sort)
+
+		c := &postsRequestConfig{}
+		sq.apply(c)
+		assert.Equal(t, `"text"`, c.search)
+		assert.Equal(t, sq.taxonomies, c.taxonomyFilters)
+		assert.Equal(t, sq.types, c.anyParams)
+		assert.False(t, c.relevanceOrder)
+
+		// Unknown filters and invalid values are searched as text
+		sq = app.parseSearchQuery(`section:unknown type:article after:yesterday foo:bar`, bc)
+		assert.Equal(t, `"section:unknown" "type:article" "after:yesterday" "foo:bar"`, sq.text)
+		assert.Empty(t, sq.sections)
+	})
+
+	t.Run("Sort", func(t *testing.T) {
+		assert.Equal(t, "tag:go sort:date", withSearchSort("tag:go", searchSortDate))
+		assert.Equal(t, "tag:go sort:relevance", withSearchSort("sort:date tag:go", searchSortRelevance))
+	})
+}
+
+func Test_searchResults(t *testing.T) {
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs[app.cfg.DefaultBlog].Search = &configSearch{Enabled: true, Path: "/search", Title: "Search"}
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/old",
+		Section:    "posts",
+		Published:  "2023-05-01T10:00:00Z",
+		Content:    "An old post about the query language. The query language is great, query language everywhere.",
+		Parameters: map[string][]string{"title": {"Query language"}, "tags": {"Go"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:       "/new",
+		Section:    "posts",
+		Published:  "2024-05-01T10:00:00Z",
+		Content:    "A new post mentioning the language once & <escaped>.",
+		Parameters: map[string][]string{"tags": {"Web"}, app.cfg.Micropub.ReplyParam: {"https://example.com/"}},
+	}))
+
+	search := func(q string) string {
+		req := httptest.NewRequest(http.MethodGet, "/search/"+searchEncode(q), nil)
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	t.Run("Snippets and relevance", func(t *testing.T) {
+		body := search("language")
+		assert.Contains(t, body, "Query <mark>language</mark></a></h2>")
+		assert.Contains(t, body, "the <mark>language</mark> once")
+		// HTML in the content isn't rendered
+		assert.NotContains(t, body, "<escaped>")
+		assert.NotContains(t, body, searchHighlightStart)
+		// The old post with more matches ranks first
+		assert.Less(t, strings.Index(body, `href=/old`), strings.Index(body, `href=/new`))
+		// Links to sort by date
+		assert.Contains(t, body, "<strong>Relevance</strong>")
+		assert.Contains(t, body, "/search/"+searchEncode("language sort:date"))
+	})
+
+	t.Run("Sort by date", func(t *testing.T) {
+		body := search("language sort:date")
+		assert.Less(t, strings.Index(body, `href=/new`), strings.Index(body, `href=/old`))
+		assert.Contains(t, body, "<strong>Date</strong>")
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		body := search("language tag:go")
+		assert.Contains(t, body, `href=/old`)
+		assert.NotContains(t, body, `href=/new`)
+
+		body = search("type:reply")
+		assert.NotContains(t, body, `href=/old`)
+		assert.Contains(t, body, `href=/new`)
+
+		body = search("language before:2024")
+		assert.Contains(t, body, `href=/old`)
+		assert.NotContains(t, body, `href=/new`)
+
+		body = search("language after:2023-12")
+		assert.NotContains(t, body, `href=/old`)
+		assert.Contains(t, body, `href=/new`)
+
+		body = search(`"old post" section:posts`)
+		assert.Contains(t, body, `href=/old`)
+		assert.NotContains(t, body, `href=/new`)
+	})
+}
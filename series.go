@@ -0,0 +1,173 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+const (
+	seriesParameter     = "series"
+	seriesPartParameter = "seriespart"
+	defaultSeriesPath   = "/series"
+)
+
+type postSeries struct {
+	name  string
+	posts []*post // Parts in order
+	index int     // Index of the current post, -1 if not part of the series
+}
+
+func (s *postSeries) prev() *post {
+	if s.index > 0 {
+		return s.posts[s.index-1]
+	}
+	return nil
+}
+
+func (s *postSeries) next() *post {
+	if s.index >= 0 && s.index < len(s.posts)-1 {
+		return s.posts[s.index+1]
+	}
+	return nil
+}
+
+func (bc *configBlog) seriesEnabled() bool {
+	return bc != nil && bc.Series != nil && bc.Series.Enabled
+}
+
+func (bc *configBlog) getSeriesPath(name string) string {
+	seriesPath := bc.getRelativePath(cmp.Or(bc.Series.Path, defaultSeriesPath))
+	if name == "" {
+		return seriesPath
+	}
+	return seriesPath + "/" + urlize(name)
+}
+
+// seriesPart returns the position of the post in the series, posts without position are ordered after numbered ones.
+func (p *post) seriesPart() int {
+	if part, err := strconv.Atoi(strings.TrimSpace(p.firstParameter(seriesPartParameter))); err == nil {
+		return part
+	}
+	return math.MaxInt
+}
+
+// getSeries returns all published public posts of a series, ordered by their position and publishing date.
+func (a *goBlog) getSeries(blog, name string) ([]*post, error) {
+	posts, err := a.getPosts(&postsRequestConfig{
+		blogs:          []string{blog},
+		parameter:      seriesParameter,
+		parameterValue: name,
+		status:         []postStatus{statusPublished},
+		visibility:     []postVisibility{visibilityPublic},
+		ascendingOrder: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(posts, func(a, b *post) int {
+		return cmp.Compare(a.seriesPart(), b.seriesPart())
+	})
+	return posts, nil
+}
+
+// postSeries returns the series of the post or nil if the post isn't part of a series.
+func (a *goBlog) postSeries(p *post) *postSeries {
+	name := p.firstParameter(seriesParameter)
+	if name == "" || !a.getBlogFromPost(p).seriesEnabled() {
+		return nil
+	}
+	posts, err := a.getSeries(p.Blog, name)
+	if err != nil || len(posts) == 0 {
+		return nil
+	}
+	return &postSeries{
+		name:  name,
+		posts: posts,
+		index: slices.IndexFunc(posts, func(sp *post) bool { return sp.Path == p.Path }),
+	}
+}
+
+// postSeriesHtml returns a paragraph mentioning the series of the post with absolute links, used for feeds and ActivityPub.
+func (a *goBlog) postSeriesHtml(p *post) string {
+	s := a.postSeries(p)
+	if s == nil || s.index < 0 {
+		return ""
+	}
+	bc := a.getBlogFromPost(p)
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	hb := htmlbuilder.NewHtmlBuilder(buf)
+	hb.WriteElementOpen("p")
+	hb.WriteEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(bc.Lang, "seriespart"), s.index+1, len(s.posts)))
+	hb.WriteEscaped(" ")
+	hb.WriteElementOpen("a", "href", a.getFullAddress(bc.getSeriesPath(s.name)))
+	hb.WriteEscaped(a.renderMdTitle(s.name))
+	hb.WriteElementClose("a")
+	hb.WriteElementClose("p")
+	return buf.String()
+}
+
+func (a *goBlog) serveSeriesIndex(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	names, err := a.db.allTaxonomyValues(blog, seriesParameter)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderSeries, &renderData{
+		Canonical: a.getFullAddress(bc.getSeriesPath("")),
+		Data: &seriesRenderData{
+			names: names,
+		},
+	})
+}
+
+func (a *goBlog) serveSeries(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	seriesParam := chi.URLParam(r, "series")
+	// Get series name from DB
+	row, err := a.db.QueryRow(
+		"select value from post_parameters where parameter = @param and urlize(value) = @series and path in (select path from posts where blog = @blog) limit 1",
+		sql.Named("param", seriesParameter), sql.Named("series", seriesParam), sql.Named("blog", blog),
+	)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var name string
+	if err = row.Scan(&name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			a.serve404(w, r)
+			return
+		}
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	posts, err := a.getSeries(blog, name)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(posts) == 0 {
+		a.serve404(w, r)
+		return
+	}
+	a.render(w, r, a.renderSeries, &renderData{
+		Canonical: a.getFullAddress(bc.getSeriesPath(name)),
+		Data: &seriesRenderData{
+			name:  name,
+			posts: posts,
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_series(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	app.cfg.ActivityPub = &configActivityPub{Enabled: true}
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs["default"].Series = &configSeries{Enabled: true}
+	require.NoError(t, app.initTemplateStrings())
+	require.NoError(t, app.initActivityPub())
+	app.d = app.buildRouter()
+
+	for _, p := range []*post{
+		{Path: "/posts/second", Published: "2021-01-01T10:00:00Z", Parameters: map[string][]string{"title": {"Second"}, "series": {"My Series"}, "seriespart": {"2"}}},
+		{Path: "/posts/first", Published: "2021-01-02T10:00:00Z", Parameters: map[string][]string{"title": {"First"}, "series": {"My Series"}, "seriespart": {"1"}}},
+		{Path: "/posts/third", Published: "2021-01-03T10:00:00Z", Parameters: map[string][]string{"title": {"Third"}, "series": {"My Series"}}},
+		{Path: "/posts/other", Published: "2021-01-04T10:00:00Z", Parameters: map[string][]string{"title": {"Other"}}},
+	} {
+		p.Section = "posts"
+		p.Content = "Content of " + p.Parameters["title"][0]
+		require.NoError(t, app.createPost(p))
+	}
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		body, _ := io.ReadAll(rec.Result().Body)
+		return rec.Code, string(body)
+	}
+
+	posts, err := app.getSeries("default", "My Series")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/posts/first", "/posts/second", "/posts/third"}, lo.Map(posts, func(p *post, _ int) string { return p.Path }))
+
+	t.Run("Post", func(t *testing.T) {
+		code, body := get("/posts/second")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "Part 2 of 3 of the series")
+		assert.Contains(t, body, `href=/series/my-series`)
+		assert.Contains(t, body, `href=/posts/first rel=prev`)
+		assert.Contains(t, body, `href=/posts/third rel=next`)
+
+		code, body = get("/posts/other")
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, body, "of the series")
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		code, body := get("/series")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "My Series")
+
+		code, body = get("/series/my-series")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Regexp(t, "(?s)First.*Second.*Third", body)
+		assert.NotContains(t, body, "Other")
+
+		code, _ = get("/series/unknown")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Feed and ActivityPub", func(t *testing.T) {
+		code, body := get("/posts.rss")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "https://example.com/series/my-series")
+
+		p, err := app.getPost("/posts/third")
+		require.NoError(t, err)
+		note := app.toAPNote(p)
+		assert.Contains(t, note.Content.First().Value, "Part 3 of 3 of the series")
+	})
+}
@@ -65,6 +65,16 @@ func (a *goBlog) initSessionStores() {
 			},
 			db: a.db,
 		}
+		a.postUnlockSessions = &dbSessionStore{
+			options: &sessions.Options{
+				Secure:   a.useSecureCookies(),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+				MaxAge:   int((30 * 24 * time.Hour).Seconds()),
+				Path:     "/", // Cookie for all pages
+			},
+			db: a.db,
+		}
 	})
 }
 
@@ -74,6 +74,12 @@ func (a *goBlog) serveSitemapBlogFeatures(w http.ResponseWriter, r *http.Request
 			Loc: a.getFullAddress(bc.getRelativePath(cmp.Or(pc.Path, defaultPhotosPath))),
 		})
 	}
+	// Series
+	if bc.seriesEnabled() {
+		sm.Add(&sitemap.URL{
+			Loc: a.getFullAddress(bc.getSeriesPath("")),
+		})
+	}
 	// Search
 	if bsc := bc.Search; bsc != nil && bsc.Enabled {
 		sm.Add(&sitemap.URL{
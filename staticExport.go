@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.goblog.app/app/pkgs/contenttype"
+	"go.goblog.app/app/pkgs/utils"
+)
+
+type staticExportOptions struct {
+	// Replace the public address in absolute links, empty to keep them
+	baseURL string
+}
+
+type staticExportResult struct {
+	Path   string
+	File   string
+	Status int
+}
+
+var (
+	staticExportSitemapLocRegex = regexp.MustCompile(`<loc>([^<]+)</loc>`)
+	staticExportCSSURLRegex     = regexp.MustCompile(`url\(['"]?([^'")]+)['"]?\)`)
+)
+
+// initStaticExport initializes the components needed to render pages.
+func (a *goBlog) initStaticExport() error {
+	for _, f := range []func() error{
+		a.initTemplateAssets, a.initTemplateStrings, a.initRegexRedirects,
+	} {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	a.initSessions()
+	a.reloadRouter()
+	return nil
+}
+
+// staticExport renders all public pages, feeds, assets and media files through the router and writes them to dir.
+// Crawling starts from the sitemap, dynamic endpoints like search, comments or Micropub are skipped.
+func (a *goBlog) staticExport(dir string, opts *staticExportOptions) ([]*staticExportResult, error) {
+	if a.isPrivate() {
+		return nil, errors.New("static export isn't possible in private mode")
+	}
+	dir = cmp.Or(dir, "static-export")
+	client := a.staticExportClient()
+	skip := a.staticExportSkippedPaths()
+	// Seed queue
+	queue := []string{sitemapPath, robotsTXTPath, profileImagePathJPEG, profileImagePathPNG}
+	for _, bc := range a.cfg.Blogs {
+		queue = append(queue, cmp.Or(bc.getRelativePath(""), "/"))
+	}
+	queue = append(queue, a.allAssetPaths()...)
+	queue = append(queue, allStaticPaths()...)
+	if files, err := a.mediaFiles(); err == nil {
+		for _, f := range files {
+			if u, ok := a.staticExportPath(f.Location); ok {
+				queue = append(queue, u)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var results []*staticExportResult
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if seen[p] || slices.ContainsFunc(skip, func(s string) bool { return p == s || strings.HasPrefix(p, strings.TrimSuffix(s, "/")+"/") }) {
+			continue
+		}
+		seen[p] = true
+		result, links, err := a.staticExportPage(client, dir, p, opts)
+		if err != nil {
+			return results, fmt.Errorf("failed to export %s: %w", p, err)
+		}
+		results = append(results, result)
+		queue = append(queue, links...)
+	}
+	return results, nil
+}
+
+// staticExportClient returns a client that renders using the router and doesn't follow redirects.
+func (a *goBlog) staticExportClient() *http.Client {
+	client := newHandlerClient(a.d)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
+
+// staticExportPage renders a single path, writes it to disk and returns the local links found in the response.
+func (a *goBlog) staticExportPage(client *http.Client, dir, p string, opts *staticExportOptions) (*staticExportResult, []string, error) {
+	result := &staticExportResult{Path: p}
+	req, err := http.NewRequest(http.MethodGet, a.getFullAddress(p), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.URL.Path = p
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	result.Status = resp.StatusCode
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get(contentType))
+	var links []string
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		// Write a redirect page, static hosting can't send redirects
+		location := resp.Header.Get("Location")
+		if lp, ok := a.staticExportPath(location); ok {
+			links = append(links, lp)
+		}
+		target := a.staticExportRewrite(a.getFullAddress(location), opts)
+		body = fmt.Appendf(nil, `<!doctype html><meta charset="utf-8"><meta http-equiv="refresh" content="0; url=%[1]s"><link rel="canonical" href="%[1]s">`, html.EscapeString(target))
+		mediaType = contenttype.HTML
+	case resp.StatusCode != http.StatusOK:
+		// Not found, gone, ...
+		return result, nil, nil
+	case mediaType == contenttype.HTML:
+		links = a.staticExportHTMLLinks(p, body)
+	case mediaType == contenttype.XML || strings.HasSuffix(p, ".xml"):
+		for _, m := range staticExportSitemapLocRegex.FindAllSubmatch(body, -1) {
+			if lp, ok := a.staticExportPath(html.UnescapeString(string(m[1]))); ok {
+				links = append(links, lp)
+			}
+		}
+	case mediaType == contenttype.CSS:
+		for _, m := range staticExportCSSURLRegex.FindAllSubmatch(body, -1) {
+			if lp, ok := a.staticExportResolve(p, string(m[1])); ok {
+				links = append(links, lp)
+			}
+		}
+	}
+	if staticExportIsText(mediaType) {
+		body = []byte(a.staticExportRewrite(string(body), opts))
+	}
+	// Pretty URLs for HTML pages
+	result.File = p
+	if mediaType == contenttype.HTML && path.Ext(p) != ".html" {
+		result.File = path.Join(p, "index.html")
+	}
+	if err := utils.SaveToFile(bytes.NewReader(body), filepath.Join(dir, filepath.FromSlash(result.File))); err != nil {
+		return nil, nil, err
+	}
+	return result, links, nil
+}
+
+func (a *goBlog) staticExportHTMLLinks(p string, body []byte) []string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	var links []string
+	add := func(ref string) {
+		if lp, ok := a.staticExportResolve(p, ref); ok {
+			links = append(links, lp)
+		}
+	}
+	doc.Find("a[href],link[href]").Each(func(_ int, s *goquery.Selection) {
+		add(s.AttrOr("href", ""))
+	})
+	doc.Find("[src]").Each(func(_ int, s *goquery.Selection) {
+		add(s.AttrOr("src", ""))
+	})
+	doc.Find("video[poster]").Each(func(_ int, s *goquery.Selection) {
+		add(s.AttrOr("poster", ""))
+	})
+	doc.Find("[srcset]").Each(func(_ int, s *goquery.Selection) {
+		for candidate := range strings.SplitSeq(s.AttrOr("srcset", ""), ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				add(fields[0])
+			}
+		}
+	})
+	return links
+}
+
+// staticExportResolve resolves a reference found on the page p and returns the local path.
+func (a *goBlog) staticExportResolve(p, ref string) (string, bool) {
+	base, err := url.Parse(a.getFullAddress(p))
+	if err != nil {
+		return "", false
+	}
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", false
+	}
+	return a.staticExportPath(u.String())
+}
+
+// staticExportPath returns the path without query and fragment if the URL belongs to the blog.
+func (a *goBlog) staticExportPath(rawURL string) (string, bool) {
+	u, err := url.Parse(a.getFullAddress(rawURL))
+	if err != nil || u.Hostname() != a.cfg.Server.publicHost {
+		return "", false
+	}
+	return cmp.Or(u.Path, "/"), true
+}
+
+func (a *goBlog) staticExportRewrite(s string, opts *staticExportOptions) string {
+	if opts == nil || opts.baseURL == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, a.cfg.Server.PublicAddress, strings.TrimSuffix(opts.baseURL, "/"))
+}
+
+// staticExportSkippedPaths returns the paths of dynamic endpoints that aren't exported.
+func (a *goBlog) staticExportSkippedPaths() []string {
+	skip := []string{
+		loginPath, logoutPath, micropubPath, indieAuthPath, webmentionPath, notificationsPath,
+		activityPubBasePath, webAuthnBasePath, "/.well-known", "/captcha", "/-/tiles", "/-/reactions",
+	}
+	for _, bc := range a.cfg.Blogs {
+		skip = append(skip, bc.getRelativePath(editorPath), bc.getRelativePath(settingsPath), bc.getRelativePath(commentPath))
+		if bsc := bc.Search; bsc != nil && bsc.Enabled {
+			skip = append(skip, bc.getRelativePath(cmp.Or(bsc.Path, defaultSearchPath)))
+		}
+		if rp := bc.RandomPost; rp != nil && rp.Enabled {
+			skip = append(skip, bc.getRelativePath(cmp.Or(rp.Path, defaultRandomPath)))
+		}
+		if otd := bc.OnThisDay; otd != nil && otd.Enabled {
+			skip = append(skip, bc.getRelativePath(cmp.Or(otd.Path, defaultOnThisDayPath)))
+		}
+		if cc := bc.Contact; cc != nil && cc.Enabled {
+			skip = append(skip, bc.getRelativePath(cmp.Or(cc.Path, defaultContactPath)))
+		}
+	}
+	return skip
+}
+
+func staticExportIsText(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") || mediaType == contenttype.JS ||
+		strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml")
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_staticExport(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	require.NoError(t, app.initConfig(false))
+	app.cfg.Blogs["default"].Search = &configSearch{Enabled: true}
+	require.NoError(t, app.initStaticExport())
+
+	media, err := app.saveMediaFile("abc.png", strings.NewReader("image"))
+	require.NoError(t, err)
+	require.NoError(t, app.createPost(&post{
+		Path:      "/posts/hello",
+		Section:   "posts",
+		Published: "2021-01-01T10:00:00Z",
+		Content:   "Hello World\n\n![Image](" + media + ")",
+		Parameters: map[string][]string{
+			"title":   {"Hello"},
+			"tags":    {"Test"},
+			"aliases": {"/old-hello"},
+		},
+	}))
+
+	dir := t.TempDir()
+	results, err := app.staticExport(dir, &staticExportOptions{baseURL: "https://mirror.example.org/"})
+	require.NoError(t, err)
+
+	paths := lo.Map(results, func(r *staticExportResult, _ int) string { return r.Path })
+	assert.Contains(t, paths, "/")
+	assert.Contains(t, paths, "/sitemap.xml")
+	assert.Contains(t, paths, "/posts/hello")
+	assert.Contains(t, paths, "/posts.rss")
+	assert.Contains(t, paths, "/tags/test")
+	assert.Contains(t, paths, "/m/abc.png")
+	assert.NotContains(t, paths, "/search")
+	assert.NotContains(t, paths, "/editor")
+
+	readFile := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	page := readFile("posts/hello/index.html")
+	assert.Contains(t, page, "Hello World")
+	assert.Contains(t, page, "https://mirror.example.org/m/abc.png")
+	assert.NotContains(t, page, "https://example.com")
+
+	assert.FileExists(t, filepath.Join(dir, "index.html"))
+	assert.Contains(t, readFile("sitemap.xml"), "https://mirror.example.org/sitemap-blog.xml")
+	assert.Contains(t, readFile("posts.rss"), "Hello")
+	assert.Equal(t, "image", readFile("m/abc.png"))
+
+	for _, asset := range app.allAssetPaths() {
+		assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(asset)))
+	}
+
+	t.Run("Redirects", func(t *testing.T) {
+		result, _, err := app.staticExportPage(app.staticExportClient(), dir, "/old-hello", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 302, result.Status)
+		assert.Contains(t, readFile("old-hello/index.html"), `url=https://example.com/posts/hello`)
+	})
+}
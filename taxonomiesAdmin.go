@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
+)
+
+const (
+	settingsTaxonomyPath       = "/taxonomy"
+	settingsTaxonomyRenamePath = "/rename"
+	settingsTaxonomyMergePath  = "/merge"
+	settingsTaxonomyDeletePath = "/delete"
+)
+
+type taxonomyValueCount struct {
+	value string
+	count int
+}
+
+// taxonomyValueCounts returns all values of a taxonomy with the number of posts using them, regardless of status or visibility.
+func (db *database) taxonomyValueCounts(blog, taxonomy string) ([]*taxonomyValueCount, error) {
+	rows, err := db.Query(
+		"select value, count(distinct path) from post_parameters where parameter = @tax and length(coalesce(value, '')) > 0 and path in (select path from posts where blog = @blog) group by value order by lowerx(value), value",
+		sql.Named("tax", taxonomy), sql.Named("blog", blog),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []*taxonomyValueCount
+	for rows.Next() {
+		v := &taxonomyValueCount{}
+		if err = rows.Scan(&v.value, &v.count); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// replaceTaxonomyValue replaces a taxonomy value with another one in all posts of the blog.
+// Replacing with an existing value merges both, replacing with an empty value deletes it.
+// Returns the affected posts.
+func (a *goBlog) replaceTaxonomyValue(blog, taxonomy, value, replacement string, sendUpdates bool) ([]*post, error) {
+	if value == "" {
+		return nil, errors.New("empty taxonomy value")
+	}
+	if value == replacement {
+		return nil, nil
+	}
+	posts, err := a.getPosts(&postsRequestConfig{
+		blogs:          []string{blog},
+		parameter:      taxonomy,
+		parameterValue: value,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		values := slices.Clone(p.Parameters[taxonomy])
+		for i, v := range values {
+			if v == value {
+				values[i] = replacement
+			}
+		}
+		p.Parameters[taxonomy] = lo.Uniq(lo.Filter(values, loStringNotEmpty))
+	}
+	if err = a.db.savePostsParam(taxonomy, posts); err != nil {
+		return nil, err
+	}
+	a.purgeCache()
+	if sendUpdates && a.apEnabled() {
+		for _, p := range posts {
+			if p.isPublishedSectionPost() && (p.Visibility == visibilityPublic || p.Visibility == visibilityUnlisted) {
+				a.postHooksWg.Go(func() { a.apUpdate(p) })
+			}
+		}
+	}
+	return posts, nil
+}
+
+func settingsTaxonomyAdminPath(tax *configTaxonomy) string {
+	return settingsPath + settingsTaxonomyPath + "/" + tax.Name
+}
+
+func (a *goBlog) settingsTaxonomy(r *http.Request) (string, *configBlog, *configTaxonomy) {
+	blog, bc := a.getBlog(r)
+	name := chi.URLParam(r, "taxonomy")
+	tax, ok := lo.Find(bc.Taxonomies, func(t *configTaxonomy) bool { return t.Name == name })
+	if !ok {
+		return blog, bc, nil
+	}
+	return blog, bc, tax
+}
+
+func (a *goBlog) serveTaxonomyAdmin(w http.ResponseWriter, r *http.Request) {
+	blog, _, tax := a.settingsTaxonomy(r)
+	if tax == nil {
+		a.serve404(w, r)
+		return
+	}
+	values, err := a.db.taxonomyValueCounts(blog, tax.Name)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderTaxonomyAdmin, &renderData{
+		Data: &taxonomyAdminRenderData{
+			taxonomy: tax,
+			values:   values,
+		},
+	})
+}
+
+func (a *goBlog) settingsTaxonomyAction(getReplacement func(r *http.Request) (string, bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		blog, bc, tax := a.settingsTaxonomy(r)
+		if tax == nil {
+			a.serve404(w, r)
+			return
+		}
+		value := r.FormValue("value")
+		replacement, ok := getReplacement(r)
+		if value == "" || !ok {
+			a.serveError(w, r, "Missing taxonomy value", http.StatusBadRequest)
+			return
+		}
+		if _, err := a.replaceTaxonomyValue(blog, tax.Name, value, replacement, r.FormValue("apupdate") == "on"); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, bc.getRelativePath(settingsTaxonomyAdminPath(tax)), http.StatusFound)
+	}
+}
+
+func (a *goBlog) settingsRenameTaxonomyValue() http.HandlerFunc {
+	return a.settingsTaxonomyAction(func(r *http.Request) (string, bool) {
+		newValue := strings.TrimSpace(r.FormValue("newvalue"))
+		return newValue, newValue != ""
+	})
+}
+
+func (a *goBlog) settingsMergeTaxonomyValue() http.HandlerFunc {
+	return a.settingsTaxonomyAction(func(r *http.Request) (string, bool) {
+		target := r.FormValue("target")
+		return target, target != ""
+	})
+}
+
+func (a *goBlog) settingsDeleteTaxonomyValue() http.HandlerFunc {
+	return a.settingsTaxonomyAction(func(*http.Request) (string, bool) {
+		return "", true
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_taxonomiesAdmin(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+
+	for path, tags := range map[string][]string{
+		"/posts/one":   {"Golang", "Blog"},
+		"/posts/two":   {"Go", "Blog"},
+		"/posts/three": {"Go", "Golang"},
+	} {
+		require.NoError(t, app.createPost(&post{
+			Path:       path,
+			Section:    "posts",
+			Content:    "Test",
+			Parameters: map[string][]string{"tags": tags},
+		}))
+	}
+
+	counts := func() map[string]int {
+		values, err := app.db.taxonomyValueCounts("default", "tags")
+		require.NoError(t, err)
+		return lo.SliceToMap(values, func(v *taxonomyValueCount) (string, int) { return v.value, v.count })
+	}
+	tags := func(path string) []string {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return p.Parameters["tags"]
+	}
+	action := func(handler http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("taxonomy", "tags")
+		req := httptest.NewRequest(http.MethodPost, "/settings/taxonomy/tags", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler(rec, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		return rec
+	}
+
+	assert.Equal(t, map[string]int{"Blog": 2, "Go": 2, "Golang": 2}, counts())
+
+	// Rename
+	rec := action(app.settingsRenameTaxonomyValue(), url.Values{"value": {"Blog"}, "newvalue": {"Blogging"}})
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/settings/taxonomy/tags", rec.Header().Get("Location"))
+	assert.Equal(t, map[string]int{"Blogging": 2, "Go": 2, "Golang": 2}, counts())
+	assert.ElementsMatch(t, []string{"Blogging", "Golang"}, tags("/posts/one"))
+
+	// Merge, posts with both values keep only one
+	rec = action(app.settingsMergeTaxonomyValue(), url.Values{"value": {"Golang"}, "target": {"Go"}})
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, map[string]int{"Blogging": 2, "Go": 3}, counts())
+	assert.Equal(t, []string{"Go"}, tags("/posts/three"))
+
+	// Delete
+	rec = action(app.settingsDeleteTaxonomyValue(), url.Values{"value": {"Blogging"}})
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, map[string]int{"Go": 3}, counts())
+	assert.Equal(t, []string{"Go"}, tags("/posts/one"))
+
+	// Every change is recorded as revision and can be undone
+	revisions, err := app.db.getPostRevisions("/posts/one")
+	require.NoError(t, err)
+	require.Len(t, revisions, 4)
+	assert.Equal(t, []string{"Go"}, revisions[0].Post.Parameters["tags"])
+	assert.ElementsMatch(t, []string{"Golang", "Blogging"}, revisions[2].Post.Parameters["tags"])
+	_, err = app.restorePostRevision(revisions[2].ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Golang", "Blogging"}, tags("/posts/one"))
+
+	// Missing values
+	rec = action(app.settingsRenameTaxonomyValue(), url.Values{"value": {"Go"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	t.Run("Many posts", func(t *testing.T) {
+		// More posts than saved per transaction
+		var posts []*post
+		for i := range postsParamChunkSize + 10 {
+			p := &post{Path: fmt.Sprintf("/posts/many-%d", i), Section: "posts", Content: "Test", Parameters: map[string][]string{"tags": {"Many"}}}
+			require.NoError(t, app.createPost(p))
+			posts = append(posts, p)
+		}
+		changed, err := app.replaceTaxonomyValue("default", "tags", "Many", "Lots", false)
+		require.NoError(t, err)
+		assert.Len(t, changed, len(posts))
+		assert.Equal(t, len(posts), counts()["Lots"])
+		assert.Zero(t, counts()["Many"])
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("taxonomy", "tags")
+		req := httptest.NewRequest(http.MethodGet, "/settings/taxonomy/tags", nil)
+		rec := httptest.NewRecorder()
+		app.serveTaxonomyAdmin(rec, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Go</a> (2)")
+		assert.Contains(t, rec.Body.String(), "/settings/taxonomy/tags/rename")
+
+		rctx = chi.NewRouteContext()
+		rctx.URLParams.Add("taxonomy", "unknown")
+		rec = httptest.NewRecorder()
+		app.serveTaxonomyAdmin(rec, req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
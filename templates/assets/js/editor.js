@@ -1,15 +1,180 @@
 (() => {
+    // Operational transformation, compatible with the operations of ot.js and the server:
+    // positive numbers retain, negative numbers delete and strings insert characters
+    const textOp = {
+        push: (op, c) => {
+            if (c === 0 || c === '') return op;
+            const last = op[op.length - 1];
+            if (typeof c === 'string' && typeof last === 'number' && last < 0) {
+                // Keep inserts before deletes
+                const beforeLast = op[op.length - 2];
+                if (typeof beforeLast === 'string') op[op.length - 2] = beforeLast + c;
+                else op.splice(op.length - 1, 0, c);
+            } else if (typeof c === 'string' && typeof last === 'string') {
+                op[op.length - 1] = last + c;
+            } else if (typeof c === 'number' && typeof last === 'number' && (c > 0) === (last > 0)) {
+                op[op.length - 1] = last + c;
+            } else {
+                op.push(c);
+            }
+            return op;
+        },
+        apply: (op, text) => {
+            let pos = 0, result = '';
+            for (const c of op) {
+                if (typeof c === 'string') {
+                    result += c;
+                } else if (c > 0) {
+                    result += text.slice(pos, pos + c);
+                    pos += c;
+                } else {
+                    pos -= c;
+                }
+            }
+            return result;
+        },
+        transformIndex: (op, index) => {
+            let newIndex = index, pos = 0;
+            for (const c of op) {
+                if (pos > index) break;
+                if (typeof c === 'string') {
+                    newIndex += c.length;
+                } else if (c > 0) {
+                    pos += c;
+                } else {
+                    newIndex -= Math.min(-c, index - pos);
+                    pos -= c;
+                }
+            }
+            return newIndex;
+        },
+        // Returns [a', b'], so that applying a and b' equals applying b and a', inserts of a go first
+        transform: (a, b) => {
+            const aPrime = [], bPrime = [];
+            const as = [...a], bs = [...b];
+            let ac = as.shift(), bc = bs.shift();
+            while (ac !== undefined || bc !== undefined) {
+                if (typeof ac === 'string') {
+                    textOp.push(aPrime, ac);
+                    textOp.push(bPrime, ac.length);
+                    ac = as.shift();
+                    continue;
+                }
+                if (typeof bc === 'string') {
+                    textOp.push(aPrime, bc.length);
+                    textOp.push(bPrime, bc);
+                    bc = bs.shift();
+                    continue;
+                }
+                if (ac === undefined || bc === undefined) throw new Error('Incompatible operations');
+                const n = Math.min(Math.abs(ac), Math.abs(bc));
+                if (ac > 0 && bc > 0) {
+                    textOp.push(aPrime, n);
+                    textOp.push(bPrime, n);
+                } else if (ac < 0 && bc > 0) {
+                    textOp.push(aPrime, -n);
+                } else if (ac > 0 && bc < 0) {
+                    textOp.push(bPrime, -n);
+                }
+                ac = ac > 0 ? ac - n : ac + n;
+                bc = bc > 0 ? bc - n : bc + n;
+                if (ac === 0) ac = as.shift();
+                if (bc === 0) bc = bs.shift();
+            }
+            return [aPrime, bPrime];
+        },
+        // Replaces the changed part between the common prefix and suffix
+        diff: (from, to) => {
+            let prefix = 0;
+            while (prefix < from.length && prefix < to.length && from[prefix] === to[prefix]) prefix++;
+            let suffix = 0;
+            while (suffix < from.length - prefix && suffix < to.length - prefix && from[from.length - 1 - suffix] === to[to.length - 1 - suffix]) suffix++;
+            // Don't split surrogate pairs
+            if (prefix > 0 && /[\uD800-\uDBFF]/.test(from[prefix - 1])) prefix--;
+            if (suffix > 0 && /[\uDC00-\uDFFF]/.test(from[from.length - suffix])) suffix--;
+            const op = [];
+            textOp.push(op, prefix);
+            textOp.push(op, to.slice(prefix, to.length - suffix));
+            textOp.push(op, -(from.length - prefix - suffix));
+            textOp.push(op, suffix);
+            return op;
+        },
+    };
+
     const setupWS = (element) => {
         if (!element.dataset.ws) return;
         const wsParams = new URLSearchParams(element.dataset.ws.split('?')[1]);
         const preview = wsParams.get('preview') === '1';
         const sync = wsParams.get('sync') === '1';
+        const collaborative = sync && wsParams.get('ot') === '1';
 
         const previewContainer = preview ? document.getElementById(element.dataset.preview) : null;
         if (preview && !previewContainer) return;
 
         let ws = null;
 
+        // Collaborative editing state: the revision and text known to the server (including the
+        // outstanding operation) and the operation that wasn't acknowledged yet
+        let rev = 0, shadow = '', outstanding = null, ready = false;
+
+        const send = (msg) => {
+            if (ws && ws.readyState === WebSocket.OPEN) {
+                ws.send(msg);
+                return true;
+            }
+            return false;
+        };
+
+        // Send local changes, only one operation is sent at a time
+        const flush = () => {
+            if (!ready || outstanding || element.value === shadow) return;
+            const op = textOp.diff(shadow, element.value);
+            if (send('op:' + JSON.stringify({ rev, op }))) {
+                outstanding = op;
+                shadow = element.value;
+            }
+        };
+
+        // Apply a remote operation and keep the cursor
+        const applyRemote = (op) => {
+            const { selectionStart, selectionEnd, scrollTop } = element;
+            const focused = document.activeElement === element;
+            element.value = textOp.apply(op, element.value);
+            if (focused) element.setSelectionRange(textOp.transformIndex(op, selectionStart), textOp.transformIndex(op, selectionEnd));
+            element.scrollTop = scrollTop;
+        };
+
+        const handleCollaborativeMessage = (msg) => {
+            if (msg.startsWith('doc:')) {
+                const doc = JSON.parse(msg.slice(4));
+                const known = shadow, local = element.value;
+                rev = doc.rev;
+                shadow = doc.text;
+                outstanding = null;
+                ready = true;
+                if (!(known === doc.text && local !== known)) {
+                    // Keep local changes made while disconnected if nothing changed on the server
+                    applyRemote(textOp.diff(local, doc.text));
+                }
+                flush();
+            } else if (msg.startsWith('ack:')) {
+                rev = JSON.parse(msg.slice(4)).rev;
+                outstanding = null;
+                flush();
+            } else if (msg.startsWith('op:')) {
+                const m = JSON.parse(msg.slice(3));
+                let op = m.op;
+                if (outstanding) {
+                    [outstanding, op] = textOp.transform(outstanding, op);
+                }
+                // Transform against local changes that weren't sent yet
+                const buffer = textOp.diff(shadow, element.value);
+                shadow = textOp.apply(op, shadow);
+                applyRemote(textOp.transform(buffer, op)[1]);
+                rev = m.rev;
+            }
+        };
+
         const openWS = () => {
             try {
                 ws = new WebSocket(`${window.location.protocol === "https:" ? "wss://" : "ws://"}${window.location.host}${element.dataset.ws}`);
@@ -26,21 +191,23 @@
                         previewContainer.innerHTML = '';
                     }
                     ws = null;
+                    ready = false;
+                    outstanding = null;
                     setTimeout(openWS, 1000);
                 };
 
                 ws.onmessage = (evt) => {
                     const msg = evt.data;
-                    if (sync && msg.startsWith("sync:")) {
+                    if (collaborative && (msg.startsWith("doc:") || msg.startsWith("ack:") || msg.startsWith("op:"))) {
+                        handleCollaborativeMessage(msg);
+                    } else if (sync && msg.startsWith("sync:")) {
                         element.value = msg.slice(5);
                     } else if (preview && msg.startsWith("preview:")) {
                         previewContainer.classList.add('preview');
                         previewContainer.classList.remove('hide');
                         previewContainer.innerHTML = msg.slice(8);
                     } else if (msg === "triggerpreview") {
-                        if (ws && ws.readyState === WebSocket.OPEN) {
-                            ws.send(element.value);
-                        }
+                        send(element.value);
                     }
                 };
 
@@ -59,17 +226,20 @@
         element.addEventListener('input', () => {
             clearTimeout(debounceTimeout);
             debounceTimeout = setTimeout(() => {
-                if (ws && ws.readyState === WebSocket.OPEN) {
-                    ws.send(element.value);
+                if (collaborative) {
+                    flush();
+                } else {
+                    send(element.value);
                 }
-            }, 500);
+            }, collaborative ? 100 : 500);
         });
 
-        element.form.addEventListener('submit', () => {
-            if (ws && ws.readyState === WebSocket.OPEN) {
-                ws.send('');
-            }
-        });
+        // Drafts of existing posts are removed after a successful update
+        if (!wsParams.get('path')) {
+            element.form.addEventListener('submit', () => {
+                send(collaborative ? 'reset' : '');
+            });
+        }
     };
 
     const setupGeoButton = () => {
@@ -105,7 +275,22 @@
         });
     };
 
+    const setupCountdown = (element) => {
+        const expiry = new Date(element.getAttribute('datetime'));
+        const update = () => {
+            const remaining = Math.max(0, Math.floor((expiry - Date.now()) / 1000));
+            const days = Math.floor(remaining / 86400);
+            const hours = Math.floor(remaining % 86400 / 3600);
+            const minutes = Math.floor(remaining % 3600 / 60);
+            const seconds = remaining % 60;
+            element.textContent = `${days}d ${hours}h ${minutes}m ${seconds}s`;
+            if (remaining > 0) setTimeout(update, 1000);
+        };
+        update();
+    };
+
     document.querySelectorAll('#editor-create, #editor-update').forEach(setupWS);
+    document.querySelectorAll('time.countdown').forEach(setupCountdown);
     setupGeoButton();
     setupTemplateButton();
 })();
\ No newline at end of file
@@ -0,0 +1,8 @@
+(function () {
+    // Show the placeholders of images while they are loading, the content security policy doesn't allow inline styles
+    Array.from(document.querySelectorAll('img[data-placeholder]')).forEach(img => {
+        if (img.complete) return
+        img.style.background = 'center/cover no-repeat url(' + img.dataset.placeholder + ')'
+        img.addEventListener('load', () => img.style.removeProperty('background'), { once: true })
+    })
+})()
@@ -3,6 +3,9 @@ package main
 import (
 	"cmp"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -186,6 +189,9 @@ func (a *goBlog) renderBase(hb *htmlbuilder.HtmlBuilder, rd *renderData, title,
 	}
 	// Footer
 	a.renderFooter(hb, rd)
+	// Image placeholders
+	hb.WriteElementOpen("script", "src", a.assetFileName("js/placeholders.js"), "defer", "")
+	hb.WriteElementClose("script")
 	// Easter egg
 	if rd.EasterEgg {
 		hb.WriteElementOpen("script", "src", a.assetFileName("js/easteregg.js"), "defer", "")
@@ -336,6 +342,12 @@ func (a *goBlog) renderSearch(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 			// Submit
 			hb.WriteElementOpen("input", "type", "submit", "value", "🔍 "+a.ts.GetTemplateStringVariant(rd.Blog.Lang, "search"))
 			hb.WriteElementClose("form")
+			// Query syntax
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("small")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "searchhelp"))
+			hb.WriteElementClose("small")
+			hb.WriteElementClose("p")
 			hb.WriteElementClose("main")
 		},
 	)
@@ -415,6 +427,14 @@ type indexRenderData struct {
 	paramUrlQuery      string
 	summaryTemplate    summaryTyp
 	withoutFeeds       bool
+	searchSorting      *indexSearchSorting
+	webSub             bool
+}
+
+// indexSearchSorting contains the paths of the search results sorted by relevance and by date
+type indexSearchSorting struct {
+	byDate          bool
+	relevance, date string
 }
 
 func (a *goBlog) renderIndex(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
@@ -438,6 +458,13 @@ func (a *goBlog) renderIndex(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 				hb.WriteElementOpen("link", "rel", "alternate", "type", "application/atom+xml", "title", "ATOM"+feedTitle, "href", a.getFullAddress(id.first+".atom")+id.paramUrlQuery)
 				hb.WriteElementOpen("link", "rel", "alternate", "type", "application/feed+json", "title", "JSON Feed"+feedTitle, "href", a.getFullAddress(id.first+".json")+id.paramUrlQuery)
 			}
+			// WebSub
+			if id.webSub {
+				for _, hub := range a.webSubHubs() {
+					hb.WriteElementOpen("link", "rel", "hub", "href", hub)
+				}
+				hb.WriteElementOpen("link", "rel", "self", "href", a.webSubTopicURL(id.first, noFeed))
+			}
 		},
 		func(hb *htmlbuilder.HtmlBuilder) {
 			hb.WriteElementOpen("main", "class", "h-feed")
@@ -457,6 +484,27 @@ func (a *goBlog) renderIndex(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 			if titleOrDesc {
 				hb.WriteElementOpen("hr")
 			}
+			// Search sorting
+			if ss := id.searchSorting; ss != nil && len(id.posts) > 0 {
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sortby"))
+				hb.WriteEscaped(": ")
+				sortLink := func(href, label string, current bool) {
+					if current {
+						hb.WriteElementOpen("strong")
+						hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, label))
+						hb.WriteElementClose("strong")
+						return
+					}
+					hb.WriteElementOpen("a", "href", href)
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, label))
+					hb.WriteElementClose("a")
+				}
+				sortLink(ss.relevance, "relevance", !ss.byDate)
+				hb.WriteEscaped(" • ")
+				sortLink(ss.date, "date", ss.byDate)
+				hb.WriteElementClose("p")
+			}
 			if len(id.posts) > 0 {
 				// Posts
 				for _, p := range id.posts {
@@ -861,6 +909,46 @@ func (a *goBlog) renderCaptcha(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 	)
 }
 
+type postUnlockRenderData struct {
+	path  string
+	wrong bool
+}
+
+func (a *goBlog) renderPostUnlock(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	urd, ok := rd.Data.(*postUnlockRenderData)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "protectedpost"))
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "protectedpost"))
+			hb.WriteElementClose("h1")
+			// Wrong password
+			if urd.wrong {
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("strong")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "wrongpassword"))
+				hb.WriteElementClose("strong")
+				hb.WriteElementClose("p")
+			}
+			// Form
+			hb.WriteElementOpen("form", "class", "fw p", "method", "post", "action", "/-"+postUnlockPath)
+			hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", urd.path)
+			hb.WriteElementOpen("input", "type", "password", "name", postPasswordParameter, "autocomplete", "current-password", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "password"), "required", "")
+			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "unlock"))
+			hb.WriteElementClose("form")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
 type taxonomyRenderData struct {
 	taxonomy    *configTaxonomy
 	valueGroups []stringGroup
@@ -911,6 +999,71 @@ func (a *goBlog) renderTaxonomy(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 	)
 }
 
+type seriesRenderData struct {
+	name  string   // Name of a single series
+	posts []*post  // Parts of a single series
+	names []string // Names of all series
+}
+
+func (a *goBlog) renderSeries(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	srd, ok := rd.Data.(*seriesRenderData)
+	if !ok {
+		return
+	}
+	title := a.renderMdTitle(cmp.Or(rd.Blog.Series.Title, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "series")))
+	if srd.name != "" {
+		title = a.renderMdTitle(srd.name)
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, title)
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(title)
+			hb.WriteElementClose("h1")
+			if srd.name == "" {
+				// Description
+				if rd.Blog.Series.Description != "" {
+					_ = a.renderMarkdownToWriter(hb, rd.Blog.Series.Description, false, rd.Blog.Lang)
+				}
+				// List of all series
+				hb.WriteElementOpen("ul")
+				for _, name := range srd.names {
+					hb.WriteElementOpen("li")
+					hb.WriteElementOpen("a", "href", rd.Blog.getSeriesPath(name))
+					hb.WriteEscaped(a.renderMdTitle(name))
+					hb.WriteElementClose("a")
+					hb.WriteElementClose("li")
+				}
+				hb.WriteElementClose("ul")
+			} else {
+				// Parts in order
+				hb.WriteElementOpen("ol")
+				for _, p := range srd.posts {
+					hb.WriteElementOpen("li")
+					hb.WriteElementOpen("a", "href", p.Path)
+					hb.WriteEscaped(cmp.Or(p.RenderedTitle, a.fallbackTitle(p)))
+					hb.WriteElementClose("a")
+					if published := toLocalTime(p.Published); !published.IsZero() {
+						hb.WriteEscaped(" (")
+						hb.WriteElementOpen("time", "datetime", published.Format(time.RFC3339))
+						hb.WriteEscaped(published.Format(isoDateFormat))
+						hb.WriteElementClose("time")
+						hb.WriteEscaped(")")
+					}
+					hb.WriteElementClose("li")
+				}
+				hb.WriteElementClose("ol")
+			}
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
 func (a *goBlog) renderPost(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 	p, ok := rd.Data.(*post)
 	if !ok {
@@ -979,11 +1132,15 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 			a.renderPostVideo(hb, p)
 			// GPS Track
 			a.renderPostTrack(hb, p, rd.Blog, false)
+			// Series
+			a.renderPostSeries(hb, p, rd.Blog)
 			// Taxonomies
 			a.renderPostTax(hb, p, rd.Blog)
 			hb.WriteElementClose("article")
 			// Author
 			a.renderAuthor(hb)
+			// Related posts
+			a.renderRelatedPosts(hb, p, rd.Blog)
 			hb.WriteElementClose("main")
 			// Reactions
 			a.renderPostReactions(hb, p)
@@ -1011,7 +1168,7 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 					hb.WriteElementClose("form")
 				}
 				// Change visibility
-				for _, visibility := range []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate} {
+				for _, visibility := range []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate, visibilityProtected, visibilityLink} {
 					if p.Visibility != visibility {
 						hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath("/editor"))
 						hb.WriteElementOpen("input", "type", "hidden", "name", "editoraction", "value", "visibility")
@@ -1021,6 +1178,26 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 						hb.WriteElementClose("form")
 					}
 				}
+				// Posting queue
+				if p.Status == statusDraft && rd.Blog.postingQueueEnabled() {
+					hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath(editorPath+editorPostingQueuePath))
+					hb.WriteElementOpen("input", "type", "hidden", "name", "action", "value", "add")
+					hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "addtoqueue"))
+					hb.WriteElementClose("form")
+				}
+				// Share links
+				if p.Visibility == visibilityProtected || p.Visibility == visibilityLink {
+					hb.WriteElementOpen("form", "method", "get", "action", rd.Blog.getRelativePath(editorPath+editorShareLinksPath))
+					hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sharelinks"))
+					hb.WriteElementClose("form")
+				}
+				// Revisions
+				hb.WriteElementOpen("form", "method", "get", "action", rd.Blog.getRelativePath(editorPath+editorRevisionsPath))
+				hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
+				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revisions"))
+				hb.WriteElementClose("form")
 				// TTS
 				if a.ttsEnabled() {
 					hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath("/editor"))
@@ -1146,7 +1323,8 @@ func (a *goBlog) renderIndieAuth(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 }
 
 type editorFilesRenderData struct {
-	files []*mediaFile
+	files   []*mediaFile
+	library map[string]*mediaLibraryFile
 }
 
 func (a *goBlog) renderEditorFiles(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
@@ -1174,6 +1352,9 @@ func (a *goBlog) renderEditorFiles(hb *htmlbuilder.HtmlBuilder, rd *renderData)
 				for _, f := range ef.files {
 					hb.WriteElementOpen("option", "value", f.Name)
 					hb.WriteEscaped(fmt.Sprintf("%s (%s), %s", f.Name, f.Time.Local().Format(isoDateFormat), mBytesString(f.Size)))
+					if lf, ok := ef.library[f.Name]; ok && lf.alt != "" {
+						hb.WriteEscaped(": " + truncateStringWithEllipsis(lf.alt, 50))
+					}
 					hb.WriteElementClose("option")
 				}
 				hb.WriteElementClose("select")
@@ -1182,6 +1363,11 @@ func (a *goBlog) renderEditorFiles(hb *htmlbuilder.HtmlBuilder, rd *renderData)
 					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "view"),
 					"formaction", rd.Blog.getRelativePath(editorPath+editorFileViewPath),
 				)
+				// Edit button
+				hb.WriteElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "edit"),
+					"formaction", rd.Blog.getRelativePath(editorPath+editorFileEditPath), "formmethod", "get",
+				)
 				// Uses button
 				hb.WriteElementOpen(
 					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "fileuses"),
@@ -1201,6 +1387,477 @@ func (a *goBlog) renderEditorFiles(hb *htmlbuilder.HtmlBuilder, rd *renderData)
 				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "nofiles"))
 				hb.WriteElementClose("p")
 			}
+			// Unused files
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(editorPath+editorFilesUnusedPath))
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "unusedfiles"))
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("p")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorFileEdit(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	f, ok := rd.Data.(*mediaLibraryFile)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, f.name)
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(f.name)
+			hb.WriteElementClose("h1")
+			// Preview
+			if strings.HasPrefix(f.mimeType, "image/") {
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("img", "src", f.location, "alt", f.alt, "loading", "lazy")
+				hb.WriteElementClose("p")
+			}
+			// Details
+			hb.WriteElementOpen("ul")
+			detail := func(key, value string) {
+				if value == "" {
+					return
+				}
+				hb.WriteElementOpen("li")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, key))
+				hb.WriteEscaped(": ")
+				hb.WriteEscaped(value)
+				hb.WriteElementClose("li")
+			}
+			hb.WriteElementOpen("li")
+			hb.WriteElementOpen("a", "href", f.location, "target", "_blank")
+			hb.WriteEscaped(f.location)
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("li")
+			if f.width > 0 && f.height > 0 {
+				detail("dimensions", fmt.Sprintf("%d × %d", f.width, f.height))
+			}
+			detail("mimetype", f.mimeType)
+			detail("contenthash", f.hash)
+			if f.uploaded != "" {
+				detail("uploadedon", toLocalSafe(f.uploaded))
+			}
+			hb.WriteElementClose("ul")
+			// Markdown
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "insertmarkdown"))
+			hb.WriteElementClose("p")
+			hb.WriteElementOpen("form", "class", "fw p")
+			hb.WriteElementOpen("input", "type", "text", "readonly", "", "value", f.mediaMarkdown())
+			hb.WriteElementClose("form")
+			// Alt text and caption
+			hb.WriteElementOpen("form", "method", "post", "class", "fw p")
+			hb.WriteElementOpen("input", "type", "hidden", "name", "filename", "value", f.name)
+			hb.WriteElementOpen("input", "type", "text", "name", "alt", "value", f.alt, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "alttext"))
+			hb.WriteElementOpen("input", "type", "text", "name", "caption", "value", f.caption, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "caption"))
+			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "update"))
+			hb.WriteElementClose("form")
+			// Back
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(editorPath+editorFilesPath))
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mediafiles"))
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("p")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorFilesUnused(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	ef, ok := rd.Data.(*editorFilesRenderData)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "unusedfiles"))
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "unusedfiles"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "unusedfilesdesc"))
+			hb.WriteElementClose("p")
+			if len(ef.files) > 0 {
+				hb.WriteElementOpen("form", "method", "post", "class", "fw p")
+				for _, f := range ef.files {
+					hb.WriteElementOpen("div")
+					hb.WriteElementOpen("input", "type", "checkbox", "name", "filename", "value", f.Name, "id", "unused-"+f.Name, "checked", "")
+					hb.WriteElementOpen("label", "for", "unused-"+f.Name)
+					hb.WriteElementOpen("a", "href", a.mediaFileLocation(f.Name), "target", "_blank")
+					hb.WriteEscaped(f.Name)
+					hb.WriteElementClose("a")
+					hb.WriteEscaped(fmt.Sprintf(" (%s), %s", f.Time.Local().Format(isoDateFormat), mBytesString(f.Size)))
+					hb.WriteElementClose("label")
+					hb.WriteElementClose("div")
+				}
+				hb.WriteElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"),
+					"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"),
+				)
+				hb.WriteElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+				hb.WriteElementClose("script")
+				hb.WriteElementClose("form")
+			} else {
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "nofiles"))
+				hb.WriteElementClose("p")
+			}
+			// Back
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(editorPath+editorFilesPath))
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mediafiles"))
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("p")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+type editorRevisionsRenderData struct {
+	post      *post
+	revisions []*postRevision
+	from, to  *postRevision
+	diff      []*revisionDiffLine
+}
+
+func (a *goBlog) renderEditorRevisions(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	rrd, ok := rd.Data.(*editorRevisionsRenderData)
+	if !ok {
+		return
+	}
+	revisionLabel := func(rev *postRevision) string {
+		return fmt.Sprintf("#%d, %s (%s, %s)", rev.ID, rev.Time, rev.Post.Status, rev.Post.Visibility)
+	}
+	revisionSelect := func(name string, selected *postRevision) {
+		hb.WriteElementOpen("select", "name", name)
+		for _, rev := range rrd.revisions {
+			hb.WriteElementOpen("option", "value", rev.ID, lo.If(rev == selected, "selected").Else(""), "")
+			hb.WriteEscaped(revisionLabel(rev))
+			hb.WriteElementClose("option")
+		}
+		hb.WriteElementClose("select")
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revisions"))
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revisions"))
+			hb.WriteElementClose("h1")
+			// Post
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", rrd.post.Path)
+			hb.WriteEscaped(cmp.Or(rrd.post.RenderedTitle, rrd.post.Path))
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("p")
+			if len(rrd.revisions) == 0 {
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "norevisions"))
+				hb.WriteElementClose("p")
+				hb.WriteElementClose("main")
+				return
+			}
+			// Compare form
+			hb.WriteElementOpen("form", "method", "get", "class", "fw p", "action", rd.Blog.getRelativePath(editorPath+editorRevisionsPath))
+			hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", rrd.post.Path)
+			revisionSelect("from", rrd.from)
+			revisionSelect("to", rrd.to)
+			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "compare"))
+			hb.WriteElementClose("form")
+			// Diff
+			a.renderLineDiff(hb, rrd.diff)
+			// Restore form
+			hb.WriteElementOpen("form", "method", "post", "class", "fw p", "action", rd.Blog.getRelativePath(editorPath+editorRevisionsRestorePath))
+			revisionSelect("revision", rrd.to)
+			hb.WriteElementOpen(
+				"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "restore"),
+				"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmrestore"),
+			)
+			hb.WriteElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.WriteElementClose("script")
+			hb.WriteElementClose("form")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+type editorShareLinksRenderData struct {
+	post   *post
+	tokens []*postToken
+}
+
+func (a *goBlog) renderEditorShareLinks(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	srd, ok := rd.Data.(*editorShareLinksRenderData)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sharelinks"))
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sharelinks"))
+			hb.WriteElementClose("h1")
+			// Post
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", srd.post.Path)
+			hb.WriteEscaped(cmp.Or(srd.post.RenderedTitle, srd.post.Path))
+			hb.WriteElementClose("a")
+			hb.WriteEscaped(" (" + string(srd.post.Visibility) + ")")
+			hb.WriteElementClose("p")
+			// Links
+			for _, t := range srd.tokens {
+				hb.WriteElementOpen("form", "method", "post", "class", "fw p", "action", rd.Blog.getRelativePath(editorPath+editorShareLinksRevokePath))
+				hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", srd.post.Path)
+				hb.WriteElementOpen("input", "type", "hidden", "name", postTokenQueryParam, "value", t.token)
+				hb.WriteElementOpen("input", "type", "text", "readonly", "", "value", a.postShareLink(srd.post, t.token))
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "expires") + ": ")
+				if t.expires == "" {
+					hb.WriteEscaped("-")
+				} else {
+					hb.WriteEscaped(toLocalSafe(t.expires))
+					if t.expired() {
+						hb.WriteEscaped(" (" + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "expired") + ")")
+					}
+				}
+				hb.WriteElementClose("p")
+				hb.WriteElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revoke"),
+					"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmrevoke"),
+				)
+				hb.WriteElementClose("form")
+			}
+			// Create form
+			hb.WriteElementOpen("h2")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "createsharelink"))
+			hb.WriteElementClose("h2")
+			hb.WriteElementOpen("form", "method", "post", "class", "fw p", "action", rd.Blog.getRelativePath(editorPath+editorShareLinksCreatePath))
+			hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", srd.post.Path)
+			hb.WriteElementOpen("label", "for", "sharelinkexpires")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "expires"))
+			hb.WriteElementClose("label")
+			hb.WriteElementOpen("input", "type", "datetime-local", "name", "expires", "id", "sharelinkexpires")
+			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "createsharelink"))
+			hb.WriteElementClose("form")
+			hb.WriteElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.WriteElementClose("script")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorPostingQueue(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	entries, ok := rd.Data.([]*postingQueueEntry)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueue"))
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueue"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueuedesc"))
+			hb.WriteElementClose("p")
+			if len(entries) == 0 {
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueueempty"))
+				hb.WriteElementClose("p")
+			}
+			// Queued posts
+			actionButton := func(entry *postingQueueEntry, action, title string) {
+				hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath(editorPath+editorPostingQueuePath))
+				hb.WriteElementOpen("input", "type", "hidden", "name", "action", "value", action)
+				hb.WriteElementOpen("input", "type", "hidden", "name", "id", "value", entry.id)
+				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, title))
+				hb.WriteElementClose("form")
+			}
+			for i, entry := range entries {
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("time", "datetime", entry.schedule.Local().Format(time.RFC3339))
+				hb.WriteEscaped(entry.schedule.Local().Format("Mon, 2006-01-02 15:04"))
+				hb.WriteElementClose("time")
+				hb.WriteEscaped(": ")
+				hb.WriteElementOpen("a", "href", entry.path)
+				if entry.post != nil {
+					hb.WriteEscaped(cmp.Or(entry.post.RenderedTitle, a.fallbackTitle(entry.post), entry.path))
+				} else {
+					hb.WriteEscaped(entry.path)
+				}
+				hb.WriteElementClose("a")
+				hb.WriteElementClose("p")
+				hb.WriteElementOpen("div", "class", "actions")
+				if i > 0 {
+					actionButton(entry, "up", "moveup")
+				}
+				if i < len(entries)-1 {
+					actionButton(entry, "down", "movedown")
+				}
+				actionButton(entry, "remove", "removefromqueue")
+				hb.WriteElementClose("div")
+			}
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+type postsAdminRenderData struct {
+	filter              *postsAdminFilter
+	posts               []*post
+	count               int64
+	hasPrev, hasNext    bool
+	prev, current, next string
+}
+
+func (a *goBlog) renderPostsAdmin(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	prd, ok := rd.Data.(*postsAdminRenderData)
+	if !ok {
+		return
+	}
+	f := prd.filter
+	fbc := a.cfg.Blogs[f.blog]
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "allposts"))
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "allposts"))
+			hb.WriteElementClose("h1")
+			// Select helper
+			selectField := func(name, selected, empty string, values []string) {
+				hb.WriteElementOpen("select", "name", name)
+				if empty != "" {
+					hb.WriteElementOpen("option", "value", "")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, empty))
+					hb.WriteElementClose("option")
+				}
+				for _, value := range values {
+					hb.WriteElementOpen("option", "value", value, lo.If(value == selected, "selected").Else(""), "")
+					hb.WriteEscaped(value)
+					hb.WriteElementClose("option")
+				}
+				hb.WriteElementClose("select")
+			}
+			blogs := slices.Sorted(maps.Keys(a.cfg.Blogs))
+			sections := slices.Sorted(maps.Keys(fbc.Sections))
+			taxonomies := lo.Map(fbc.Taxonomies, func(t *configTaxonomy, _ int) string { return t.Name })
+			statuses := []string{
+				string(statusPublished), string(statusDraft), string(statusScheduled),
+				string(statusPublishedDeleted), string(statusDraftDeleted), string(statusScheduledDeleted),
+			}
+			visibilities := []string{
+				string(visibilityPublic), string(visibilityUnlisted), string(visibilityPrivate),
+				string(visibilityProtected), string(visibilityLink),
+			}
+			// Filter form
+			hb.WriteElementOpen("form", "class", "fw p", "method", "get", "action", rd.Blog.getRelativePath(editorPath+editorPostsPath))
+			selectField("blog", f.blog, "", blogs)
+			selectField("section", f.section, "allsections", sections)
+			selectField("status", string(f.status), "allstatuses", statuses)
+			selectField("visibility", string(f.visibility), "allvisibilities", visibilities)
+			selectField("taxonomy", f.taxonomy, "", taxonomies)
+			hb.WriteElementOpen("input", "type", "text", "name", "taxvalue", "value", f.taxonomyValue, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomyvalue"))
+			hb.WriteElementOpen("label", "for", "filter-from")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "publishedfrom"))
+			hb.WriteElementClose("label")
+			hb.WriteElementOpen("input", "type", "date", "name", "from", "id", "filter-from", "value", f.from)
+			hb.WriteElementOpen("label", "for", "filter-to")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "publishedto"))
+			hb.WriteElementClose("label")
+			hb.WriteElementOpen("input", "type", "date", "name", "to", "id", "filter-to", "value", f.to)
+			hb.WriteElementOpen("input", "type", "search", "name", "q", "value", f.search, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "search"))
+			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "filter"))
+			hb.WriteElementClose("form")
+			// Count
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postsfound"), prd.count))
+			hb.WriteElementClose("p")
+			if len(prd.posts) == 0 {
+				hb.WriteElementClose("main")
+				return
+			}
+			// Posts with bulk actions
+			hb.WriteElementOpen("form", "class", "fw p", "method", "post", "action", rd.Blog.getRelativePath(editorPath+editorPostsBulkPath))
+			hb.WriteElementOpen("input", "type", "hidden", "name", "redir", "value", prd.current)
+			for i, p := range prd.posts {
+				id := fmt.Sprintf("post-%d", i)
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("input", "type", "checkbox", "name", "path", "value", p.Path, "id", id)
+				hb.WriteElementOpen("label", "for", id)
+				hb.WriteEscaped(cmp.Or(p.RenderedTitle, a.fallbackTitle(p), p.Path))
+				hb.WriteElementClose("label")
+				hb.WriteElementOpen("br")
+				hb.WriteElementOpen("small")
+				hb.WriteElementOpen("a", "href", p.Path)
+				hb.WriteEscaped(p.Path)
+				hb.WriteElementClose("a")
+				info := []string{string(p.Status), string(p.Visibility), p.Section}
+				if published := toLocalTime(p.Published); !published.IsZero() {
+					info = append(info, published.Format(isoDateFormat))
+				}
+				for _, value := range lo.Compact(info) {
+					hb.WriteEscaped(" · " + value)
+				}
+				hb.WriteElementClose("small")
+				hb.WriteElementClose("p")
+			}
+			// Bulk action
+			hb.WriteElementOpen("select", "name", "bulkaction")
+			for _, action := range []string{
+				postsBulkSection, postsBulkAddTax, postsBulkRemoveTax, postsBulkVisibility,
+				postsBulkDelete, postsBulkUndelete, postsBulkWebmentions,
+			} {
+				hb.WriteElementOpen("option", "value", action)
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkaction-"+action))
+				hb.WriteElementClose("option")
+			}
+			hb.WriteElementClose("select")
+			selectField("section", "", "", sections)
+			selectField("taxonomy", "", "", taxonomies)
+			hb.WriteElementOpen("input", "type", "text", "name", "taxvalue", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomyvalue"))
+			selectField("visibility", "", "", visibilities)
+			hb.WriteElementOpen(
+				"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "apply"),
+				"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmbulkaction"),
+			)
+			hb.WriteElementClose("form")
+			// Pagination
+			a.renderPagination(hb, rd.Blog, prd.hasPrev, prd.hasNext, prd.prev, prd.next)
+			// Scripts
+			hb.WriteElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.WriteElementClose("script")
 			hb.WriteElementClose("main")
 		},
 	)
@@ -1423,9 +2080,19 @@ func (a *goBlog) renderWebmentionAdmin(hb *htmlbuilder.HtmlBuilder, rd *renderDa
 }
 
 type editorRenderData struct {
-	updatePostUrl     string
-	updatePostContent string
-	presetParams      map[string][]string
+	updatePostUrl          string
+	updatePostPath         string
+	updatePostHash         string
+	updatePostContent      string
+	updatePostRedirects    []string
+	updatePostExpiry       time.Time
+	updatePostExpiryAction string
+	updatePostDraft        bool
+	updatePostConflict     *editorUpdateConflict
+	presetParams           map[string][]string
+	uploaded               string
+	uploadedMetadata       *mediaMetadata
+	uploadedFile           *mediaLibraryFile
 }
 
 func (a *goBlog) renderEditor(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
@@ -1465,7 +2132,7 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 				"class", "monospace h400p",
 				"id", "create-input",
 				"data-preview", "post-preview",
-				"data-ws", rd.Blog.getRelativePath("/editor/ws?preview=1&sync=1"),
+				"data-ws", rd.Blog.getRelativePath("/editor/ws?preview=1&sync=1&ot=1"),
 				"data-template", a.editorPostTemplate(rd.BlogString, rd.Blog, edrd.presetParams),
 			)
 			hb.WriteElementClose("textarea")
@@ -1482,16 +2149,51 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 				hb.WriteElementOpen("h2", "id", "update")
 				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "update"))
 				hb.WriteElementClose("h2")
+				// Discard the autosaved draft or the own changes
+				discardForm := func(title string) {
+					hb.WriteElementOpen("form", "method", "post", "class", "actions", "action", "#update")
+					hb.WriteElementOpen("input", "type", "hidden", "name", "editoraction", "value", "discarddraft")
+					hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", edrd.updatePostPath)
+					hb.WriteElementOpen(
+						"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, title),
+						"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdiscard"),
+					)
+					hb.WriteElementClose("form")
+				}
+				if conflict := edrd.updatePostConflict; conflict != nil {
+					hb.WriteElementOpen("div", "id", "update-conflict", "class", "p")
+					hb.WriteElementOpen("p")
+					hb.WriteElementOpen("strong")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "updateconflict"))
+					hb.WriteElementClose("strong")
+					hb.WriteElementClose("p")
+					hb.WriteElementOpen("p")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "updateconflictdesc"))
+					hb.WriteElementClose("p")
+					a.renderLineDiff(hb, conflict.diff)
+					discardForm("loadcurrentversion")
+					hb.WriteElementClose("div")
+				} else if edrd.updatePostDraft {
+					hb.WriteElementOpen("div", "id", "update-draft", "class", "p")
+					hb.WriteElementOpen("p")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "draftrestored"))
+					hb.WriteElementClose("p")
+					discardForm("discarddraft")
+					hb.WriteElementClose("div")
+				}
 				hb.WriteElementOpen("form", "method", "post", "class", "fw p", "action", "#update")
 				hb.WriteElementOpen("input", "type", "hidden", "name", "editoraction", "value", "updatepost")
 				hb.WriteElementOpen("input", "type", "hidden", "name", "url", "value", edrd.updatePostUrl)
+				hb.WriteElementOpen("input", "type", "hidden", "name", "hash", "value", edrd.updatePostHash)
 				hb.WriteElementOpen(
 					"textarea",
 					"id", "editor-update",
 					"name", "content",
 					"class", "monospace h400p",
 					"data-preview", "update-preview",
-					"data-ws", rd.Blog.getRelativePath("/editor/ws?preview=1"),
+					"data-ws", rd.Blog.getRelativePath("/editor/ws?"+url.Values{
+						"preview": {"1"}, "sync": {"1"}, "ot": {"1"}, "path": {edrd.updatePostPath}, "hash": {edrd.updatePostHash},
+					}.Encode()),
 				)
 				hb.WriteEscaped(edrd.updatePostContent)
 				hb.WriteElementClose("textarea")
@@ -1502,6 +2204,31 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 				hb.WriteElementClose("div")
 				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "update"))
 				hb.WriteElementClose("form")
+				// Old paths
+				if len(edrd.updatePostRedirects) > 0 {
+					hb.WriteElementOpen("p")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "oldpaths"))
+					hb.WriteElementClose("p")
+					hb.WriteElementOpen("ul", "id", "update-redirects")
+					for _, path := range edrd.updatePostRedirects {
+						hb.WriteElementOpen("li")
+						hb.WriteElementOpen("code")
+						hb.WriteEscaped(path)
+						hb.WriteElementClose("code")
+						hb.WriteElementClose("li")
+					}
+					hb.WriteElementClose("ul")
+				}
+				// Expiry countdown
+				if !edrd.updatePostExpiry.IsZero() {
+					hb.WriteElementOpen("p", "id", "update-expiry")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "expiresin") + " ")
+					hb.WriteElementOpen("time", "datetime", edrd.updatePostExpiry.Format(time.RFC3339), "class", "countdown")
+					hb.WriteEscaped(formatCountdown(time.Until(edrd.updatePostExpiry)))
+					hb.WriteElementClose("time")
+					hb.WriteEscaped(", " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "expiryaction-"+edrd.updatePostExpiryAction))
+					hb.WriteElementClose("p")
+				}
 			}
 
 			// Posts
@@ -1516,26 +2243,75 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 				hb.WriteElementClose("a")
 				hb.WriteElementClose("p")
 			}
+			// All posts
+			postsListLink(editorPath+editorPostsPath, "allposts")
 			// Drafts
 			postsListLink("/editor/drafts", "drafts")
 			// Private
 			postsListLink("/editor/private", "privateposts")
 			// Unlisted
 			postsListLink("/editor/unlisted", "unlistedposts")
+			// Password-protected and link-only
+			postsListLink("/editor/shared", "sharedposts")
+			// Posting queue
+			if rd.Blog.postingQueueEnabled() {
+				postsListLink(editorPath+editorPostingQueuePath, "postingqueue")
+			}
 			// Scheduled
 			postsListLink("/editor/scheduled", "scheduledposts")
 			// Deleted
 			postsListLink("/editor/deleted", "deletedposts")
 
 			// Upload
-			hb.WriteElementOpen("h2")
+			hb.WriteElementOpen("h2", "id", "upload")
 			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "upload"))
 			hb.WriteElementClose("h2")
 			hb.WriteElementOpen("form", "class", "fw p", "method", "post", "enctype", "multipart/form-data")
 			hb.WriteElementOpen("input", "type", "hidden", "name", "editoraction", "value", "upload")
 			hb.WriteElementOpen("input", "type", "file", "name", "file")
+			hb.WriteElementOpen("input", "type", "text", "name", "alt", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "alttextopt"))
+			hb.WriteElementOpen("input", "type", "text", "name", "caption", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "captionopt"))
 			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "upload"))
 			hb.WriteElementClose("form")
+			if edrd.uploaded != "" {
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "uploadedfile"))
+				hb.WriteEscaped(": ")
+				hb.WriteElementOpen("a", "href", edrd.uploaded, "target", "_blank")
+				hb.WriteEscaped(edrd.uploaded)
+				hb.WriteElementClose("a")
+				hb.WriteElementClose("p")
+				// Suggest the Markdown with the stored alt text and caption
+				if f := edrd.uploadedFile; f != nil {
+					hb.WriteElementOpen("p")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "insertmarkdown"))
+					hb.WriteElementClose("p")
+					hb.WriteElementOpen("form", "class", "fw p")
+					hb.WriteElementOpen("input", "id", "uploadedmarkdown", "type", "text", "readonly", "", "value", f.mediaMarkdown())
+					hb.WriteElementClose("form")
+				}
+				// Suggest the location stripped from the photo
+				if m := edrd.uploadedMetadata; m != nil && m.geo != "" {
+					hb.WriteElementOpen("p")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "photolocation"))
+					hb.WriteElementClose("p")
+					hb.WriteElementOpen("form", "class", "fw p")
+					hb.WriteElementOpen("input", "id", "photolocation", "type", "text", "readonly", "", "value", a.cfg.Micropub.LocationParam+": "+m.geo)
+					hb.WriteElementClose("form")
+					hb.WriteElementOpen("p")
+					newPostParams := url.Values{
+						"p:" + a.cfg.Micropub.PhotoParam:    {edrd.uploaded},
+						"p:" + a.cfg.Micropub.LocationParam: {m.geo},
+					}
+					if f := edrd.uploadedFile; f != nil && f.alt != "" {
+						newPostParams.Set("p:"+a.cfg.Micropub.PhotoDescriptionParam, f.alt)
+					}
+					hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(editorPath)+"?"+newPostParams.Encode())
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "newpostwithphoto"))
+					hb.WriteElementClose("a")
+					hb.WriteElementClose("p")
+				}
+			}
 			// Media files
 			hb.WriteElementOpen("p")
 			hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(editorPath+editorFilesPath))
@@ -1575,6 +2351,8 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 			// Script
 			hb.WriteElementOpen("script", "src", a.assetFileName("js/editor.js"), "defer", "")
 			hb.WriteElementClose("script")
+			hb.WriteElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.WriteElementClose("script")
 		},
 	)
 }
@@ -1662,6 +2440,12 @@ func (a *goBlog) renderSettings(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 			// Post sections
 			a.renderPostSectionSettings(hb, rd, srd)
 
+			// Taxonomies
+			a.renderTaxonomySettings(hb, rd)
+
+			// Backup
+			a.renderBackupSettings(hb, rd)
+
 			// Scripts
 			hb.WriteElementOpen("script", "src", a.assetFileName("js/settings.js"), "defer", "")
 			hb.WriteElementClose("script")
@@ -1675,6 +2459,98 @@ func (a *goBlog) renderSettings(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 	)
 }
 
+type taxonomyAdminRenderData struct {
+	taxonomy *configTaxonomy
+	values   []*taxonomyValueCount
+}
+
+func (a *goBlog) renderTaxonomyAdmin(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	tard, ok := rd.Data.(*taxonomyAdminRenderData)
+	if !ok {
+		return
+	}
+	title := cmp.Or(a.renderMdTitle(tard.taxonomy.Title), tard.taxonomy.Name)
+	basePath := rd.Blog.getRelativePath(settingsTaxonomyAdminPath(tard.taxonomy))
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HtmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, title)
+		},
+		func(hb *htmlbuilder.HtmlBuilder) {
+			hb.WriteElementOpen("main")
+			// Title
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(title)
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomiesdesc"))
+			hb.WriteElementClose("p")
+			// Values
+			for i, tv := range tard.values {
+				hb.WriteElementOpen("details")
+				hb.WriteElementOpen("summary")
+				hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(fmt.Sprintf("/%s/%s", tard.taxonomy.Name, urlize(tv.value))))
+				hb.WriteEscaped(tv.value)
+				hb.WriteElementClose("a")
+				hb.WriteEscaped(fmt.Sprintf(" (%d)", tv.count))
+				hb.WriteElementClose("summary")
+
+				hb.WriteElementOpen("form", "class", "fw p", "method", "post")
+				hb.WriteElementOpen("input", "type", "hidden", "name", "value", "value", tv.value)
+				// Rename
+				hb.WriteElementOpen("input", "type", "text", "name", "newvalue", "value", tv.value, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "newvalue"))
+				hb.WriteElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "rename"),
+					"formaction", basePath+settingsTaxonomyRenamePath,
+				)
+				// Merge
+				if len(tard.values) > 1 {
+					hb.WriteElementOpen("select", "name", "target")
+					hb.WriteElementOpen("option", "value", "")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mergeinto"))
+					hb.WriteElementClose("option")
+					for _, target := range tard.values {
+						if target == tv {
+							continue
+						}
+						hb.WriteElementOpen("option", "value", target.value)
+						hb.WriteEscaped(target.value)
+						hb.WriteElementClose("option")
+					}
+					hb.WriteElementClose("select")
+					hb.WriteElementOpen(
+						"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "merge"),
+						"formaction", basePath+settingsTaxonomyMergePath,
+					)
+				}
+				// ActivityPub updates
+				if a.apEnabled() {
+					id := fmt.Sprintf("apupdate-%d", i)
+					hb.WriteElementOpen("input", "type", "checkbox", "name", "apupdate", "id", id)
+					hb.WriteElementOpen("label", "for", id)
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sendapupdates"))
+					hb.WriteElementClose("label")
+				}
+				// Delete
+				hb.WriteElementOpen("div", "class", "p")
+				hb.WriteElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"),
+					"formaction", basePath+settingsTaxonomyDeletePath,
+					"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"),
+				)
+				hb.WriteElementClose("div")
+
+				hb.WriteElementClose("form")
+				hb.WriteElementClose("details")
+			}
+			// Scripts
+			hb.WriteElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.WriteElementClose("script")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
 type activityPubFollowersRenderData struct {
 	apUser    string
 	followers []*apFollower
@@ -49,7 +49,11 @@ func (a *goBlog) renderSummary(origHb *htmlbuilder.HtmlBuilder, rd *renderData,
 		// Has title
 		hb.WriteElementOpen("h2", "class", "p-name")
 		hb.WriteElementOpen("a", "class", "u-url", "href", p.Path)
-		hb.WriteEscaped(p.RenderedTitle)
+		if s := p.searchSnippet; s != nil && strings.Contains(s.title, searchHighlightStart) {
+			writeSearchHighlighted(hb, a.renderMdTitle(s.title))
+		} else {
+			hb.WriteEscaped(p.RenderedTitle)
+		}
 		hb.WriteElementClose("a")
 		hb.WriteElementClose("h2")
 	}
@@ -61,11 +65,26 @@ func (a *goBlog) renderSummary(origHb *htmlbuilder.HtmlBuilder, rd *renderData,
 			hb.WriteElementOpen("img", "src", photo, "class", "u-photo")
 			hb.WriteElementClose("img")
 			hb.WriteElementClose("p")
+			// Show EXIF metadata
+			if pc := bc.Photos; pc != nil && pc.ShowExif {
+				if m, _ := a.db.getMediaMetadata(photo); m != nil && m.summary() != "" {
+					hb.WriteElementOpen("p")
+					hb.WriteElementOpen("small")
+					hb.WriteEscaped(m.summary())
+					hb.WriteElementClose("small")
+					hb.WriteElementClose("p")
+				}
+			}
 		}
 	}
 	// Post meta
 	a.renderPostMeta(hb, p, bc, "summary")
-	if typ != photoSummary && a.showFull(p) {
+	if s := p.searchSnippet; s != nil && strings.Contains(s.content, searchHighlightStart) {
+		// Show the search result snippet
+		hb.WriteElementOpen("p", "class", "p-summary")
+		writeSearchHighlighted(hb, a.renderTextSafe(s.content))
+		hb.WriteElementClose("p")
+	} else if typ != photoSummary && a.showFull(p) {
 		// Show full content
 		a.postHtmlToWriter(hb, &postHtmlOptions{p: p})
 	} else {
@@ -137,6 +156,68 @@ func (a *goBlog) renderPostTax(hb *htmlbuilder.HtmlBuilder, p *post, b *configBl
 	}
 }
 
+// navigation inside a series of posts
+func (a *goBlog) renderPostSeries(hb *htmlbuilder.HtmlBuilder, p *post, b *configBlog) {
+	if b == nil || p == nil {
+		return
+	}
+	s := a.postSeries(p)
+	if s == nil || s.index < 0 {
+		return
+	}
+	hb.WriteElementOpen("nav", "class", "p series")
+	// Part N of M
+	hb.WriteElementOpen("p")
+	hb.WriteEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(b.Lang, "seriespart"), s.index+1, len(s.posts)))
+	hb.WriteEscaped(" ")
+	hb.WriteElementOpen("a", "href", b.getSeriesPath(s.name))
+	hb.WriteEscaped(a.renderMdTitle(s.name))
+	hb.WriteElementClose("a")
+	hb.WriteElementClose("p")
+	// Previous and next part
+	for _, link := range []struct {
+		p   *post
+		rel string
+	}{{s.prev(), "prev"}, {s.next(), "next"}} {
+		if link.p == nil {
+			continue
+		}
+		hb.WriteElementOpen("p")
+		hb.WriteEscaped(a.ts.GetTemplateStringVariant(b.Lang, link.rel))
+		hb.WriteEscaped(": ")
+		hb.WriteElementOpen("a", "href", link.p.Path, "rel", link.rel)
+		hb.WriteEscaped(cmp.Or(link.p.RenderedTitle, a.fallbackTitle(link.p)))
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("p")
+	}
+	hb.WriteElementClose("nav")
+}
+
+// list of related posts
+func (a *goBlog) renderRelatedPosts(hb *htmlbuilder.HtmlBuilder, p *post, b *configBlog) {
+	if p == nil || !b.relatedPostsEnabled() || !p.isPublicPublishedSectionPost() {
+		return
+	}
+	related, err := a.getRelatedPosts(p, b.RelatedPosts.Count)
+	if err != nil || len(related) == 0 {
+		return
+	}
+	hb.WriteElementOpen("div", "class", "p related")
+	hb.WriteElementOpen("h2")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(b.Lang, "relatedposts"))
+	hb.WriteElementClose("h2")
+	hb.WriteElementOpen("ul")
+	for _, rp := range related {
+		hb.WriteElementOpen("li")
+		hb.WriteElementOpen("a", "href", rp.Path)
+		hb.WriteEscaped(cmp.Or(rp.RenderedTitle, a.fallbackTitle(rp)))
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("li")
+	}
+	hb.WriteElementClose("ul")
+	hb.WriteElementClose("div")
+}
+
 // post meta information.
 // typ can be "summary", "post" or "preview".
 func (a *goBlog) renderPostMeta(hb *htmlbuilder.HtmlBuilder, p *post, b *configBlog, typ string) {
@@ -1015,6 +1096,44 @@ func (a *goBlog) renderSecuritySettings(hb *htmlbuilder.HtmlBuilder, rd *renderD
 	}
 }
 
+func (a *goBlog) renderTaxonomySettings(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	taxonomies := lo.Filter(rd.Blog.Taxonomies, func(t *configTaxonomy, _ int) bool { return t.Name != "" })
+	if len(taxonomies) == 0 {
+		return
+	}
+	hb.WriteElementOpen("h2")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomies"))
+	hb.WriteElementClose("h2")
+
+	hb.WriteElementOpen("p")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomiesdesc"))
+	hb.WriteElementClose("p")
+
+	hb.WriteElementOpen("ul")
+	for _, tax := range taxonomies {
+		hb.WriteElementOpen("li")
+		hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(settingsTaxonomyAdminPath(tax)))
+		hb.WriteEscaped(cmp.Or(a.renderMdTitle(tax.Title), tax.Name))
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("li")
+	}
+	hb.WriteElementClose("ul")
+}
+
+func (a *goBlog) renderBackupSettings(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
+	hb.WriteElementOpen("h2")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "backup"))
+	hb.WriteElementClose("h2")
+
+	hb.WriteElementOpen("p")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "backupdesc"))
+	hb.WriteElementClose("p")
+
+	hb.WriteElementOpen("form", "class", "fw p", "method", "get", "action", rd.Blog.getRelativePath(settingsPath+settingsBackupPath))
+	hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "downloadbackup"))
+	hb.WriteElementClose("form")
+}
+
 func (a *goBlog) renderAppPasswordCreated(hb *htmlbuilder.HtmlBuilder, rd *renderData) {
 	data, ok := rd.Data.(*appPasswordCreatedRenderData)
 	if !ok {
@@ -1121,3 +1240,27 @@ func (a *goBlog) renderSocialIcons(hb *htmlbuilder.HtmlBuilder, b *configBlog) {
 	}
 	hb.WriteElementClose("div")
 }
+
+func (*goBlog) renderLineDiff(hb *htmlbuilder.HtmlBuilder, diff []*revisionDiffLine) {
+	if len(diff) == 0 {
+		return
+	}
+	hb.WriteElementOpen("pre")
+	for _, line := range diff {
+		switch line.op {
+		case '-':
+			hb.WriteElementOpen("del")
+		case '+':
+			hb.WriteElementOpen("ins")
+		}
+		hb.WriteEscaped(string(line.op) + " " + line.text)
+		switch line.op {
+		case '-':
+			hb.WriteElementClose("del")
+		case '+':
+			hb.WriteElementClose("ins")
+		}
+		hb.WriteEscaped("\n")
+	}
+	hb.WriteElementClose("pre")
+}
@@ -18,8 +18,8 @@ import (
 const postParamWebmention = "webmention"
 
 func (a *goBlog) sendWebmentions(p *post) error {
-	if p.Status != statusPublished && p.Visibility != visibilityPublic && p.Visibility != visibilityUnlisted {
-		// Not published or unlisted
+	if (p.Status != statusPublished && p.Status != statusPublishedDeleted) || (p.Visibility != visibilityPublic && p.Visibility != visibilityUnlisted) {
+		// Not published (deleted published posts notify the targets) or not public or unlisted
 		return nil
 	}
 	if wm := a.cfg.Webmention; wm != nil && wm.DisableSending {
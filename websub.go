@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
+)
+
+// Implement WebSub publishing
+// https://www.w3.org/TR/websub/
+
+const webSubHubPath = "/websub"
+
+// The minified feeds first, so their extensions are checked before the shorter ones
+var webSubFeedTypes = []feedType{minRssFeed, minAtomFeed, minJsonFeed, rssFeed, atomFeed, jsonFeed}
+
+func (a *goBlog) initWebSub() {
+	if !a.webSubEnabled() {
+		return
+	}
+	// Add hooks
+	hook := func(p *post) {
+		// Check if post is published
+		if !p.isPublicPublishedSectionPost() {
+			return
+		}
+		a.webSubPublish(a.webSubTopics(p))
+	}
+	a.pPostHooks = append(a.pPostHooks, hook)
+	a.pUpdateHooks = append(a.pUpdateHooks, hook)
+	a.pUndeleteHooks = append(a.pUndeleteHooks, hook)
+	// The feeds also change when a published post gets deleted
+	a.pDeleteHooks = append(a.pDeleteHooks, func(p *post) {
+		if p.Section == "" || p.Visibility != visibilityPublic || strings.TrimSuffix(string(p.Status), string(statusDeletedSuffix)) != string(statusPublished) {
+			return
+		}
+		a.webSubPublish(a.webSubTopics(p))
+	})
+	// Distribute content and verify subscriptions of the built-in hub
+	if a.cfg.WebSub.BuiltInHub {
+		a.initWebSubQueue()
+	}
+}
+
+func (a *goBlog) webSubEnabled() bool {
+	// Check if private mode is enabled
+	if a.isPrivate() {
+		return false
+	}
+	// Check if WebSub is disabled or there's no hub
+	if wsc := a.cfg.WebSub; wsc == nil || !wsc.Enabled || (len(wsc.Hubs) == 0 && !wsc.BuiltInHub) {
+		return false
+	}
+	return true
+}
+
+// webSubHubs returns the hubs to advertise
+func (a *goBlog) webSubHubs() []string {
+	hubs := slices.Clone(a.cfg.WebSub.Hubs)
+	if a.cfg.WebSub.BuiltInHub {
+		hubs = append(hubs, a.getFullAddress(webSubHubPath))
+	}
+	return hubs
+}
+
+// webSubTopicURL returns the topic URL for the index path and feed type
+func (a *goBlog) webSubTopicURL(indexPath string, ft feedType) string {
+	if ft != noFeed {
+		indexPath += "." + string(ft)
+	}
+	return a.getFullAddress(indexPath)
+}
+
+// webSubIndexPaths returns the paths of the indexes showing the post: the blog home, the section and the taxonomy values
+func (a *goBlog) webSubIndexPaths(p *post) []string {
+	bc, ok := a.cfg.Blogs[p.Blog]
+	if !ok {
+		return nil
+	}
+	var paths []string
+	if !bc.PostAsHome {
+		paths = append(paths, bc.getRelativePath(""))
+	}
+	if section, ok := bc.Sections[p.Section]; ok {
+		paths = append(paths, bc.getRelativePath(section.Name))
+	}
+	for _, tax := range bc.Taxonomies {
+		for _, value := range p.Parameters[tax.Name] {
+			paths = append(paths, bc.getRelativePath("/"+tax.Name+"/"+urlize(value)))
+		}
+	}
+	return lo.Uniq(paths)
+}
+
+// webSubTopics returns the topics that change with the post
+func (a *goBlog) webSubTopics(p *post) (topics []string) {
+	for _, indexPath := range a.webSubIndexPaths(p) {
+		topics = append(topics, a.webSubTopicURL(indexPath, noFeed))
+		for _, ft := range webSubFeedTypes {
+			topics = append(topics, a.webSubTopicURL(indexPath, ft))
+		}
+	}
+	return topics
+}
+
+// isWebSubIndex checks if the index is a topic that gets published on changes
+func (a *goBlog) isWebSubIndex(r *http.Request, ic *indexConfig, bc *configBlog) bool {
+	if !a.webSubEnabled() {
+		return false
+	}
+	// Only the first page without search, date or parameter filters
+	if ic.search != "" || ic.year != 0 || ic.month != 0 || ic.day != 0 || ic.parameter != "" || ic.usesFile != "" ||
+		len(ic.status) > 0 || len(ic.visibility) > 0 || stringToInt(chi.URLParam(r, "page")) > 1 {
+		return false
+	}
+	for param := range r.URL.Query() {
+		if strings.HasPrefix(param, "p:") {
+			return false
+		}
+	}
+	return ic.section != nil || ic.tax != nil || ic.path == bc.getRelativePath("")
+}
+
+// setWebSubLinkHeaders advertises the hubs and the topic URL using HTTP Link headers
+func (a *goBlog) setWebSubLinkHeaders(w http.ResponseWriter, topic string) {
+	for _, hub := range a.webSubHubs() {
+		w.Header().Add("Link", "<"+hub+`>; rel="hub"`)
+	}
+	w.Header().Add("Link", "<"+topic+`>; rel="self"`)
+}
+
+// webSubPublish notifies the hubs about the updated topics
+func (a *goBlog) webSubPublish(topics []string) {
+	if !a.webSubEnabled() || len(topics) == 0 {
+		return
+	}
+	// Built-in hub
+	if a.cfg.WebSub.BuiltInHub {
+		if err := a.webSubDistribute(topics); err != nil {
+			a.error("WebSub distribution failed", "err", err)
+		}
+	}
+	// External hubs
+	for _, hub := range a.cfg.WebSub.Hubs {
+		err := requests.URL(hub).
+			Client(a.httpClient).
+			BodyForm(url.Values{
+				"hub.mode": {"publish"},
+				"hub.url":  topics,
+			}).
+			Fetch(context.Background())
+		if err != nil {
+			a.error("Sending WebSub publish request failed", "hub", hub, "err", err)
+			continue
+		}
+		a.info("WebSub publish request sent", "hub", hub, "topics", len(topics))
+	}
+}
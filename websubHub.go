@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+// Built-in WebSub hub, subscribers are verified and the content is distributed using the queue
+// https://www.w3.org/TR/websub/#hub
+
+const (
+	webSubQueueName = "websub"
+
+	webSubModeSubscribe   = "subscribe"
+	webSubModeUnsubscribe = "unsubscribe"
+	webSubModeDeliver     = "deliver"
+
+	webSubDefaultLease = 10 * 24 * time.Hour
+	webSubMaxLease     = 30 * 24 * time.Hour
+	webSubMaxSecretLen = 200
+	webSubMaxTries     = 10
+
+	// Limits against the abuse of the hub to flood other servers
+	webSubMaxHostSubscriptions = 100
+	webSubRateLimitRequests    = 30
+	webSubRateLimitWindow      = time.Minute
+)
+
+var (
+	webSubQueueInterval = 30 * time.Second
+	webSubLookupIP      = net.DefaultResolver.LookupNetIP
+)
+
+type webSubRequest struct {
+	Mode, Topic, Callback, Secret string
+	Lease                         int // Seconds
+	Try                           int
+}
+
+func (r *webSubRequest) encode(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(r)
+}
+
+func (a *goBlog) webSubEnqueue(r *webSubRequest) error {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := r.encode(buf); err != nil {
+		return err
+	}
+	return a.enqueue(webSubQueueName, buf.Bytes(), time.Now())
+}
+
+func (a *goBlog) initWebSubQueue() {
+	a.listenOnQueue(webSubQueueName, webSubQueueInterval, a.processWebSubQueueItem)
+}
+
+func (a *goBlog) processWebSubQueueItem(qi *queueItem, dequeue func(), reschedule func(time.Duration)) {
+	var r webSubRequest
+	if err := gob.NewDecoder(bytes.NewReader(qi.content)).Decode(&r); err != nil {
+		a.error("WebSub queue", "err", err)
+		dequeue()
+		return
+	}
+	switch r.Mode {
+	case webSubModeSubscribe, webSubModeUnsubscribe:
+		// The subscriber has to request a new subscription if the verification fails
+		if err := a.webSubVerify(&r); err != nil {
+			a.info("WebSub verification failed", "mode", r.Mode, "callback", r.Callback, "err", err)
+		}
+	case webSubModeDeliver:
+		if err := a.webSubDeliver(&r); err != nil {
+			if r.Try++; r.Try < webSubMaxTries {
+				// Try it again
+				buf := bufferpool.Get()
+				_ = r.encode(buf)
+				qi.content = buf.Bytes()
+				reschedule(time.Duration(r.Try) * 10 * time.Minute)
+				bufferpool.Put(buf)
+				return
+			}
+			a.info("WebSub delivery failed for the 10th time, giving up", "callback", r.Callback, "err", err)
+		}
+	}
+	dequeue()
+}
+
+// serveWebSubHub handles subscription requests, the verification of intent happens asynchronously
+func (a *goBlog) serveWebSubHub(w http.ResponseWriter, r *http.Request) {
+	if a.webSubRateLimiter.limited(remoteIP(r), webSubRateLimitRequests, webSubRateLimitWindow) {
+		a.serveError(w, r, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &webSubRequest{
+		Mode:     r.Form.Get("hub.mode"),
+		Topic:    r.Form.Get("hub.topic"),
+		Callback: r.Form.Get("hub.callback"),
+		Secret:   r.Form.Get("hub.secret"),
+		Lease:    int(webSubDefaultLease.Seconds()),
+	}
+	if req.Mode != webSubModeSubscribe && req.Mode != webSubModeUnsubscribe {
+		a.serveError(w, r, "unsupported hub.mode", http.StatusBadRequest)
+		return
+	}
+	if !a.isWebSubTopic(req.Topic) {
+		a.serveError(w, r, "unknown hub.topic", http.StatusBadRequest)
+		return
+	}
+	cu, err := url.Parse(req.Callback)
+	if err != nil || (cu.Scheme != "http" && cu.Scheme != "https") || cu.Host == "" || !webSubPublicHost(r.Context(), cu.Hostname()) {
+		a.serveError(w, r, "invalid hub.callback", http.StatusBadRequest)
+		return
+	}
+	if len(req.Secret) >= webSubMaxSecretLen {
+		a.serveError(w, r, "hub.secret too long", http.StatusBadRequest)
+		return
+	}
+	if lease := stringToInt(r.Form.Get("hub.lease_seconds")); lease > 0 {
+		req.Lease = min(lease, int(webSubMaxLease.Seconds()))
+	}
+	if req.Mode == webSubModeSubscribe {
+		if full, err := a.db.webSubHostLimitReached(req.Topic, req.Callback); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		} else if full {
+			a.serveError(w, r, "too many subscriptions for the hub.callback host", http.StatusForbidden)
+			return
+		}
+	}
+	if err := a.webSubEnqueue(req); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// webSubPublicHost checks if the host only resolves to public addresses,
+// the HTTP client checks the address again when connecting
+func webSubPublicHost(ctx context.Context, host string) bool {
+	ips, err := webSubLookupIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webSubHostLimitReached checks if the host of the callback has the maximum number of subscriptions,
+// renewing an existing subscription is always possible
+func (db *database) webSubHostLimitReached(topic, callback string) (bool, error) {
+	cu, err := url.Parse(callback)
+	if err != nil {
+		return false, err
+	}
+	rows, err := db.Query(
+		"select topic, callback from websub_subscriptions where expires > @now",
+		sql.Named("now", utcNowString()),
+	)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		var subTopic, subCallback string
+		if err = rows.Scan(&subTopic, &subCallback); err != nil {
+			return false, err
+		}
+		if subTopic == topic && subCallback == callback {
+			return false, nil
+		}
+		if su, err := url.Parse(subCallback); err == nil && strings.EqualFold(su.Hostname(), cu.Hostname()) {
+			count++
+		}
+	}
+	return count >= webSubMaxHostSubscriptions, rows.Err()
+}
+
+// isWebSubTopic checks if the URL is a topic of the blogs: a home, section or taxonomy value index or its feed
+func (a *goBlog) isWebSubTopic(topic string) bool {
+	topicPath, ok := strings.CutPrefix(topic, a.getFullAddress(""))
+	if !ok {
+		return false
+	}
+	topicPath = cmp.Or(topicPath, "/")
+	for _, ft := range webSubFeedTypes {
+		if p, ok := strings.CutSuffix(topicPath, "."+string(ft)); ok {
+			topicPath = cmp.Or(p, "/")
+			break
+		}
+	}
+	for _, bc := range a.cfg.Blogs {
+		if !bc.PostAsHome && topicPath == bc.getRelativePath("") {
+			return true
+		}
+		for _, section := range bc.Sections {
+			if topicPath == bc.getRelativePath(section.Name) {
+				return true
+			}
+		}
+		for _, tax := range bc.Taxonomies {
+			if value, ok := strings.CutPrefix(topicPath, bc.getRelativePath(tax.Name)+"/"); ok && value != "" && !strings.Contains(value, "/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// webSubVerify verifies the intent of the subscriber and saves or deletes the subscription
+func (a *goBlog) webSubVerify(r *webSubRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	challenge := randomString(32)
+	var body string
+	rb := requests.URL(r.Callback).
+		Client(a.publicHttpClient).
+		Param("hub.mode", r.Mode).
+		Param("hub.topic", r.Topic).
+		Param("hub.challenge", challenge).
+		ToString(&body)
+	if r.Mode == webSubModeSubscribe {
+		rb.Param("hub.lease_seconds", strconv.Itoa(r.Lease))
+	}
+	if err := rb.Fetch(ctx); err != nil {
+		return err
+	}
+	if body != challenge {
+		return errors.New("challenge doesn't match")
+	}
+	if r.Mode == webSubModeUnsubscribe {
+		return a.db.deleteWebSubSubscription(r.Topic, r.Callback)
+	}
+	// Other subscriptions of the host could have been verified in the meantime
+	if full, err := a.db.webSubHostLimitReached(r.Topic, r.Callback); err != nil {
+		return err
+	} else if full {
+		return errors.New("too many subscriptions for the callback host")
+	}
+	expires := time.Now().Add(time.Duration(r.Lease) * time.Second)
+	_, err := a.db.Exec(
+		"insert or replace into websub_subscriptions (topic, callback, secret, expires) values (@topic, @callback, @secret, @expires)",
+		sql.Named("topic", r.Topic),
+		sql.Named("callback", r.Callback),
+		sql.Named("secret", r.Secret),
+		sql.Named("expires", expires.UTC().Format(time.RFC3339)),
+	)
+	if err == nil {
+		a.info("WebSub subscription verified", "topic", r.Topic, "callback", r.Callback)
+	}
+	return err
+}
+
+func (db *database) deleteWebSubSubscription(topic, callback string) error {
+	_, err := db.Exec(
+		"delete from websub_subscriptions where topic = @topic and callback = @callback",
+		sql.Named("topic", topic), sql.Named("callback", callback),
+	)
+	return err
+}
+
+// getWebSubSecret returns the secret of the subscription and false if there is no active subscription
+func (db *database) getWebSubSecret(topic, callback string) (string, bool, error) {
+	row, err := db.QueryRow(
+		"select secret from websub_subscriptions where topic = @topic and callback = @callback and expires > @now",
+		sql.Named("topic", topic), sql.Named("callback", callback), sql.Named("now", utcNowString()),
+	)
+	if err != nil {
+		return "", false, err
+	}
+	var secret string
+	if err = row.Scan(&secret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return secret, true, nil
+}
+
+// webSubDistribute queues the content distribution to the subscribers of the topics
+func (a *goBlog) webSubDistribute(topics []string) error {
+	now := utcNowString()
+	if _, err := a.db.Exec("delete from websub_subscriptions where expires <= @now", sql.Named("now", now)); err != nil {
+		return err
+	}
+	for _, topic := range topics {
+		rows, err := a.db.Query(
+			"select callback from websub_subscriptions where topic = @topic",
+			sql.Named("topic", topic),
+		)
+		if err != nil {
+			return err
+		}
+		var callbacks []string
+		for rows.Next() {
+			var callback string
+			if err = rows.Scan(&callback); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			callbacks = append(callbacks, callback)
+		}
+		_ = rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		for _, callback := range callbacks {
+			if err = a.webSubEnqueue(&webSubRequest{Mode: webSubModeDeliver, Topic: topic, Callback: callback}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// webSubDeliver sends the current content of the topic to the subscriber
+func (a *goBlog) webSubDeliver(r *webSubRequest) error {
+	secret, subscribed, err := a.db.getWebSubSecret(r.Topic, r.Callback)
+	if err != nil || !subscribed {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	// Render the topic
+	topicReq, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Topic, nil)
+	if err != nil {
+		return err
+	}
+	if topicReq.URL.Path == "" {
+		topicReq.URL.Path = "/"
+	}
+	topicResp, err := newHandlerClient(a.d).Do(topicReq)
+	if err != nil {
+		return err
+	}
+	defer topicResp.Body.Close()
+	if topicResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("topic returned status %d", topicResp.StatusCode)
+	}
+	content, err := io.ReadAll(topicResp.Body)
+	if err != nil {
+		return err
+	}
+	// Send the content
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Callback, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(contentType, topicResp.Header.Get(contentType))
+	req.Header.Set("Link", fmt.Sprintf(`<%s>; rel="hub", <%s>; rel="self"`, a.getFullAddress(webSubHubPath), r.Topic))
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write(content)
+		req.Header.Set("X-Hub-Signature", fmt.Sprintf("sha256=%x", mac.Sum(nil)))
+	}
+	resp, err := a.publicHttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusGone {
+		// The subscriber doesn't want updates anymore
+		return a.db.deleteWebSubSubscription(r.Topic, r.Callback)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webSubTopics(t *testing.T) {
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	app.cfg.WebSub = &configWebSub{Enabled: true, BuiltInHub: true}
+	require.NoError(t, app.initConfig(false))
+
+	topics := app.webSubTopics(&post{
+		Blog:       app.cfg.DefaultBlog,
+		Section:    "posts",
+		Parameters: map[string][]string{"tags": {"Go Lang"}},
+	})
+	assert.Len(t, topics, 21)
+	assert.Contains(t, topics, "http://localhost:8080")
+	assert.Contains(t, topics, "http://localhost:8080/.rss")
+	assert.Contains(t, topics, "http://localhost:8080/posts.min.atom")
+	assert.Contains(t, topics, "http://localhost:8080/tags/go-lang.json")
+	for _, topic := range topics {
+		assert.True(t, app.isWebSubTopic(topic), topic)
+	}
+
+	assert.False(t, app.isWebSubTopic("https://example.com/"))
+	assert.False(t, app.isWebSubTopic("http://localhost:8080/unknown.rss"))
+	assert.False(t, app.isWebSubTopic("http://localhost:8080/tags/go/page/2"))
+}
+
+func Test_webSubPublishing(t *testing.T) {
+	fc := newFakeHttpClient()
+	fc.setFakeResponse(http.StatusNoContent, "")
+
+	app := &goBlog{
+		cfg:        createDefaultTestConfig(t),
+		httpClient: fc.Client,
+	}
+	app.cfg.WebSub = &configWebSub{Enabled: true, Hubs: []string{"https://hub.example.com/"}}
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.initWebSub()
+	app.d = app.buildRouter()
+
+	t.Run("Advertise hubs", func(t *testing.T) {
+		for path, self := range map[string]string{
+			"/":           "http://localhost:8080",
+			"/.rss":       "http://localhost:8080/.rss",
+			"/posts":      "http://localhost:8080/posts",
+			"/posts.json": "http://localhost:8080/posts.json",
+		} {
+			rec := httptest.NewRecorder()
+			app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusOK, rec.Code, path)
+			assert.Equal(t, []string{`<https://hub.example.com/>; rel="hub"`, "<" + self + `>; rel="self"`}, rec.Header().Values("Link"), path)
+		}
+
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts", nil))
+		assert.Contains(t, rec.Body.String(), `<link rel=hub href=https://hub.example.com/>`)
+		assert.Contains(t, rec.Body.String(), `<link rel=self href=http://localhost:8080/posts>`)
+
+		// Other indexes aren't topics
+		for _, path := range []string{"/page/2", "/2024", "/?p:title=test"} {
+			rec := httptest.NewRecorder()
+			app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Empty(t, rec.Header().Values("Link"), path)
+		}
+	})
+
+	t.Run("Ping hub", func(t *testing.T) {
+		require.NoError(t, app.createPost(&post{
+			Section:    "posts",
+			Path:       "/testpost",
+			Content:    "Test",
+			Parameters: map[string][]string{"tags": {"Test"}},
+		}))
+		app.postHooksWg.Wait()
+
+		fc.mu.Lock()
+		defer fc.mu.Unlock()
+		require.NotNil(t, fc.req)
+		assert.Equal(t, "https://hub.example.com/", fc.req.URL.String())
+		require.NoError(t, fc.req.ParseForm())
+		assert.Equal(t, "publish", fc.req.Form.Get("hub.mode"))
+		assert.Len(t, fc.req.Form["hub.url"], 21)
+		assert.Contains(t, fc.req.Form["hub.url"], "http://localhost:8080/tags/test.rss")
+	})
+}
+
+func Test_webSubHub(t *testing.T) {
+	// Fake subscriber
+	var mu sync.Mutex
+	var verifications []url.Values
+	var deliveries []*http.Request
+	var deliveryBodies []string
+	deliveryStatus := http.StatusOK
+	fc := newFakeHttpClient()
+	fc.setHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			verifications = append(verifications, r.URL.Query())
+			_, _ = io.WriteString(w, r.URL.Query().Get("hub.challenge"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		deliveries = append(deliveries, r)
+		deliveryBodies = append(deliveryBodies, string(body))
+		w.WriteHeader(deliveryStatus)
+	}))
+
+	// The subscriber has a public address
+	origLookupIP := webSubLookupIP
+	t.Cleanup(func() { webSubLookupIP = origLookupIP })
+	webSubLookupIP = func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		if strings.EqualFold(host, "subscriber.example.com") {
+			return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+		}
+		return origLookupIP(ctx, network, host)
+	}
+
+	app := &goBlog{
+		cfg:              createDefaultTestConfig(t),
+		publicHttpClient: fc.Client,
+	}
+	app.cfg.WebSub = &configWebSub{Enabled: true, BuiltInHub: true}
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.d = app.buildRouter()
+
+	processQueue := func() {
+		items, err := app.getQueueItems(webSubQueueName)
+		require.NoError(t, err)
+		for _, qi := range items {
+			app.processWebSubQueueItem(qi, func() { _ = app.dequeue(qi) }, func(d time.Duration) { _ = app.reschedule(qi, d) })
+		}
+	}
+	hubRequest := func(values url.Values) int {
+		req := httptest.NewRequest(http.MethodPost, webSubHubPath, strings.NewReader(values.Encode()))
+		req.Header.Set(contentType, "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	const topic, callback = "http://localhost:8080/posts.atom", "https://subscriber.example.com/callback?id=1"
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, hubRequest(url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}))
+		assert.Equal(t, http.StatusBadRequest, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://example.com/feed"}, "hub.callback": {callback}}))
+		assert.Equal(t, http.StatusBadRequest, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {"/relative"}}))
+		assert.Equal(t, http.StatusBadRequest, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {callback}, "hub.secret": {strings.Repeat("a", 200)}}))
+		// Callbacks with non-public addresses
+		for _, cb := range []string{"http://localhost:8080/callback", "http://127.0.0.1/callback", "http://[::1]/callback", "http://169.254.169.254/latest/meta-data", "http://192.168.1.1/callback"} {
+			assert.Equal(t, http.StatusBadRequest, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {cb}}), cb)
+		}
+		items, err := app.getQueueItems(webSubQueueName)
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("Subscribe", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, hubRequest(url.Values{
+			"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {callback},
+			"hub.secret": {"secret"}, "hub.lease_seconds": {"99999999"},
+		}))
+		processQueue()
+
+		mu.Lock()
+		require.Len(t, verifications, 1)
+		assert.Equal(t, "subscribe", verifications[0].Get("hub.mode"))
+		assert.Equal(t, topic, verifications[0].Get("hub.topic"))
+		assert.Equal(t, "1", verifications[0].Get("id"))
+		assert.Equal(t, "2592000", verifications[0].Get("hub.lease_seconds"))
+		mu.Unlock()
+
+		secret, subscribed, err := app.db.getWebSubSecret(topic, callback)
+		require.NoError(t, err)
+		assert.True(t, subscribed)
+		assert.Equal(t, "secret", secret)
+	})
+
+	t.Run("Distribute content", func(t *testing.T) {
+		p := &post{Section: "posts", Path: "/testpost", Content: "Distributed content"}
+		require.NoError(t, app.createPost(p))
+		app.webSubPublish(app.webSubTopics(p))
+		processQueue()
+
+		mu.Lock()
+		require.Len(t, deliveries, 1)
+		assert.Contains(t, deliveries[0].Header.Get(contentType), "application/atom+xml")
+		assert.Equal(t, `<http://localhost:8080/websub>; rel="hub", <`+topic+`>; rel="self"`, deliveries[0].Header.Get("Link"))
+		assert.Contains(t, deliveryBodies[0], "Distributed content")
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(deliveryBodies[0]))
+		assert.Equal(t, fmt.Sprintf("sha256=%x", mac.Sum(nil)), deliveries[0].Header.Get("X-Hub-Signature"))
+		mu.Unlock()
+	})
+
+	t.Run("Retry failed delivery", func(t *testing.T) {
+		mu.Lock()
+		deliveryStatus = http.StatusInternalServerError
+		mu.Unlock()
+		app.webSubPublish([]string{topic})
+		processQueue()
+
+		// The delivery is rescheduled
+		items, err := app.getQueueItems(webSubQueueName)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.True(t, items[0].schedule.After(time.Now()))
+
+		// Gone removes the subscription
+		mu.Lock()
+		deliveryStatus = http.StatusGone
+		mu.Unlock()
+		require.NoError(t, app.setQueueSchedule(items[0], time.Now()))
+		processQueue()
+		_, subscribed, err := app.db.getWebSubSecret(topic, callback)
+		require.NoError(t, err)
+		assert.False(t, subscribed)
+		items, err = app.getQueueItems(webSubQueueName)
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {callback}}))
+		processQueue()
+		_, subscribed, err := app.db.getWebSubSecret(topic, callback)
+		require.NoError(t, err)
+		assert.True(t, subscribed)
+
+		assert.Equal(t, http.StatusAccepted, hubRequest(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.callback": {callback}}))
+		processQueue()
+		_, subscribed, err = app.db.getWebSubSecret(topic, callback)
+		require.NoError(t, err)
+		assert.False(t, subscribed)
+	})
+
+	t.Run("Subscriptions per host", func(t *testing.T) {
+		expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		for i := range webSubMaxHostSubscriptions {
+			_, err := app.db.Exec(
+				"insert into websub_subscriptions (topic, callback, secret, expires) values (@topic, @callback, '', @expires)",
+				sql.Named("topic", topic), sql.Named("callback", fmt.Sprintf("https://SUBSCRIBER.example.com/callback?id=%d", i+10)), sql.Named("expires", expires),
+			)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, http.StatusForbidden, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {callback}}))
+		// Renewing an existing subscription and unsubscribing still works
+		assert.Equal(t, http.StatusAccepted, hubRequest(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.callback": {"https://SUBSCRIBER.example.com/callback?id=10"}}))
+		assert.Equal(t, http.StatusAccepted, hubRequest(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.callback": {callback}}))
+		processQueue()
+	})
+
+	t.Run("Rate limit", func(t *testing.T) {
+		app.webSubRateLimiter.reset()
+		codes := map[int]int{}
+		for range webSubRateLimitRequests {
+			codes[hubRequest(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.callback": {callback}})]++
+		}
+		assert.Equal(t, http.StatusTooManyRequests, hubRequest(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.callback": {callback}}))
+		assert.Zero(t, codes[http.StatusTooManyRequests])
+
+		// Other clients aren't affected
+		req := httptest.NewRequest(http.MethodPost, webSubHubPath, strings.NewReader(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.callback": {callback}}.Encode()))
+		req.Header.Set(contentType, "application/x-www-form-urlencoded")
+		req.RemoteAddr = "198.51.100.1:1234"
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	})
+}